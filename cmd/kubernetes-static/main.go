@@ -76,6 +76,7 @@ func main() {
 		apiServerClient,
 		"ens5",
 		true,
+		nil,
 		podsFetcher.FetchFuncWithCache(),
 		metric2.CadvisorFetchFunc(kubeletClient, metric.CadvisorQueries))
 	// KSM
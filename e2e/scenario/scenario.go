@@ -92,6 +92,7 @@ func defaultEventTypeToSchemaFilename() map[string]jsonschema.EventTypeToSchemaF
 			"K8sNodeSample":      "node.json",
 			"K8sVolumeSample":    "volume.json",
 			"K8sClusterSample":   "cluster.json",
+			"K8sKubeletSample":   "kubelet.json",
 		},
 		"scheduler": {
 			"K8sSchedulerSample": "scheduler.json",
@@ -3,13 +3,10 @@ module github.com/newrelic/nri-kubernetes
 go 1.14
 
 require (
-	cloud.google.com/go v0.24.1-0.20180622202109-d84cd321885b // indirect
 	github.com/docker/spdystream v0.0.0-20170912183627-bc6354cbbc29 // indirect
 	github.com/elazarl/goproxy v0.0.0-20180530192236-91d82cc1070b // indirect
-	github.com/ghodss/yaml v1.0.0 // indirect
 	github.com/gogo/protobuf v0.0.0-20171213104750-35b81a066e52 // indirect
-	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b // indirect
-	github.com/golang/protobuf v1.2.0
+	github.com/golang/protobuf v1.5.2
 	github.com/google/gofuzz v0.0.0-20170612174753-24818f796faf // indirect
 	github.com/googleapis/gnostic v0.1.1-0.20171211024024-933c109c13ce // indirect
 	github.com/howeyc/gopass v0.0.0-20170109162249-bf9dde6d0d2c // indirect
@@ -17,23 +14,23 @@ require (
 	github.com/json-iterator/go v0.0.0-20171223025217-96fcb84835b0 // indirect
 	github.com/newrelic/infra-integrations-sdk v2.0.1-0.20180410150501-14a5386f9150+incompatible
 	github.com/pkg/errors v0.8.0
-	github.com/prometheus/client_model v0.0.0-20180712105110-5c3871d89910
+	github.com/prometheus/client_model v0.0.0-20190812154241-14fe0d1b01d4
+	github.com/prometheus/common v0.0.0-20181126121408-4724e9255275
 	github.com/prometheus/prom2json v1.1.1-0.20190107153843-47e3ee600b5a
 	github.com/segmentio/go-camelcase v0.0.0-20160726192923-7085f1e3c734
 	github.com/sirupsen/logrus v1.2.0
 	github.com/spf13/pflag v1.0.1-0.20171106142849-4c012f6dcd95 // indirect
-	github.com/stretchr/testify v1.2.2
+	github.com/stretchr/testify v1.7.0
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
 	github.com/xeipuuv/gojsonschema v0.0.0-20180525140004-9ff6d6c47f3f
-	golang.org/x/net v0.0.0-20180621144259-afe8f62b1d6b
-	golang.org/x/oauth2 v0.0.0-20180620175406-ef147856a6dd
-	golang.org/x/text v0.3.1-0.20180511172408-5c1cf69b5978 // indirect
-	golang.org/x/time v0.0.0-20180412165947-fbb02b2291d2 // indirect
-	google.golang.org/appengine v1.1.0 // indirect
-	gopkg.in/check.v1 v1.0.0-20161208181325-20d25e280405 // indirect
+	go.opentelemetry.io/proto/otlp v0.19.0
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+	golang.org/x/oauth2 v0.0.0-20211104180415-d3ed0bb246c8
+	google.golang.org/grpc v1.42.0
+	google.golang.org/protobuf v1.27.1
 	gopkg.in/inf.v0 v0.9.0 // indirect
-	gopkg.in/yaml.v2 v2.0.0-20171116090243-287cf08546ab // indirect
+	gopkg.in/yaml.v2 v2.2.3
 	k8s.io/api v0.0.0-20180521142803-feb48db456a5
 	k8s.io/apimachinery v0.0.0-20180515182440-31dade610c05
 	k8s.io/client-go v7.0.0+incompatible
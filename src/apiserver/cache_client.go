@@ -32,12 +32,15 @@ func (currentTimeProvider) Time() time.Time { return time.Now() }
 
 // NewFileCacheClientWrapper wraps the given Client and caches the responses for the given cacheDuration.
 func NewFileCacheClientWrapper(client Client, cacheDir string, cacheDuration time.Duration, options ...Option) Client {
+	return NewCacheClientWrapper(client, storage.NewJSONDiskStorage(cacheDir), cacheDuration, options...)
+}
 
-	diskStore := storage.NewJSONDiskStorage(cacheDir)
-
+// NewCacheClientWrapper wraps the given Client and caches the responses in the given storage for the given
+// cacheDuration.
+func NewCacheClientWrapper(client Client, cache storage.Storage, cacheDuration time.Duration, options ...Option) Client {
 	fcc := &fileCacheClient{
 		client:        client,
-		cache:         diskStore,
+		cache:         cache,
 		cacheDuration: cacheDuration,
 		timeProvider:  currentTimeProvider(0),
 	}
@@ -49,7 +52,7 @@ func NewFileCacheClientWrapper(client Client, cacheDir string, cacheDuration tim
 	return fcc
 }
 
-// fileCacheClient is an API Server client wrapper that caches responses on disk.
+// fileCacheClient is an API Server client wrapper that caches responses in a storage, usually on disk.
 type fileCacheClient struct {
 	client        Client
 	cache         storage.Storage
@@ -104,6 +107,38 @@ func (f *fileCacheClient) GetNodeInfo(nodeName string) (*NodeInfo, error) {
 	return n, f.store(n, n.NodeName)
 }
 
+func (f *fileCacheClient) GetNamespaceInfo(namespace string) (*NamespaceInfo, error) {
+
+	n := &NamespaceInfo{}
+
+	if f.load(n, namespace) {
+		return n, nil
+	}
+
+	n, err := f.client.GetNamespaceInfo(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, f.store(n, n.Name)
+}
+
+func (f *fileCacheClient) GetOwnerInfo(kind, name, namespace string) (*OwnerInfo, error) {
+	key := fmt.Sprintf("%s_%s_%s", kind, namespace, name)
+	o := &OwnerInfo{}
+
+	if f.load(o, key) {
+		return o, nil
+	}
+
+	o, err := f.client.GetOwnerInfo(kind, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, f.store(o, key)
+}
+
 func (f *fileCacheClient) GetServerVersion() (*version.Info, error) {
 	const key = "k8sVersion"
 	k8sVersion := &version.Info{}
@@ -4,13 +4,16 @@ import (
 	"github.com/newrelic/nri-kubernetes/src/client"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/version"
 )
 
 // Client an interface for querying the k8s API server
 type Client interface {
 	GetNodeInfo(nodeName string) (*NodeInfo, error)
+	GetNamespaceInfo(namespace string) (*NamespaceInfo, error)
 	GetServerVersion() (*version.Info, error)
+	GetOwnerInfo(kind, name, namespace string) (*OwnerInfo, error)
 }
 
 // NewClient creates a new API Server client
@@ -39,19 +42,88 @@ func (c clientImpl) GetNodeInfo(nodeName string) (*NodeInfo, error) {
 	}
 
 	return &NodeInfo{
-		NodeName:    node.ObjectMeta.Name,
-		Labels:      node.Labels,
-		Allocatable: node.Status.Allocatable,
-		Capacity:    node.Status.Capacity,
+		NodeName:       node.ObjectMeta.Name,
+		Labels:         node.Labels,
+		Allocatable:    node.Status.Allocatable,
+		Capacity:       node.Status.Capacity,
+		KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		Conditions:     node.Status.Conditions,
+		Taints:         node.Spec.Taints,
+		Unschedulable:  node.Spec.Unschedulable,
 	}, nil
 }
 
+// GetNamespaceInfo queries the API server for information about the given namespace
+func (c clientImpl) GetNamespaceInfo(namespace string) (*NamespaceInfo, error) {
+	ns, err := c.k8sClient.FindNamespace(namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find namespace information for namespace='%s'", namespace)
+	}
+
+	return &NamespaceInfo{
+		Name:   ns.ObjectMeta.Name,
+		Labels: ns.Labels,
+	}, nil
+}
+
+// GetOwnerInfo queries the API server for information about the given controller owning pods
+func (c clientImpl) GetOwnerInfo(kind, name, namespace string) (*OwnerInfo, error) {
+	owner, err := c.k8sClient.FindOwner(kind, name, namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find owner information for %s='%s/%s'", kind, namespace, name)
+	}
+
+	info := &OwnerInfo{
+		Kind:      kind,
+		Name:      owner.Name,
+		Namespace: owner.Namespace,
+	}
+	if ref := controllerOf(owner.OwnerReferences); ref != nil {
+		info.OwnerKind = ref.Kind
+		info.OwnerName = ref.Name
+	}
+	return info, nil
+}
+
+// controllerOf returns the reference to the controller among the given owner references, or the first of them if
+// none is marked as the controller.
+func controllerOf(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	if len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
+}
+
+// OwnerInfo contains information about a controller owning pods, and about its own owner, if any
+type OwnerInfo struct {
+	Kind      string
+	Name      string
+	Namespace string
+	OwnerKind string
+	OwnerName string
+}
+
+// NamespaceInfo contains information about a specific namespace
+type NamespaceInfo struct {
+	Name   string
+	Labels map[string]string
+}
+
 // NodeInfo contains information about a specific node
 type NodeInfo struct {
-	NodeName    string
-	Labels      map[string]string
-	Allocatable v1.ResourceList
-	Capacity    v1.ResourceList
+	NodeName       string
+	Labels         map[string]string
+	Allocatable    v1.ResourceList
+	Capacity       v1.ResourceList
+	KubeletVersion string
+	Conditions     []v1.NodeCondition
+	Taints         []v1.Taint
+	Unschedulable  bool
 }
 
 // IsMasterNode returns true if the NodeInfo contains the labels that
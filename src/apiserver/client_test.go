@@ -1,16 +1,19 @@
 package apiserver
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/newrelic/nri-kubernetes/src/client"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func getTempDir(t *testing.T) (string, func()) {
@@ -145,3 +148,135 @@ type manualTimeProvider struct {
 }
 
 func (m manualTimeProvider) Time() time.Time { return m.time }
+
+// TestFileCacheNamespaceInfo tests whether the fileCache will store the namespaces and read them from the cache
+func TestFileCacheNamespaceInfo(t *testing.T) {
+
+	dir, cleanup := getTempDir(t)
+	defer cleanup()
+
+	myNamespace := &NamespaceInfo{Name: "my-namespace", Labels: map[string]string{"team": "platform"}}
+	client := TestAPIServer{Namespaces: map[string]*NamespaceInfo{"my-namespace": myNamespace}}
+
+	cacheWrapper := NewFileCacheClientWrapper(client, dir, time.Hour)
+
+	ns, err := cacheWrapper.GetNamespaceInfo("my-namespace")
+	assert.NoError(t, err)
+	assert.Equal(t, myNamespace, ns)
+
+	// The namespace is read from the cache once it is removed from the API server
+	delete(client.Namespaces, "my-namespace")
+	ns, err = cacheWrapper.GetNamespaceInfo("my-namespace")
+	assert.NoError(t, err)
+	assert.Equal(t, myNamespace, ns)
+
+	_, err = cacheWrapper.GetNamespaceInfo("missing")
+	assert.Error(t, err)
+}
+
+// TestFileCacheOwnerInfo tests whether the fileCache will store the owners and read them from the cache
+func TestFileCacheOwnerInfo(t *testing.T) {
+
+	dir, cleanup := getTempDir(t)
+	defer cleanup()
+
+	replicaSet := &OwnerInfo{Kind: "ReplicaSet", Name: "web-5d4f8c", Namespace: "default", OwnerKind: "Deployment", OwnerName: "web"}
+	client := TestAPIServer{Owners: map[string]*OwnerInfo{"ReplicaSet/default/web-5d4f8c": replicaSet}}
+
+	cacheWrapper := NewFileCacheClientWrapper(client, dir, time.Hour)
+
+	o, err := cacheWrapper.GetOwnerInfo("ReplicaSet", "web-5d4f8c", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, replicaSet, o)
+
+	delete(client.Owners, "ReplicaSet/default/web-5d4f8c")
+	o, err = cacheWrapper.GetOwnerInfo("ReplicaSet", "web-5d4f8c", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, replicaSet, o)
+
+	// Owners with the same name but another kind are not mixed up
+	_, err = cacheWrapper.GetOwnerInfo("Job", "web-5d4f8c", "default")
+	assert.Error(t, err)
+}
+
+func TestGetOwnerInfo(t *testing.T) {
+	isController := true
+	k8s := &client.MockedKubernetes{}
+	k8s.On("FindOwner", "Job", "backup-1589", "default").Return(&metav1.ObjectMeta{
+		Name:      "backup-1589",
+		Namespace: "default",
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "Backup", Name: "nightly"},
+			{Kind: "CronJob", Name: "backup", Controller: &isController},
+		},
+	}, nil)
+	k8s.On("FindOwner", "Job", "missing", "default").Return((*metav1.ObjectMeta)(nil), errors.New("not found"))
+
+	o, err := NewClient(k8s).GetOwnerInfo("Job", "backup-1589", "default")
+	require.NoError(t, err)
+	assert.Equal(t, &OwnerInfo{
+		Kind:      "Job",
+		Name:      "backup-1589",
+		Namespace: "default",
+		OwnerKind: "CronJob",
+		OwnerName: "backup",
+	}, o)
+
+	_, err = NewClient(k8s).GetOwnerInfo("Job", "missing", "default")
+	assert.EqualError(t, err, "could not find owner information for Job='default/missing': not found")
+}
+
+func TestGetNodeInfo(t *testing.T) {
+	transition := metav1.NewTime(time.Date(2020, 10, 14, 8, 30, 0, 0, time.UTC))
+	k8s := &client.MockedKubernetes{}
+	k8s.On("FindNode", "MyNode").Return(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "MyNode"},
+		Spec: v1.NodeSpec{
+			Unschedulable: true,
+			Taints: []v1.Taint{
+				{Key: "node.kubernetes.io/unschedulable", Effect: v1.TaintEffectNoSchedule},
+			},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue, LastTransitionTime: transition},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse, LastTransitionTime: transition},
+			},
+			NodeInfo: v1.NodeSystemInfo{KubeletVersion: "v1.18.3"},
+		},
+	}, nil)
+
+	n, err := NewClient(k8s).GetNodeInfo("MyNode")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.18.3", n.KubeletVersion)
+	assert.True(t, n.Unschedulable)
+	assert.Equal(t, []v1.Taint{{Key: "node.kubernetes.io/unschedulable", Effect: v1.TaintEffectNoSchedule}}, n.Taints)
+	require.Len(t, n.Conditions, 2)
+	assert.Equal(t, v1.NodeReady, n.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionTrue, n.Conditions[0].Status)
+}
+
+// TestFileCacheNodeConditions tests whether the conditions and taints of the nodes survive the fileCache
+func TestFileCacheNodeConditions(t *testing.T) {
+	dir, cleanup := getTempDir(t)
+	defer cleanup()
+
+	myNode := &NodeInfo{
+		NodeName: "MyNode",
+		Conditions: []v1.NodeCondition{
+			{Type: v1.NodeReady, Status: v1.ConditionFalse, Reason: "KubeletNotReady", LastTransitionTime: metav1.NewTime(time.Unix(1602664890, 0))},
+		},
+		Taints:        []v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoExecute}},
+		Unschedulable: true,
+	}
+	client := TestAPIServer{Mem: map[string]*NodeInfo{"MyNode": myNode}}
+
+	_, err := NewFileCacheClientWrapper(client, dir, time.Hour).GetNodeInfo("MyNode")
+	require.NoError(t, err)
+	delete(client.Mem, "MyNode")
+
+	// A new wrapper reads the node stored on disk
+	node, err := NewFileCacheClientWrapper(client, dir, time.Hour).GetNodeInfo("MyNode")
+	require.NoError(t, err)
+	assert.Equal(t, myNode, node)
+}
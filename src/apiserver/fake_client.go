@@ -8,7 +8,10 @@ import (
 
 // TestAPIServer is for testing purposes. It implements the apiserver.Client interface with an in-memory list of objects
 type TestAPIServer struct {
-	Mem map[string]*NodeInfo
+	Mem        map[string]*NodeInfo
+	Namespaces map[string]*NamespaceInfo
+	// Owners are indexed by kind, namespace and name, separated by slashes
+	Owners map[string]*OwnerInfo
 }
 
 func (t TestAPIServer) GetNodeInfo(nodeName string) (*NodeInfo, error) {
@@ -20,6 +23,24 @@ func (t TestAPIServer) GetNodeInfo(nodeName string) (*NodeInfo, error) {
 	return node, nil
 }
 
+func (t TestAPIServer) GetNamespaceInfo(namespace string) (*NamespaceInfo, error) {
+	ns, ok := t.Namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("could not find namespace info for: %s", namespace)
+	}
+
+	return ns, nil
+}
+
 func (t TestAPIServer) GetServerVersion() (*version.Info, error) {
 	return &version.Info{}, nil
 }
+
+func (t TestAPIServer) GetOwnerInfo(kind, name, namespace string) (*OwnerInfo, error) {
+	owner, ok := t.Owners[kind+"/"+namespace+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("could not find owner info for: %s %s/%s", kind, namespace, name)
+	}
+
+	return owner, nil
+}
@@ -0,0 +1,278 @@
+// Package attribute limits the label and annotation attributes of the entities, which copy the Kubernetes labels
+// and annotations and can easily have a high cardinality.
+package attribute
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/pattern"
+)
+
+const (
+	labelPrefix      = "label."
+	annotationPrefix = "annotation."
+)
+
+// DroppedMetric is the name of the metric reporting the number of attributes of an entity dropped because of the
+// limit of attributes per entity.
+const DroppedMetric = "attributesDropped"
+
+// Selector selects attributes by the name of the label or annotation they copy. Patterns are globs, where '*'
+// matches any sequence of characters and '?' any single character, or regular expressions when enclosed in
+// slashes, like /^team-.+$/. An attribute is selected when it matches any of the include patterns, or there are
+// none, and it does not match any of the exclude patterns.
+type Selector struct {
+	Include []string
+	Exclude []string
+}
+
+// Policy declares which label and annotation attributes the entities have.
+type Policy struct {
+	Labels      Selector
+	Annotations Selector
+	// CollectAnnotations enables the annotation attributes, which are not reported otherwise.
+	CollectAnnotations bool
+	// MaxPerEntity caps the number of label and annotation attributes of every entity. Zero means no limit.
+	MaxPerEntity int
+}
+
+// Limiter applies a Policy to the specs of the entities.
+type Limiter struct {
+	labels             selector
+	annotations        selector
+	collectAnnotations bool
+	maxPerEntity       int
+}
+
+type selector struct {
+	include []namePattern
+	exclude []namePattern
+}
+
+// namePattern matches the names of labels and annotations. Labels coming from kube-state-metrics have their names
+// sanitized, so the globs also match the sanitized names.
+type namePattern struct {
+	expr      *pattern.Matcher
+	sanitized *pattern.Matcher
+}
+
+// New creates a Limiter applying the given Policy. It fails if any of the patterns is not valid.
+func New(p Policy) (*Limiter, error) {
+	l := &Limiter{collectAnnotations: p.CollectAnnotations, maxPerEntity: p.MaxPerEntity}
+	var err error
+	if l.labels, err = compileSelector(p.Labels); err != nil {
+		return nil, fmt.Errorf("labels: %v", err)
+	}
+	if l.annotations, err = compileSelector(p.Annotations); err != nil {
+		return nil, fmt.Errorf("annotations: %v", err)
+	}
+	if p.MaxPerEntity < 0 {
+		return nil, fmt.Errorf("the maximum number of attributes per entity must not be negative, got %d", p.MaxPerEntity)
+	}
+	return l, nil
+}
+
+func compileSelector(s Selector) (selector, error) {
+	var compiled selector
+	var err error
+	if compiled.include, err = compilePatterns(s.Include); err != nil {
+		return compiled, err
+	}
+	compiled.exclude, err = compilePatterns(s.Exclude)
+	return compiled, err
+}
+
+func compilePatterns(patterns []string) ([]namePattern, error) {
+	compiled := make([]namePattern, 0, len(patterns))
+	for _, p := range patterns {
+		expr, err := pattern.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		c := namePattern{expr: expr}
+		if !pattern.IsRegexp(p) {
+			c.sanitized = pattern.Glob(sanitize(p))
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+var invalidLabelChars = regexp.MustCompile("[^a-zA-Z0-9_*?]")
+
+// sanitize returns the given glob matching the names as exposed by kube-state-metrics.
+func sanitize(p string) string {
+	return invalidLabelChars.ReplaceAllString(p, "_")
+}
+
+func (p namePattern) matches(name string) bool {
+	return p.expr.Matches(name) || (p.sanitized != nil && p.sanitized.Matches(name))
+}
+
+func matchesAny(patterns []namePattern, name string) bool {
+	for _, p := range patterns {
+		if p.matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s selector) selects(name string) bool {
+	if len(s.include) > 0 && !matchesAny(s.include, name) {
+		return false
+	}
+	return !matchesAny(s.exclude, name)
+}
+
+// selects returns whether the attribute with the given name is kept. Attributes that do not copy a label or an
+// annotation are always kept.
+func (l *Limiter) selects(attribute string) bool {
+	switch {
+	case strings.HasPrefix(attribute, labelPrefix):
+		return l.labels.selects(strings.TrimPrefix(attribute, labelPrefix))
+	case strings.HasPrefix(attribute, annotationPrefix):
+		return l.annotations.selects(strings.TrimPrefix(attribute, annotationPrefix))
+	}
+	return true
+}
+
+func (l *Limiter) selecting() bool {
+	return len(l.labels.include) > 0 || len(l.labels.exclude) > 0 ||
+		len(l.annotations.include) > 0 || len(l.annotations.exclude) > 0
+}
+
+func isAttributeSpec(s definition.Spec) bool {
+	return s.Name == labelPrefix+"*" || s.Name == annotationPrefix+"*"
+}
+
+// Apply returns the given specs with the label and annotation attributes limited by the policy. The annotation
+// specs are removed unless they are collected. When there are patterns or a maximum of attributes per entity, the
+// label and annotation specs of every group are merged into a single spec, in place of the first of them, so the
+// attributes are counted across all of them, and the DroppedMetric spec is added when there is a maximum.
+// The given specs are not modified.
+func (l *Limiter) Apply(specs definition.SpecGroups) definition.SpecGroups {
+	limited := make(definition.SpecGroups, len(specs))
+	for groupLabel, group := range specs {
+		var kept, attributes []definition.Spec
+		position := -1
+		for _, s := range group.Specs {
+			if s.Name == annotationPrefix+"*" && !l.collectAnnotations {
+				continue
+			}
+			if isAttributeSpec(s) && (l.selecting() || l.maxPerEntity > 0) {
+				if position < 0 {
+					position = len(kept)
+					kept = append(kept, definition.Spec{})
+				}
+				attributes = append(attributes, s)
+				continue
+			}
+			kept = append(kept, s)
+		}
+
+		if position >= 0 {
+			kept[position] = l.mergedSpec(attributes)
+			if l.maxPerEntity > 0 {
+				kept = append(kept, definition.Spec{
+					Name:      DroppedMetric,
+					ValueFunc: l.droppedValue(attributes),
+					Type:      sdkMetric.GAUGE,
+				})
+			}
+		}
+
+		group.Specs = kept
+		limited[groupLabel] = group
+	}
+	return limited
+}
+
+func (l *Limiter) mergedSpec(attributes []definition.Spec) definition.Spec {
+	optional := true
+	for _, s := range attributes {
+		optional = optional && s.Optional
+	}
+	return definition.Spec{
+		Name: attributes[0].Name,
+		ValueFunc: func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+			values, _, err := l.attributes(attributes, groupLabel, entityID, groups)
+			return values, err
+		},
+		Type:     sdkMetric.ATTRIBUTE,
+		Optional: optional,
+	}
+}
+
+func (l *Limiter) droppedValue(attributes []definition.Spec) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		_, dropped, err := l.attributes(attributes, groupLabel, entityID, groups)
+		if err != nil {
+			return nil, err
+		}
+		return dropped, nil
+	}
+}
+
+// attributes fetches the values of the given attribute specs, keeping the selected ones up to the maximum per
+// entity. Attributes are kept in the order of their specs, and by name within every spec. When an attribute is
+// fetched by several specs, the value of the last one is kept, as it would be when populating them one by one.
+// The number of selected attributes over the maximum is returned too. Errors fetching the values are only
+// returned if no attribute is kept.
+func (l *Limiter) attributes(
+	specs []definition.Spec,
+	groupLabel, entityID string,
+	groups definition.RawGroups,
+) (definition.FetchedValues, int, error) {
+	values := make(definition.FetchedValues)
+	var names []string
+	var errs []string
+	for _, s := range specs {
+		fetched, err := s.ValueFunc(groupLabel, entityID, groups)
+		if err != nil {
+			if !s.Optional {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		multiple, ok := fetched.(definition.FetchedValues)
+		if !ok {
+			multiple = definition.FetchedValues{s.Name: fetched}
+		}
+
+		keys := make([]string, 0, len(multiple))
+		for k := range multiple {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !l.selects(k) {
+				continue
+			}
+			if _, ok := values[k]; !ok {
+				names = append(names, k)
+			}
+			values[k] = multiple[k]
+		}
+	}
+
+	if len(values) == 0 && len(errs) > 0 {
+		return nil, 0, errors.New(strings.Join(errs, "; "))
+	}
+
+	var dropped int
+	if l.maxPerEntity > 0 && len(names) > l.maxPerEntity {
+		for _, k := range names[l.maxPerEntity:] {
+			delete(values, k)
+		}
+		dropped = len(names) - l.maxPerEntity
+	}
+	return values, dropped, nil
+}
@@ -0,0 +1,141 @@
+package attribute
+
+import (
+	"errors"
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+)
+
+func values(v definition.FetchedValues) definition.FetchFunc {
+	return func(string, string, definition.RawGroups) (definition.FetchedValue, error) {
+		return v, nil
+	}
+}
+
+func failing(string, string, definition.RawGroups) (definition.FetchedValue, error) {
+	return nil, errors.New("related metric not found")
+}
+
+var specs = definition.SpecGroups{
+	"deployment": {Specs: []definition.Spec{
+		{Name: "podsDesired", ValueFunc: values(definition.FetchedValues{"podsDesired": 3}), Type: sdkMetric.GAUGE},
+		// Namespace labels come first so the ones of the deployment override them
+		{Name: "label.*", ValueFunc: values(definition.FetchedValues{"label.team": "ci", "label.env": "prod"}), Type: sdkMetric.ATTRIBUTE},
+		{Name: "label.*", ValueFunc: values(definition.FetchedValues{
+			"label.team":              "web",
+			"label.app":               "web",
+			"label.pod_template_hash": "5d4f8c",
+		}), Type: sdkMetric.ATTRIBUTE},
+		{Name: "annotation.*", ValueFunc: values(definition.FetchedValues{
+			"annotation.prometheus.io/scrape":              "true",
+			"annotation.deployment.kubernetes.io/revision": "2",
+		}), Type: sdkMetric.ATTRIBUTE, Optional: true},
+		{Name: "namespaceName", ValueFunc: values(definition.FetchedValues{"namespaceName": "default"}), Type: sdkMetric.ATTRIBUTE},
+	}},
+	"node": {Specs: []definition.Spec{
+		{Name: "cpu", ValueFunc: values(definition.FetchedValues{"cpu": 4}), Type: sdkMetric.GAUGE},
+	}},
+}
+
+func specNames(group definition.SpecGroup) []string {
+	names := make([]string, 0, len(group.Specs))
+	for _, s := range group.Specs {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func fetch(t *testing.T, group definition.SpecGroup, name string) definition.FetchedValue {
+	for _, s := range group.Specs {
+		if s.Name == name {
+			v, err := s.ValueFunc("deployment", "default_web", nil)
+			require.NoError(t, err)
+			return v
+		}
+	}
+	t.Fatalf("spec %s not found", name)
+	return nil
+}
+
+func TestApply_Default(t *testing.T) {
+	l, err := New(Policy{})
+	require.NoError(t, err)
+
+	limited := l.Apply(specs)
+	// Annotations are not collected, and the other specs are kept untouched
+	assert.Equal(t, []string{"podsDesired", "label.*", "label.*", "namespaceName"}, specNames(limited["deployment"]))
+	assert.Equal(t, []string{"cpu"}, specNames(limited["node"]))
+	assert.Len(t, specs["deployment"].Specs, 5)
+}
+
+func TestApply_Selectors(t *testing.T) {
+	l, err := New(Policy{
+		Labels:             Selector{Exclude: []string{"pod-template-hash", "/^en.$/"}},
+		Annotations:        Selector{Include: []string{"prometheus.io/*"}},
+		CollectAnnotations: true,
+	})
+	require.NoError(t, err)
+
+	limited := l.Apply(specs)
+	assert.Equal(t, []string{"podsDesired", "label.*", "namespaceName"}, specNames(limited["deployment"]))
+	// The glob also excludes the sanitized name of the label
+	assert.Equal(t, definition.FetchedValues{
+		"label.team":                      "web",
+		"label.app":                       "web",
+		"annotation.prometheus.io/scrape": "true",
+	}, fetch(t, limited["deployment"], "label.*"))
+}
+
+func TestApply_MaxPerEntity(t *testing.T) {
+	l, err := New(Policy{CollectAnnotations: true, MaxPerEntity: 4})
+	require.NoError(t, err)
+
+	limited := l.Apply(specs)
+	assert.Equal(t, []string{"podsDesired", "label.*", "namespaceName", DroppedMetric}, specNames(limited["deployment"]))
+	assert.Equal(t, []string{"cpu"}, specNames(limited["node"]))
+
+	// Attributes are kept in the order of their specs, and sorted by name within them
+	assert.Equal(t, definition.FetchedValues{
+		"label.env":               "prod",
+		"label.team":              "web",
+		"label.app":               "web",
+		"label.pod_template_hash": "5d4f8c",
+	}, fetch(t, limited["deployment"], "label.*"))
+	assert.Equal(t, 2, fetch(t, limited["deployment"], DroppedMetric))
+}
+
+func TestApply_Errors(t *testing.T) {
+	l, err := New(Policy{MaxPerEntity: 10})
+	require.NoError(t, err)
+
+	failingSpecs := definition.SpecGroups{"pod": {Specs: []definition.Spec{
+		{Name: "label.*", ValueFunc: failing, Type: sdkMetric.ATTRIBUTE},
+	}}}
+	limited := l.Apply(failingSpecs)
+	attributes := limited["pod"].Specs[0]
+	assert.False(t, attributes.Optional)
+	_, err = attributes.ValueFunc("pod", "default_web", nil)
+	assert.EqualError(t, err, "related metric not found")
+
+	// Errors are ignored when other specs fetch attributes
+	failingSpecs = definition.SpecGroups{"pod": {Specs: append(failingSpecs["pod"].Specs, definition.Spec{
+		Name: "label.*", ValueFunc: values(definition.FetchedValues{"label.app": "web"}), Type: sdkMetric.ATTRIBUTE,
+	})}}
+	v, err := l.Apply(failingSpecs)["pod"].Specs[0].ValueFunc("pod", "default_web", nil)
+	require.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{"label.app": "web"}, v)
+}
+
+func TestNew_InvalidPatterns(t *testing.T) {
+	_, err := New(Policy{Labels: Selector{Include: []string{"/team-(/"}}})
+	assert.Error(t, err)
+	_, err = New(Policy{Annotations: Selector{Exclude: []string{""}}})
+	assert.Error(t, err)
+	_, err = New(Policy{MaxPerEntity: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+package attribute
+
+import (
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+)
+
+type populator struct {
+	populator data.Populator
+	limiter   *Limiter
+}
+
+// NewPopulator creates a data.Populator that applies the given Limiter to the specs before populating them with
+// the given populator.
+func NewPopulator(p data.Populator, l *Limiter) data.Populator {
+	return &populator{populator: p, limiter: l}
+}
+
+// Populate populates the integration with the label and annotation attributes limited by the policy.
+func (p *populator) Populate(
+	groups definition.RawGroups,
+	specGroups definition.SpecGroups,
+	i *sdk.IntegrationProtocol2,
+	clusterName string,
+	k8sVersion *version.Info,
+) data.PopulateResult {
+	return p.populator.Populate(groups, p.limiter.Apply(specGroups), i, clusterName, k8sVersion)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const cachedKey = "cadvisor-client"
+
+// cache holds the data to be cached for a cAdvisor client.
+// Its fields must be public to make them visible for the JSON Marshaller.
+type cache struct {
+	Endpoint url.URL
+	NodeIP   string
+}
+
+// compose implements the ClientComposer function signature
+func compose(source interface{}, cacher *client.DiscoveryCacher, timeout time.Duration) (client.HTTPClient, error) {
+	cached := source.(*cache)
+	cd := cacher.Discoverer.(*discoverer)
+	return cd.newCadvisor(cached.Endpoint, cached.NodeIP, timeout)
+}
+
+// decompose implements the ClientDecomposer function signature
+func decompose(source client.HTTPClient) (interface{}, error) {
+	cc := source.(*cadvisor)
+	return &cache{
+		Endpoint: cc.endpoint,
+		NodeIP:   cc.nodeIP,
+	}, nil
+}
+
+// NewDiscoveryCacher creates a new DiscoveryCacher that wraps a discoverer and caches the data into the
+// specified storage
+func NewDiscoveryCacher(discoverer client.Discoverer, storage storage.Storage, ttl time.Duration, logger *logrus.Logger) *client.DiscoveryCacher {
+	return &client.DiscoveryCacher{
+		CachedDataPtr: &cache{},
+		StorageKey:    cachedKey,
+		Discoverer:    discoverer,
+		Storage:       storage,
+		TTL:           ttl,
+		Logger:        logger,
+		Compose:       compose,
+		Decompose:     decompose,
+	}
+}
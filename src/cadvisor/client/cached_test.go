@@ -0,0 +1,46 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/storage"
+)
+
+func TestDiscover_Cache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_discover")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	store := storage.NewJSONDiskStorage(tmpDir)
+
+	// Given a cAdvisor discoverer wrapped into a Cached Discoverer
+	c := k8sClientWithPods(cadvisorPod("cadvisor", nodeIP, "10.0.0.1", v1.PodRunning))
+	d, err := NewDiscoverer(Options{PodLabel: "app", Authentication: AuthServiceAccount}, nodeIP, logger, c)
+	require.NoError(t, err)
+	cacher := NewDiscoveryCacher(d, store, time.Hour, logger)
+
+	// That previously has discovered the HTTP Client
+	_, err = cacher.Discover(timeout)
+	require.NoError(t, err)
+
+	// When the discovery process is invoked again, and the pod is no longer found
+	c.ExpectedCalls = nil
+	c.On("FindPodsByLabel", "app").Return(&v1.PodList{}, nil)
+	c.On("Config").Return(k8sClientWithPods().Config())
+	caClient, err := cacher.Discover(timeout)
+	require.NoError(t, err)
+
+	// The cached value has been retrieved, instead of triggering the discovery
+	cc := client.WrappedClient(caClient).(*cadvisor)
+	assert.Equal(t, url.URL{Scheme: "http", Host: "10.0.0.1:8080"}, cc.endpoint)
+	assert.Equal(t, "foobar", cc.bearerToken)
+	assert.Equal(t, nodeIP, caClient.NodeIP())
+}
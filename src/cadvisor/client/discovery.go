@@ -0,0 +1,215 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+// Authentication methods against a standalone cAdvisor.
+const (
+	AuthNone           = "none"
+	AuthServiceAccount = "service_account"
+)
+
+// defaultPort is the port cAdvisor listens on by default.
+const defaultPort = 8080
+
+var errNoStandaloneCadvisor = errors.New("no standalone cAdvisor configured: set its URL, pod label or port")
+
+// Options configures how a standalone cAdvisor is reached.
+type Options struct {
+	// URL is the static endpoint of cAdvisor. No discovery is done when it is set.
+	URL string
+	// PodLabel is the label, set to "true", of the cAdvisor pods. The pod running on the node is discovered by it.
+	PodLabel string
+	// Port is the port cAdvisor listens on in the discovered pod, 8080 by default. When no pod label is set, the
+	// port cAdvisor listens on in the node.
+	Port int
+	// Scheme is either "http", the default, or "https".
+	Scheme string
+	// Authentication is either AuthNone, the default, or AuthServiceAccount to send the service account token.
+	Authentication     string
+	InsecureSkipVerify bool
+	// CAFile is the path of the certificate authority the certificate of cAdvisor is verified with.
+	CAFile string
+}
+
+// discoverer implements the Discoverer interface to find the standalone cAdvisor running on the node.
+type discoverer struct {
+	options   Options
+	nodeIP    string
+	logger    *logrus.Logger
+	k8sClient client.Kubernetes
+}
+
+// cadvisor implements the HTTPClient interface to call a standalone cAdvisor.
+type cadvisor struct {
+	httpClient  *http.Client
+	endpoint    url.URL
+	nodeIP      string
+	bearerToken string
+	options     Options
+	logger      *logrus.Logger
+}
+
+func (c *cadvisor) NodeIP() string {
+	return c.nodeIP
+}
+
+// Describe returns the discovered cAdvisor endpoint and how the client authenticates against it.
+func (c *cadvisor) Describe() client.Description {
+	d := client.Description{Endpoint: c.endpoint.String(), Authentication: "None"}
+	if c.bearerToken != "" {
+		d.Authentication = "Service account (Bearer token)"
+	}
+	if c.endpoint.Scheme == "https" && c.options.InsecureSkipVerify {
+		d.Authentication += ", without verifying the cAdvisor certificate"
+	}
+	return d
+}
+
+// Do calls the given path of the cAdvisor endpoint, like "/metrics".
+func (c *cadvisor) Do(method, urlPath string) (*http.Response, error) {
+	e := c.endpoint
+	e.Path = path.Join(c.endpoint.Path, urlPath)
+
+	r, err := prometheus.NewRequest(method, e.String())
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s request to: %s. Got error: %s ", method, e.String(), err)
+	}
+	if c.bearerToken != "" {
+		r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.bearerToken))
+	}
+
+	c.logger.Debugf("Calling cAdvisor endpoint: %s", r.URL.String())
+
+	return c.httpClient.Do(r)
+}
+
+// Discover finds the standalone cAdvisor endpoint, which is the configured URL, the one of the cAdvisor pod running
+// on the node or the configured port of the node, in this order.
+func (sd *discoverer) Discover(timeout time.Duration) (client.HTTPClient, error) {
+	endpoint, err := sd.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	return sd.newCadvisor(endpoint, sd.nodeIP, timeout)
+}
+
+func (sd *discoverer) endpoint() (url.URL, error) {
+	scheme := sd.options.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	switch {
+	case sd.options.URL != "":
+		u, err := url.Parse(sd.options.URL)
+		if err != nil {
+			return url.URL{}, fmt.Errorf("wrong user-provided cAdvisor URL: %s", err)
+		}
+		return *u, nil
+	case sd.options.PodLabel != "":
+		pod, err := sd.findPodOnNode()
+		if err != nil {
+			return url.URL{}, err
+		}
+		port := sd.options.Port
+		if port == 0 {
+			port = defaultPort
+		}
+		return url.URL{Scheme: scheme, Host: fmt.Sprintf("%s:%d", pod.Status.PodIP, port)}, nil
+	case sd.options.Port != 0:
+		return url.URL{Scheme: scheme, Host: fmt.Sprintf("%s:%d", sd.nodeIP, sd.options.Port)}, nil
+	}
+	return url.URL{}, errNoStandaloneCadvisor
+}
+
+// findPodOnNode returns the running cAdvisor pod of the node. If there are several, the same one is always chosen.
+func (sd *discoverer) findPodOnNode() (*v1.Pod, error) {
+	pods, err := sd.k8sClient.FindPodsByLabel(sd.options.PodLabel, "true")
+	if err != nil {
+		return nil, fmt.Errorf("could not query api server for cAdvisor pods: %s", err)
+	}
+
+	var found []v1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.HostIP == sd.nodeIP && pod.Status.PodIP != "" && pod.Status.Phase == v1.PodRunning {
+			found = append(found, pod)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no running cAdvisor pod found on node %s with label: '%s'", sd.nodeIP, sd.options.PodLabel)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	sd.logger.Debugf("Found cAdvisor pod %s running on this node, pod IP: %s", found[0].Name, found[0].Status.PodIP)
+	return &found[0], nil
+}
+
+// newCadvisor creates a client calling the given endpoint with the authentication and TLS options of the discoverer.
+func (sd *discoverer) newCadvisor(endpoint url.URL, nodeIP string, timeout time.Duration) (*cadvisor, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: sd.options.InsecureSkipVerify}
+	if sd.options.CAFile != "" {
+		ca, err := ioutil.ReadFile(sd.options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading the cAdvisor CA file: %s", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in the cAdvisor CA file %s", sd.options.CAFile)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	var bearerToken string
+	if sd.options.Authentication == AuthServiceAccount {
+		bearerToken = sd.k8sClient.Config().BearerToken
+	}
+
+	return &cadvisor{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		endpoint:    endpoint,
+		nodeIP:      nodeIP,
+		bearerToken: bearerToken,
+		options:     sd.options,
+		logger:      sd.logger,
+	}, nil
+}
+
+// NewDiscoverer creates a Discoverer of the standalone cAdvisor running on the node with the given IP.
+func NewDiscoverer(options Options, nodeIP string, logger *logrus.Logger, k8sClient client.Kubernetes) (client.Discoverer, error) {
+	if options.URL == "" && options.PodLabel == "" && options.Port == 0 {
+		return nil, errNoStandaloneCadvisor
+	}
+	switch options.Authentication {
+	case "", AuthNone, AuthServiceAccount:
+	default:
+		return nil, fmt.Errorf("unknown cAdvisor authentication %q", options.Authentication)
+	}
+
+	return &discoverer{
+		options:   options,
+		nodeIP:    nodeIP,
+		logger:    logger,
+		k8sClient: k8sClient,
+	}, nil
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+)
+
+const (
+	nodeIP  = "1.2.3.4"
+	timeout = time.Second
+)
+
+var logger = logrus.StandardLogger()
+
+func cadvisorPod(name, hostIP, podIP string, phase v1.PodPhase) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     v1.PodStatus{HostIP: hostIP, PodIP: podIP, Phase: phase},
+	}
+}
+
+func k8sClientWithPods(pods ...v1.Pod) *client.MockedKubernetes {
+	c := new(client.MockedKubernetes)
+	c.On("FindPodsByLabel", "app").Return(&v1.PodList{Items: pods}, nil)
+	c.On("Config").Return(&rest.Config{BearerToken: "foobar"})
+	return c
+}
+
+func TestDiscover_PodLabel(t *testing.T) {
+	c := k8sClientWithPods(
+		cadvisorPod("cadvisor-b", nodeIP, "10.0.0.2", v1.PodRunning),
+		cadvisorPod("cadvisor-a", nodeIP, "10.0.0.1", v1.PodRunning),
+		cadvisorPod("cadvisor-pending", nodeIP, "10.0.0.3", v1.PodPending),
+		cadvisorPod("cadvisor-other-node", "5.6.7.8", "10.0.1.1", v1.PodRunning),
+	)
+
+	d, err := NewDiscoverer(Options{PodLabel: "app", Scheme: "https"}, nodeIP, logger, c)
+	require.NoError(t, err)
+	cc, err := d.Discover(timeout)
+	require.NoError(t, err)
+
+	assert.Equal(t, url.URL{Scheme: "https", Host: "10.0.0.1:8080"}, cc.(*cadvisor).endpoint)
+	assert.Equal(t, nodeIP, cc.NodeIP())
+	assert.Empty(t, cc.(*cadvisor).bearerToken)
+}
+
+func TestDiscover_PodLabelCustomPort(t *testing.T) {
+	c := k8sClientWithPods(cadvisorPod("cadvisor", nodeIP, "10.0.0.1", v1.PodRunning))
+
+	d, err := NewDiscoverer(Options{PodLabel: "app", Port: 4194}, nodeIP, logger, c)
+	require.NoError(t, err)
+	cc, err := d.Discover(timeout)
+	require.NoError(t, err)
+
+	assert.Equal(t, url.URL{Scheme: "http", Host: "10.0.0.1:4194"}, cc.(*cadvisor).endpoint)
+}
+
+func TestDiscover_PodLabelNotOnNode(t *testing.T) {
+	c := k8sClientWithPods(cadvisorPod("cadvisor", "5.6.7.8", "10.0.0.1", v1.PodRunning))
+
+	d, err := NewDiscoverer(Options{PodLabel: "app"}, nodeIP, logger, c)
+	require.NoError(t, err)
+	_, err = d.Discover(timeout)
+
+	assert.Error(t, err)
+}
+
+func TestDiscover_URLTakesPrecedence(t *testing.T) {
+	c := new(client.MockedKubernetes)
+
+	d, err := NewDiscoverer(Options{URL: "https://cadvisor.kube-system:8443", PodLabel: "app", Port: 4194}, nodeIP, logger, c)
+	require.NoError(t, err)
+	cc, err := d.Discover(timeout)
+	require.NoError(t, err)
+
+	assert.Equal(t, url.URL{Scheme: "https", Host: "cadvisor.kube-system:8443"}, cc.(*cadvisor).endpoint)
+	c.AssertNotCalled(t, "FindPodsByLabel", mock.Anything)
+}
+
+func TestDiscover_NodePort(t *testing.T) {
+	d, err := NewDiscoverer(Options{Port: 4194}, nodeIP, logger, new(client.MockedKubernetes))
+	require.NoError(t, err)
+	cc, err := d.Discover(timeout)
+	require.NoError(t, err)
+
+	assert.Equal(t, url.URL{Scheme: "http", Host: "1.2.3.4:4194"}, cc.(*cadvisor).endpoint)
+}
+
+func TestNewDiscoverer_Errors(t *testing.T) {
+	_, err := NewDiscoverer(Options{}, nodeIP, logger, new(client.MockedKubernetes))
+	assert.Equal(t, errNoStandaloneCadvisor, err)
+
+	_, err = NewDiscoverer(Options{Port: 4194, Authentication: "basic"}, nodeIP, logger, new(client.MockedKubernetes))
+	assert.Error(t, err)
+}
+
+func TestDo_ServiceAccountAuthentication(t *testing.T) {
+	var authorization, path string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		path = r.URL.Path
+	}))
+	defer server.Close()
+
+	d, err := NewDiscoverer(Options{
+		URL:                server.URL,
+		Authentication:     AuthServiceAccount,
+		InsecureSkipVerify: true,
+	}, nodeIP, logger, k8sClientWithPods())
+	require.NoError(t, err)
+	cc, err := d.Discover(timeout)
+	require.NoError(t, err)
+
+	resp, err := cc.Do(http.MethodGet, "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Bearer foobar", authorization)
+	assert.Equal(t, "/metrics", path)
+	assert.Equal(t, "Service account (Bearer token), without verifying the cAdvisor certificate", cc.(*cadvisor).Describe().Authentication)
+}
+
+func TestDo_CAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tmpDir, err := ioutil.TempDir("", "test_cadvisor")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	caFile := filepath.Join(tmpDir, "ca.crt")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(caFile, ca, 0600))
+
+	d, err := NewDiscoverer(Options{URL: server.URL, CAFile: caFile}, nodeIP, logger, new(client.MockedKubernetes))
+	require.NoError(t, err)
+	cc, err := d.Discover(timeout)
+	require.NoError(t, err)
+
+	resp, err := cc.Do(http.MethodGet, "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Without the CA the certificate of the server is not trusted
+	d, err = NewDiscoverer(Options{URL: server.URL}, nodeIP, logger, new(client.MockedKubernetes))
+	require.NoError(t, err)
+	cc, err = d.Discover(timeout)
+	require.NoError(t, err)
+	_, err = cc.Do(http.MethodGet, "/metrics")
+	assert.Error(t, err)
+}
+
+func TestDiscover_WrongCAFile(t *testing.T) {
+	d, err := NewDiscoverer(Options{Port: 4194, CAFile: "non-existing.crt"}, nodeIP, logger, new(client.MockedKubernetes))
+	require.NoError(t, err)
+
+	_, err = d.Discover(timeout)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/newrelic/nri-kubernetes/src/storage"
@@ -9,6 +10,42 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// CacheStats holds how many times a discovery was resolved from the cache (hits) or had to run the
+// discovery process (misses).
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// CacheStatsReporter is implemented by the discoverers that keep track of the usage of their cache.
+type CacheStatsReporter interface {
+	// CacheStats returns the cache hits and misses counted since its previous call, so each collection reports
+	// the usage of the cache since the previous one.
+	CacheStats() CacheStats
+}
+
+// cacheCounters counts the cache hits and misses. It can be safely used from several goroutines.
+type cacheCounters struct {
+	hits   uint64
+	misses uint64
+}
+
+func (c *cacheCounters) hit() {
+	atomic.AddUint64(&c.hits, 1)
+}
+
+func (c *cacheCounters) miss() {
+	atomic.AddUint64(&c.misses, 1)
+}
+
+// CacheStats returns the cache hits and misses counted since its previous call, and resets them.
+func (c *cacheCounters) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.SwapUint64(&c.hits, 0),
+		Misses: atomic.SwapUint64(&c.misses, 0),
+	}
+}
+
 // DiscoveryCacher implements the Discoverer API to read endpoints from a cache storage. It also wraps another
 // Discoverer and uses it to discover endpoints when the data is not found in the cache.
 // This type is not thread-safe.
@@ -25,6 +62,8 @@ type DiscoveryCacher struct {
 	Logger    *logrus.Logger
 	Compose   Composer
 	Decompose Decomposer
+
+	cacheCounters
 }
 
 // Decomposer implementors must convert a HTTPClient into a data structure that can be Stored in the cache.
@@ -45,6 +84,7 @@ func (d *DiscoveryCacher) Discover(timeout time.Duration) (HTTPClient, error) {
 			if err != nil {
 				return nil, err
 			}
+			d.hit()
 			return d.wrap(wrappedClient, timeout), nil
 		}
 		d.Logger.Debugf("Cached copy of %q expired. Refreshing", d.StorageKey)
@@ -59,6 +99,7 @@ func (d *DiscoveryCacher) Discover(timeout time.Duration) (HTTPClient, error) {
 }
 
 func (d *DiscoveryCacher) discoverAndCache(timeout time.Duration) (HTTPClient, error) {
+	d.miss()
 	client, err := d.Discoverer.Discover(timeout)
 	if err != nil {
 		return nil, err
@@ -114,6 +155,11 @@ func (c *cacheAwareClient) NodeIP() string {
 	return c.client.NodeIP()
 }
 
+// Describe describes the wrapped client.
+func (c *cacheAwareClient) Describe() Description {
+	return Describe(c.client)
+}
+
 // WrappedClient is only aimed for testing. It allows extracting the wrapped client of a given cacheAwareClient.
 func WrappedClient(caClient HTTPClient) HTTPClient {
 	return caClient.(*cacheAwareClient).client
@@ -131,6 +177,8 @@ type MultiDiscoveryCacher struct {
 	Logger        *logrus.Logger
 	Compose       MultiComposer
 	Decompose     MultiDecomposer
+
+	cacheCounters
 }
 
 // Discover runs the underlying discovery and caches its result.
@@ -147,6 +195,7 @@ func (d *MultiDiscoveryCacher) Discover(timeout time.Duration) ([]HTTPClient, er
 			if err != nil {
 				return nil, errors.Wrap(err, "could not compose cache")
 			}
+			d.hit()
 			return clients, nil
 		}
 		d.Logger.Debugf("Cached copy of %q expired. Refreshing", d.StorageKey)
@@ -161,6 +210,7 @@ func (d *MultiDiscoveryCacher) Discover(timeout time.Duration) ([]HTTPClient, er
 }
 
 func (d *MultiDiscoveryCacher) discoverAndCache(timeout time.Duration) ([]HTTPClient, error) {
+	d.miss()
 	clients, err := d.Discoverer.Discover(timeout)
 	if err != nil {
 		return nil, err
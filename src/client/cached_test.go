@@ -37,7 +37,8 @@ func (f *fakeStorage) Read(key string, valuePtr interface{}) (int64, error) {
 	if !ok {
 		return 0, fmt.Errorf("key not found: %s", key)
 	}
-	*(valuePtr.(*MockedKubernetes)) = *valPtr //nolint: govet
+	// The mocks hold a lock, so only their expectations are copied.
+	valuePtr.(*MockedKubernetes).ExpectedCalls = valPtr.ExpectedCalls
 	return int64(1234567), nil
 }
 
@@ -94,6 +95,7 @@ func TestCacheAwareClient_CachedClientWorks(t *testing.T) {
 
 	// The Discovery process has been triggered once
 	discoverer.AssertExpectations(t)
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 1}, cacher.CacheStats())
 }
 
 func TestCacheAwareClient_CachedClientDoesNotWork(t *testing.T) {
@@ -128,6 +130,45 @@ func TestCacheAwareClient_CachedClientDoesNotWork(t *testing.T) {
 
 	// The Discovery process has been triggered again
 	discoverer.AssertExpectations(t)
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 2}, cacher.CacheStats())
+}
+
+// storage that always contains a fresh entry for any key
+type freshStorage struct{}
+
+func (freshStorage) Write(string, interface{}) error { return nil }
+func (freshStorage) Read(string, interface{}) (int64, error) {
+	return time.Now().Unix(), nil
+}
+func (freshStorage) Delete(string) error { return nil }
+
+func TestDiscoveryCacher_CacheHits(t *testing.T) {
+	// Setup discovered client
+	wrappedClient := new(MockDiscoveredHTTPClient)
+
+	// Setup wrapped discoverer, which should never be invoked
+	discoverer := new(MockDiscoverer)
+
+	// Given a DiscoveryCacher whose storage contains a non expired entry
+	cacher := discoveryCacher(wrappedClient, discoverer, freshStorage{})
+	cacher.CachedDataPtr = wrappedClient
+	cacher.TTL = time.Hour
+
+	// When discovering twice
+	_, err := cacher.Discover(timeout)
+	assert.NoError(t, err)
+	_, err = cacher.Discover(timeout)
+	assert.NoError(t, err)
+
+	// Both discoveries are resolved from the cache
+	discoverer.AssertNotCalled(t, "Discover", mock.Anything)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 0}, cacher.CacheStats())
+
+	// The stats are reset once reported
+	_, err = cacher.Discover(timeout)
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 0}, cacher.CacheStats())
+	assert.Equal(t, CacheStats{}, cacher.CacheStats())
 }
 
 func TestCacheAwareClient_RediscoveryDoesntWork(t *testing.T) {
@@ -39,10 +39,34 @@ type Kubernetes interface {
 	SecureHTTPClient(time.Duration) (*http.Client, error)
 	// FindSecret returns the secret with the given name, if any
 	FindSecret(name, namespace string) (*v1.Secret, error)
+	// FindNamespace returns the namespace with the given name, if any
+	FindNamespace(name string) (*v1.Namespace, error)
+	// FindOwner returns the metadata of the controller of the given kind, name and namespace, which owns other
+	// objects. Only the built-in controllers are supported: see IsOwnerKindSupported.
+	FindOwner(kind, name, namespace string) (*metav1.ObjectMeta, error)
 	// ServerVersion returns the kubernetes server version.
 	ServerVersion() (*version.Info, error)
 }
 
+// Kinds of the built-in controllers owning pods, directly or through other controllers.
+const (
+	KindReplicaSet  = "ReplicaSet"
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindJob         = "Job"
+	KindCronJob     = "CronJob"
+)
+
+// IsOwnerKindSupported returns whether FindOwner can look up controllers of the given kind.
+func IsOwnerKindSupported(kind string) bool {
+	switch kind {
+	case KindReplicaSet, KindDeployment, KindStatefulSet, KindDaemonSet, KindJob, KindCronJob:
+		return true
+	}
+	return false
+}
+
 type goClientImpl struct {
 	client *kubernetes.Clientset
 	config *rest.Config
@@ -100,6 +124,52 @@ func (ka *goClientImpl) FindSecret(name, namespace string) (*v1.Secret, error) {
 	return ka.client.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
 }
 
+func (ka *goClientImpl) FindNamespace(name string) (*v1.Namespace, error) {
+	return ka.client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+}
+
+func (ka *goClientImpl) FindOwner(kind, name, namespace string) (*metav1.ObjectMeta, error) {
+	switch kind {
+	case KindReplicaSet:
+		o, err := ka.client.AppsV1().ReplicaSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &o.ObjectMeta, nil
+	case KindDeployment:
+		o, err := ka.client.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &o.ObjectMeta, nil
+	case KindStatefulSet:
+		o, err := ka.client.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &o.ObjectMeta, nil
+	case KindDaemonSet:
+		o, err := ka.client.AppsV1().DaemonSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &o.ObjectMeta, nil
+	case KindJob:
+		o, err := ka.client.BatchV1().Jobs(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &o.ObjectMeta, nil
+	case KindCronJob:
+		o, err := ka.client.BatchV1beta1().CronJobs(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &o.ObjectMeta, nil
+	}
+	return nil, fmt.Errorf("unsupported owner kind %q", kind)
+}
+
 // BasicHTTPClient returns http.Client configured with timeout
 func BasicHTTPClient(t time.Duration) *http.Client {
 	return &http.Client{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/rest"
 )
@@ -74,3 +75,15 @@ func (m *MockedKubernetes) ListServices() (*v1.ServiceList, error) {
 	args := m.Called()
 	return args.Get(0).(*v1.ServiceList), args.Error(1)
 }
+
+// FindOwner mocks Kubernetes FindOwner
+func (m *MockedKubernetes) FindOwner(kind, name, namespace string) (*metav1.ObjectMeta, error) {
+	args := m.Called(kind, name, namespace)
+	return args.Get(0).(*metav1.ObjectMeta), args.Error(1)
+}
+
+// FindNamespace mocks Kubernetes FindNamespace
+func (m *MockedKubernetes) FindNamespace(name string) (*v1.Namespace, error) {
+	args := m.Called(name)
+	return args.Get(0).(*v1.Namespace), args.Error(1)
+}
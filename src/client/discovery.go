@@ -22,3 +22,23 @@ type HTTPClient interface {
 	Do(method, path string) (*http.Response, error)
 	NodeIP() string
 }
+
+// Description tells which endpoint an HTTPClient calls and how it authenticates against it.
+type Description struct {
+	Endpoint       string
+	Authentication string
+}
+
+// Describer is implemented by the HTTPClients that can describe the endpoint they call.
+type Describer interface {
+	Describe() Description
+}
+
+// Describe returns the Description of the given client. Endpoint and authentication are "unknown" for clients not
+// implementing Describer.
+func Describe(c HTTPClient) Description {
+	if d, ok := c.(Describer); ok {
+		return d.Describe()
+	}
+	return Description{Endpoint: "unknown", Authentication: "unknown"}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/attribute"
+	"github.com/newrelic/nri-kubernetes/src/config"
+	"github.com/newrelic/nri-kubernetes/src/controlplane"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/explain"
+	"github.com/newrelic/nri-kubernetes/src/filter"
+	kubeletMetric "github.com/newrelic/nri-kubernetes/src/kubelet/metric"
+	"github.com/newrelic/nri-kubernetes/src/metric"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+// explicitArgs returns the name of the arguments that have been explicitly set, either from the command line or
+// from the environment variables.
+func explicitArgs() map[string]bool {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	flag.VisitAll(func(f *flag.Flag) {
+		if os.Getenv(strings.ToUpper(f.Name)) != "" {
+			explicit[f.Name] = true
+		}
+	})
+	return explicit
+}
+
+// applyConfig sets the arguments from the values of the given configuration. Arguments explicitly set from the
+// command line or the environment variables take precedence over the configuration, so existing deployments
+// keep working when a configuration file is introduced.
+func applyConfig(args *argumentList, cfg *config.Config, explicit map[string]bool) {
+	setString := func(name string, dst *string, value string) {
+		if value != "" && !explicit[name] {
+			*dst = value
+		}
+	}
+	setBool := func(name string, dst *bool, value *bool) {
+		if value != nil && !explicit[name] {
+			*dst = *value
+		}
+	}
+	setInt := func(name string, dst *int, value int) {
+		if value != 0 && !explicit[name] {
+			*dst = value
+		}
+	}
+	setDuration := func(name string, dst *string, value *time.Duration) {
+		if value != nil && !explicit[name] {
+			*dst = value.String()
+		}
+	}
+	setMillis := func(name string, dst *int, value *time.Duration) {
+		if value != nil && !explicit[name] {
+			*dst = int(*value / time.Millisecond)
+		}
+	}
+
+	setString("cluster_name", &args.ClusterName, cfg.ClusterName)
+	setBool("verbose", &args.Verbose, cfg.Verbose)
+	setMillis("timeout", &args.Timeout, cfg.Timeout)
+
+	setBool("enable_volume_metrics", &args.EnableVolumeMetrics, cfg.Kubelet.EnableVolumeMetrics)
+	setBool("enable_network_interfaces", &args.EnableNetworkInterfaces, cfg.Kubelet.NetworkInterfaces.Enabled)
+	setString("network_route_file", &args.NetworkRouteFile, cfg.Kubelet.NetworkRouteFile)
+	setString("kubelet_usage_source", &args.KubeletUsageSource, cfg.Kubelet.UsageSource)
+
+	cadvisor := cfg.Cadvisor
+	setString("cadvisor_source", &args.CadvisorSource, cadvisor.Source)
+	setString("cadvisor_url", &args.CadvisorURL, cadvisor.URL)
+	setString("cadvisor_pod_label", &args.CadvisorPodLabel, cadvisor.PodLabel)
+	setInt("cadvisor_port", &args.CadvisorPort, cadvisor.Port)
+	setString("cadvisor_scheme", &args.CadvisorScheme, cadvisor.Scheme)
+	setString("cadvisor_authentication", &args.CadvisorAuthentication, cadvisor.Authentication)
+	setBool("cadvisor_insecure_skip_verify", &args.CadvisorInsecureSkipVerify, cadvisor.TLS.InsecureSkipVerify)
+	setString("cadvisor_ca_file", &args.CadvisorCAFile, cadvisor.TLS.CAFile)
+
+	ksm := cfg.KubeStateMetrics
+	if ksm.Enabled != nil && !explicit["disable_kube_state_metrics"] {
+		args.DisableKubeStateMetrics = !*ksm.Enabled
+	}
+	setString("kube_state_metrics_url", &args.KubeStateMetricsURL, ksm.URL)
+	setString("kube_state_metrics_pod_label", &args.KubeStateMetricsPodLabel, ksm.PodLabel)
+	setInt("kube_state_metrics_port", &args.KubeStateMetricsPort, ksm.Port)
+	setString("kube_state_metrics_scheme", &args.KubeStateMetricsScheme, ksm.Scheme)
+	setBool("distributed_kube_state_metrics", &args.DistributedKubeStateMetrics, ksm.Distributed)
+
+	cp := cfg.ControlPlane
+	setString("api_server_endpoint_url", &args.APIServerEndpointURL, cp.APIServer.EndpointURL)
+	setString("api_server_secure_port", &args.APIServerSecurePort, cp.APIServer.SecurePort)
+	setString("etcd_endpoint_url", &args.EtcdEndpointURL, cp.Etcd.EndpointURL)
+	setString("etcd_tls_secret_name", &args.EtcdTLSSecretName, cp.Etcd.TLSSecretName)
+	setString("etcd_tls_secret_namespace", &args.EtcdTLSSecretNamespace, cp.Etcd.TLSSecretNamespace)
+	setString("scheduler_endpoint_url", &args.SchedulerEndpointURL, cp.Scheduler.EndpointURL)
+	setString("controller_manager_endpoint_url", &args.ControllerManagerEndpointURL, cp.ControllerManager.EndpointURL)
+
+	setString("cache_dir", &args.CacheDir, cfg.Cache.Dir)
+	setDuration("discovery_cache_ttl", &args.DiscoveryCacheTTL, cfg.Cache.DiscoveryTTL)
+	setDuration("api_server_cache_ttl", &args.APIServerCacheTTL, cfg.Cache.APIServerTTL)
+	setDuration("api_server_cache_k8_s_version_ttl", &args.APIServerCacheK8SVersionTTL, cfg.Cache.APIServerK8sVersionTTL)
+
+	setMillis("job_timeout", &args.JobTimeout, cfg.Jobs.Timeout)
+	if cfg.Jobs.MaxConcurrent != nil && !explicit["max_concurrent_jobs"] {
+		args.MaxConcurrentJobs = *cfg.Jobs.MaxConcurrent
+	}
+
+	setBool("enable_self_metrics", &args.EnableSelfMetrics, cfg.SelfMetrics.Enabled)
+
+	setBool("daemon", &args.Daemon, cfg.Daemon.Enabled)
+	setDuration("daemon_interval", &args.DaemonInterval, cfg.Daemon.Interval)
+
+	setString("output", &args.Output, cfg.Output.Mode)
+	setString("prometheus_listen_address", &args.PrometheusListenAddress, cfg.Output.PrometheusListenAddress)
+
+	le := cfg.LeaderElection
+	setBool("leader_election", &args.LeaderElection, le.Enabled)
+	setString("leader_election_namespace", &args.LeaderElectionNamespace, le.Namespace)
+	setString("leader_election_lease_name", &args.LeaderElectionLeaseName, le.LeaseName)
+	setDuration("leader_election_lease_duration", &args.LeaderElectionLeaseDuration, le.LeaseDuration)
+
+	setString("health_listen_address", &args.HealthListenAddress, cfg.Health.ListenAddress)
+	setDuration("health_max_collection_age", &args.HealthMaxCollectionAge, cfg.Health.MaxCollectionAge)
+
+	otlp := cfg.Output.OTLP
+	setString("otlp_endpoint", &args.OtlpEndpoint, otlp.Endpoint)
+	setString("otlp_protocol", &args.OtlpProtocol, otlp.Protocol)
+	setMillis("otlp_timeout", &args.OtlpTimeout, otlp.Timeout)
+	setString("otlp_headers", &args.OtlpHeaders, formatHeaders(otlp.Headers))
+}
+
+// formatHeaders formats the given headers in the "key=value,key2=value2" format of the otlp_headers argument.
+func formatHeaders(headers map[string]string) string {
+	pairs := make([]string, 0, len(headers))
+	for k, v := range headers {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// newPopulatorChain returns the function wrapping the populator of a scrape job with the ones applying the
+// configured attribute limits and filters.
+func newPopulatorChain(cfg *config.Config, apiServer apiserver.Client) (explain.Chain, error) {
+	limiter, err := newAttributeLimiter(cfg.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	f := newFilter(cfg.Filters, apiServer)
+
+	return func(p data.Populator) data.Populator {
+		p = attribute.NewPopulator(p, limiter)
+		if f != nil {
+			p = filter.NewPopulator(p, f)
+		}
+		return p
+	}, nil
+}
+
+// newFilter creates the filter applying the configured rules, looking up the namespace labels with the given
+// client, or returns nil if there are no rules.
+func newFilter(filters config.Filters, apiServer apiserver.Client) *filter.Filter {
+	if len(filters.Include) == 0 && len(filters.Exclude) == 0 {
+		return nil
+	}
+	return filter.New(filterRules(filters.Include), filterRules(filters.Exclude), apiServer)
+}
+
+func filterRules(rules []config.FilterRule) []filter.Rule {
+	converted := make([]filter.Rule, 0, len(rules))
+	for _, r := range rules {
+		converted = append(converted, filter.Rule{
+			Namespaces:      r.Namespaces,
+			NamespaceLabels: r.NamespaceLabels,
+			Groups:          r.Groups,
+			PodLabels:       r.PodLabels,
+			Metrics:         r.Metrics,
+		})
+	}
+	return converted
+}
+
+// newAttributeLimiter creates the limiter applying the configured policy to the label and annotation attributes.
+func newAttributeLimiter(attributes config.Attributes) (*attribute.Limiter, error) {
+	policy := attribute.Policy{
+		Labels:       attribute.Selector{Include: attributes.Labels.Include, Exclude: attributes.Labels.Exclude},
+		Annotations:  attribute.Selector{Include: attributes.Annotations.Include, Exclude: attributes.Annotations.Exclude},
+		MaxPerEntity: attributes.MaxPerEntity,
+	}
+	if attributes.CollectAnnotations != nil {
+		policy.CollectAnnotations = *attributes.CollectAnnotations
+	}
+	return attribute.New(policy)
+}
+
+// controlPlaneComponents maps the component names used in the configuration to the control plane components.
+var controlPlaneComponents = map[string]controlplane.ComponentName{
+	"api_server":         controlplane.APIServer,
+	"etcd":               controlplane.Etcd,
+	"scheduler":          controlplane.Scheduler,
+	"controller_manager": controlplane.ControllerManager,
+}
+
+// metricDefinitions holds the metric specs and queries of every scrape job, including the custom metrics.
+type metricDefinitions struct {
+	ksmSpecs          definition.SpecGroups
+	ksmQueries        []prometheus.Query
+	kubeletSpecs      definition.SpecGroups
+	kubeletQueries    []prometheus.Query
+	cadvisorQueries   []prometheus.Query
+	controlPlaneSpecs []controlplane.ComponentOption
+}
+
+// newMetricDefinitions merges the built-in metric specs with the given custom metrics, adding the queries
+// needed to fetch them.
+func newMetricDefinitions(custom config.CustomMetrics, interfaces config.NetworkInterfaces) (*metricDefinitions, error) {
+	d := &metricDefinitions{
+		ksmQueries:      metric.WithCustomQueries(metric.KSMQueries, custom.KubeStateMetrics),
+		kubeletQueries:  metric.KubeletQueries,
+		cadvisorQueries: metric.WithCustomQueries(metric.CadvisorQueries, custom.Cadvisor),
+	}
+
+	var err error
+	if d.ksmSpecs, err = metric.WithCustomSpecs(metric.KSMSpecs, custom.KubeStateMetrics, true); err != nil {
+		return nil, fmt.Errorf("custom kube-state-metrics metrics: %v", err)
+	}
+	if d.kubeletSpecs, err = metric.WithCustomSpecs(metric.KubeletSpecs, custom.Kubelet, false); err != nil {
+		return nil, fmt.Errorf("custom kubelet metrics: %v", err)
+	}
+	if d.kubeletSpecs, err = metric.WithCustomSpecs(d.kubeletSpecs, custom.Cadvisor, true); err != nil {
+		return nil, fmt.Errorf("custom cadvisor metrics: %v", err)
+	}
+	if interfaces.Enabled != nil && *interfaces.Enabled {
+		filter, err := kubeletMetric.NewInterfaceFilter(interfaces.Include, interfaces.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("network interfaces: %v", err)
+		}
+		d.kubeletSpecs = metric.WithNetworkInterfaceSpecs(d.kubeletSpecs, filter)
+	}
+
+	components := controlplane.BuildComponentList()
+	for _, key := range config.ControlPlaneComponents {
+		customSpecs, ok := custom.ControlPlane[key]
+		if !ok {
+			continue
+		}
+
+		name := controlPlaneComponents[key]
+		for _, component := range components {
+			if component.Name != name {
+				continue
+			}
+			groups := map[string][]config.MetricSpec{string(name): customSpecs}
+			specs, err := metric.WithCustomSpecs(component.Specs, groups, true)
+			if err != nil {
+				return nil, fmt.Errorf("custom %s metrics: %v", name, err)
+			}
+			queries := metric.WithCustomQueries(component.Queries, groups)
+			d.controlPlaneSpecs = append(d.controlPlaneSpecs, controlplane.WithSpecs(name, specs, queries))
+		}
+	}
+
+	return d, nil
+}
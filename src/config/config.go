@@ -0,0 +1,624 @@
+// Package config holds the structured configuration of the integration, which can be loaded from a YAML file as
+// an alternative to the flat list of command line arguments and environment variables.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/newrelic/nri-kubernetes/src/pattern"
+)
+
+// Config is the structured configuration of the integration. Every field is optional: the ones that are not
+// set keep the value given by the command line arguments, the environment variables or the defaults.
+type Config struct {
+	ClusterName      string           `yaml:"cluster_name"`
+	Verbose          *bool            `yaml:"verbose"`
+	Timeout          *time.Duration   `yaml:"timeout"`
+	Kubelet          Kubelet          `yaml:"kubelet"`
+	KubeStateMetrics KubeStateMetrics `yaml:"kube_state_metrics"`
+	Cadvisor         Cadvisor         `yaml:"cadvisor"`
+	ControlPlane     ControlPlane     `yaml:"control_plane"`
+	Cache            Cache            `yaml:"cache"`
+	Jobs             Jobs             `yaml:"jobs"`
+	SelfMetrics      SelfMetrics      `yaml:"self_metrics"`
+	Daemon           Daemon           `yaml:"daemon"`
+	CustomMetrics    CustomMetrics    `yaml:"custom_metrics"`
+	Output           Output           `yaml:"output"`
+	Health           Health           `yaml:"health"`
+	LeaderElection   LeaderElection   `yaml:"leader_election"`
+	Filters          Filters          `yaml:"filters"`
+	Attributes       Attributes       `yaml:"attributes"`
+}
+
+// Kubelet configures how metrics are fetched from the Kubelet.
+type Kubelet struct {
+	EnableVolumeMetrics *bool  `yaml:"enable_volume_metrics"`
+	NetworkRouteFile    string `yaml:"network_route_file"`
+	// UsageSource is the endpoint the CPU and memory usage of the node, pods and containers is fetched from:
+	// "summary" for /stats/summary, "resource" for the lighter /metrics/resource, or "auto" to use the latter when
+	// the kubelet serves it.
+	UsageSource string `yaml:"usage_source"`
+	// NetworkInterfaces enables the network metrics of every interface of the nodes and pods.
+	NetworkInterfaces NetworkInterfaces `yaml:"network_interfaces"`
+}
+
+// NetworkInterfaces configures the net.<interface>.* metrics reporting the network usage of every interface of the
+// nodes and pods, like the secondary interfaces added by Multus or bonding. Interfaces are selected by name with
+// globs, where '*' matches any sequence of characters and '?' any single character, or regular expressions when
+// enclosed in slashes. An interface is selected when it matches any of the include patterns, or there are none,
+// and it does not match any of the exclude patterns.
+type NetworkInterfaces struct {
+	Enabled *bool    `yaml:"enabled"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// KubeStateMetrics configures how kube-state-metrics is discovered and queried.
+type KubeStateMetrics struct {
+	Enabled     *bool  `yaml:"enabled"`
+	URL         string `yaml:"url"`
+	PodLabel    string `yaml:"pod_label"`
+	Port        int    `yaml:"port"`
+	Scheme      string `yaml:"scheme"`
+	Distributed *bool  `yaml:"distributed"`
+}
+
+// Cadvisor configures where the cAdvisor metrics of the containers are fetched from, which is either the
+// /metrics/cadvisor endpoint of the kubelet or a standalone cAdvisor running on each node, usually as a DaemonSet.
+// The standalone cAdvisor is reached at URL when set, otherwise the cAdvisor pod running on the node is discovered
+// by PodLabel, or it is reached on Port of the node.
+type Cadvisor struct {
+	// Source is "kubelet" to fetch the metrics from the kubelet, "standalone" to fetch them from the standalone
+	// cAdvisor and fall back to the kubelet when it fails, or "auto" to do the opposite. It defaults to "standalone"
+	// when a standalone cAdvisor is configured, and to "kubelet" otherwise.
+	Source   string `yaml:"source"`
+	URL      string `yaml:"url"`
+	PodLabel string `yaml:"pod_label"`
+	Port     int    `yaml:"port"`
+	Scheme   string `yaml:"scheme"`
+	// Authentication is "none", or "service_account" to send the token of the service account of the integration.
+	Authentication string      `yaml:"authentication"`
+	TLS            CadvisorTLS `yaml:"tls"`
+}
+
+// CadvisorTLS configures how the certificate of a standalone cAdvisor served over https is verified.
+type CadvisorTLS struct {
+	InsecureSkipVerify *bool  `yaml:"insecure_skip_verify"`
+	CAFile             string `yaml:"ca_file"`
+}
+
+// ControlPlane configures each one of the control plane components.
+type ControlPlane struct {
+	APIServer         APIServer `yaml:"api_server"`
+	Etcd              Etcd      `yaml:"etcd"`
+	Scheduler         Component `yaml:"scheduler"`
+	ControllerManager Component `yaml:"controller_manager"`
+}
+
+// Component configures a control plane component.
+type Component struct {
+	EndpointURL string `yaml:"endpoint_url"`
+}
+
+// APIServer configures the API server control plane component.
+type APIServer struct {
+	Component  `yaml:",inline"`
+	SecurePort string `yaml:"secure_port"`
+}
+
+// Etcd configures the etcd control plane component.
+type Etcd struct {
+	Component          `yaml:",inline"`
+	TLSSecretName      string `yaml:"tls_secret_name"`
+	TLSSecretNamespace string `yaml:"tls_secret_namespace"`
+}
+
+// Cache configures where and for how long the discovered endpoints and the API server responses are cached.
+type Cache struct {
+	Dir                    string         `yaml:"dir"`
+	DiscoveryTTL           *time.Duration `yaml:"discovery_ttl"`
+	APIServerTTL           *time.Duration `yaml:"api_server_ttl"`
+	APIServerK8sVersionTTL *time.Duration `yaml:"api_server_k8s_version_ttl"`
+}
+
+// Jobs configures how the scrape jobs are run.
+type Jobs struct {
+	Timeout       *time.Duration `yaml:"timeout"`
+	MaxConcurrent *int           `yaml:"max_concurrent"`
+}
+
+// SelfMetrics configures the samples describing the integration itself.
+type SelfMetrics struct {
+	Enabled *bool `yaml:"enabled"`
+}
+
+// Daemon configures the integration to keep running, collecting metrics periodically.
+type Daemon struct {
+	Enabled  *bool          `yaml:"enabled"`
+	Interval *time.Duration `yaml:"interval"`
+}
+
+// LeaderElection configures the election of the instance of the integration running the cluster scoped jobs.
+type LeaderElection struct {
+	Enabled       *bool          `yaml:"enabled"`
+	Namespace     string         `yaml:"namespace"`
+	LeaseName     string         `yaml:"lease_name"`
+	LeaseDuration *time.Duration `yaml:"lease_duration"`
+}
+
+// Health configures the health endpoints of the integration running as a daemon.
+type Health struct {
+	ListenAddress    string         `yaml:"listen_address"`
+	MaxCollectionAge *time.Duration `yaml:"max_collection_age"`
+}
+
+// Output configures where the metrics are sent.
+type Output struct {
+	// Mode lists the enabled OutputModes, separated by commas.
+	Mode                    string `yaml:"mode"`
+	PrometheusListenAddress string `yaml:"prometheus_listen_address"`
+	OTLP                    OTLP   `yaml:"otlp"`
+}
+
+// OTLP configures the export of the metrics to an OpenTelemetry collector.
+type OTLP struct {
+	Endpoint string `yaml:"endpoint"`
+	// Protocol is one of OTLPProtocols.
+	Protocol string            `yaml:"protocol"`
+	Timeout  *time.Duration    `yaml:"timeout"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// OutputModes are the outputs that can be enabled in Output.Mode. "both" is kept as an alias of
+// "stdout,prometheus".
+var OutputModes = []string{"stdout", "prometheus", "otlp", "both"}
+
+// OTLPProtocols are the valid values for OTLP.Protocol.
+var OTLPProtocols = []string{"grpc", "http/protobuf"}
+
+// Outputs returns the outputs enabled by the given mode, which lists OutputModes separated by commas.
+func Outputs(mode string) (map[string]bool, error) {
+	outputs := make(map[string]bool)
+	for _, o := range strings.Split(mode, ",") {
+		o = strings.TrimSpace(o)
+		switch {
+		case o == "both":
+			outputs["stdout"] = true
+			outputs["prometheus"] = true
+		case contains(OutputModes, o):
+			outputs[o] = true
+		default:
+			return nil, fmt.Errorf("must list some of %s separated by commas, got %q", strings.Join(OutputModes, ", "), mode)
+		}
+	}
+	return outputs, nil
+}
+
+// Filters select the entities and metrics that are populated. An entity is kept when it matches any of the
+// include rules, or there are none, and it does not match any of the exclude rules.
+type Filters struct {
+	Include []FilterRule `yaml:"include"`
+	Exclude []FilterRule `yaml:"exclude"`
+}
+
+// FilterRule selects the entities meeting all the conditions that are set. Names and label values are globs,
+// where '*' matches any sequence of characters and '?' any single character.
+type FilterRule struct {
+	Namespaces      []string          `yaml:"namespaces"`
+	NamespaceLabels map[string]string `yaml:"namespace_labels"`
+	// Groups are the entity groups, like pod, container, volume or node.
+	Groups    []string          `yaml:"groups"`
+	PodLabels map[string]string `yaml:"pod_labels"`
+	// Metrics, when set, make the rule select these metrics of the matching entities instead of the entities.
+	Metrics []string `yaml:"metrics"`
+}
+
+// Attributes limit the label.* and annotation.* attributes copied from the Kubernetes labels and annotations.
+type Attributes struct {
+	Labels      AttributeSelector `yaml:"labels"`
+	Annotations AttributeSelector `yaml:"annotations"`
+	// CollectAnnotations enables the annotation.* attributes of pods, namespaces and deployments.
+	CollectAnnotations *bool `yaml:"collect_annotations"`
+	// MaxPerEntity caps the number of label.* and annotation.* attributes of every entity. The number of dropped
+	// ones is reported as the attributesDropped metric.
+	MaxPerEntity int `yaml:"max_per_entity"`
+}
+
+// AttributeSelector selects labels or annotations by name. Patterns are globs, where '*' matches any sequence of
+// characters and '?' any single character, or regular expressions when enclosed in slashes.
+type AttributeSelector struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// CustomMetrics holds the metric specs added to the built-in ones, indexed by the name of the spec group they
+// belong to.
+type CustomMetrics struct {
+	// KubeStateMetrics specs fetch their values from the kube-state-metrics Prometheus metrics.
+	KubeStateMetrics map[string][]MetricSpec `yaml:"kube_state_metrics"`
+	// Kubelet specs fetch their values from the raw data the integration gets from the Kubelet.
+	Kubelet map[string][]MetricSpec `yaml:"kubelet"`
+	// Cadvisor specs fetch their values from the cAdvisor Prometheus metrics exposed by the Kubelet.
+	Cadvisor map[string][]MetricSpec `yaml:"cadvisor"`
+	// ControlPlane specs are indexed by component (api_server, etcd, scheduler or controller_manager) and fetch
+	// their values from the Prometheus metrics of that component.
+	ControlPlane map[string][]MetricSpec `yaml:"control_plane"`
+}
+
+// MetricSpec declares a metric to be collected.
+type MetricSpec struct {
+	// Name of the attribute the metric is reported as.
+	Name string `yaml:"name"`
+	// Source is the name of the metric the value is read from.
+	Source string `yaml:"source"`
+	// Type is the source type of the metric: GAUGE, RATE, DELTA or ATTRIBUTE.
+	Type string `yaml:"type"`
+	// Label, only for ATTRIBUTE metrics from Prometheus sources, reports the value of the given label of the
+	// source metric instead of its value.
+	Label string `yaml:"label"`
+	// IncludeLabels, for Prometheus sources, only keeps the given labels when naming the reported attributes.
+	IncludeLabels []string `yaml:"include_labels"`
+	// IgnoreLabels, for Prometheus sources, discards the given labels when naming the reported attributes.
+	IgnoreLabels []string `yaml:"ignore_labels"`
+	// Optional metrics do not report an error when their source is missing.
+	Optional bool `yaml:"optional"`
+}
+
+// SourceTypes are the valid values for MetricSpec.Type.
+var SourceTypes = []string{"GAUGE", "RATE", "DELTA", "ATTRIBUTE"}
+
+// ControlPlaneComponents are the valid keys for CustomMetrics.ControlPlane.
+var ControlPlaneComponents = []string{"api_server", "etcd", "scheduler", "controller_manager"}
+
+// Load reads and validates the configuration stored in the YAML file at the given path.
+func Load(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration file: %v", err)
+	}
+
+	c, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("configuration file %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Parse decodes and validates the given YAML configuration. Unknown fields are reported as errors, so
+// misspelled options do not go unnoticed.
+func Parse(content []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ValidationError holds all the problems found in a configuration.
+type ValidationError []string
+
+// Error implements the error interface.
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(v, "; "))
+}
+
+// Validate checks the consistency of the configuration, returning a ValidationError listing all the
+// problems found.
+func (c *Config) Validate() error {
+	var errs ValidationError
+	addErr := func(field, format string, a ...interface{}) {
+		errs = append(errs, fmt.Sprintf("%s: %s", field, fmt.Sprintf(format, a...)))
+	}
+
+	if c.Timeout != nil && *c.Timeout <= 0 {
+		addErr("timeout", "must be greater than 0, got %s", *c.Timeout)
+	}
+
+	switch c.Kubelet.UsageSource {
+	case "", "summary", "resource", "auto":
+	default:
+		addErr("kubelet.usage_source", `must be "summary", "resource" or "auto", got %q`, c.Kubelet.UsageSource)
+	}
+	errs = append(errs, validatePatterns("kubelet.network_interfaces.include", c.Kubelet.NetworkInterfaces.Include)...)
+	errs = append(errs, validatePatterns("kubelet.network_interfaces.exclude", c.Kubelet.NetworkInterfaces.Exclude)...)
+
+	ksm := c.KubeStateMetrics
+	if ksm.URL != "" {
+		if err := validateURL(ksm.URL); err != nil {
+			addErr("kube_state_metrics.url", "%v", err)
+		}
+	}
+	if ksm.Scheme != "" && ksm.Scheme != "http" && ksm.Scheme != "https" {
+		addErr("kube_state_metrics.scheme", `must be "http" or "https", got %q`, ksm.Scheme)
+	}
+	if ksm.Port < 0 || ksm.Port > 65535 {
+		addErr("kube_state_metrics.port", "must be a valid port number, got %d", ksm.Port)
+	}
+	if ksm.Distributed != nil && *ksm.Distributed && ksm.PodLabel == "" {
+		addErr("kube_state_metrics.distributed", "requires kube_state_metrics.pod_label to be set")
+	}
+
+	cadvisor := c.Cadvisor
+	switch cadvisor.Source {
+	case "", "kubelet":
+	case "standalone", "auto":
+		if cadvisor.URL == "" && cadvisor.PodLabel == "" && cadvisor.Port == 0 {
+			addErr("cadvisor.source", "%q requires cadvisor.url, cadvisor.pod_label or cadvisor.port to be set", cadvisor.Source)
+		}
+	default:
+		addErr("cadvisor.source", `must be "kubelet", "standalone" or "auto", got %q`, cadvisor.Source)
+	}
+	if cadvisor.URL != "" {
+		if err := validateURL(cadvisor.URL); err != nil {
+			addErr("cadvisor.url", "%v", err)
+		}
+	}
+	if cadvisor.Scheme != "" && cadvisor.Scheme != "http" && cadvisor.Scheme != "https" {
+		addErr("cadvisor.scheme", `must be "http" or "https", got %q`, cadvisor.Scheme)
+	}
+	if cadvisor.Port < 0 || cadvisor.Port > 65535 {
+		addErr("cadvisor.port", "must be a valid port number, got %d", cadvisor.Port)
+	}
+	switch cadvisor.Authentication {
+	case "", "none", "service_account":
+	default:
+		addErr("cadvisor.authentication", `must be "none" or "service_account", got %q`, cadvisor.Authentication)
+	}
+
+	cp := c.ControlPlane
+	if cp.APIServer.SecurePort != "" && cp.APIServer.EndpointURL != "" {
+		addErr("control_plane.api_server", "secure_port and endpoint_url can not both be set")
+	}
+	if cp.APIServer.SecurePort != "" {
+		if port, err := strconv.Atoi(cp.APIServer.SecurePort); err != nil || port <= 0 || port > 65535 {
+			addErr("control_plane.api_server.secure_port", "must be a valid port number, got %q", cp.APIServer.SecurePort)
+		}
+	}
+	components := map[string]Component{
+		"api_server":         cp.APIServer.Component,
+		"etcd":               cp.Etcd.Component,
+		"scheduler":          cp.Scheduler,
+		"controller_manager": cp.ControllerManager,
+	}
+	for _, name := range []string{"api_server", "etcd", "scheduler", "controller_manager"} {
+		if endpoint := components[name].EndpointURL; endpoint != "" {
+			if err := validateURL(endpoint); err != nil {
+				addErr("control_plane."+name+".endpoint_url", "%v", err)
+			}
+		}
+	}
+	if cp.Etcd.TLSSecretNamespace != "" && cp.Etcd.TLSSecretName == "" {
+		addErr("control_plane.etcd.tls_secret_namespace", "requires control_plane.etcd.tls_secret_name to be set")
+	}
+
+	durations := []struct {
+		field string
+		value *time.Duration
+	}{
+		{"cache.discovery_ttl", c.Cache.DiscoveryTTL},
+		{"cache.api_server_ttl", c.Cache.APIServerTTL},
+		{"cache.api_server_k8s_version_ttl", c.Cache.APIServerK8sVersionTTL},
+		{"jobs.timeout", c.Jobs.Timeout},
+	}
+	for _, d := range durations {
+		if d.value != nil && *d.value < 0 {
+			addErr(d.field, "must not be negative, got %s", *d.value)
+		}
+	}
+
+	if c.Jobs.MaxConcurrent != nil && *c.Jobs.MaxConcurrent < 0 {
+		addErr("jobs.max_concurrent", "must not be negative, got %d", *c.Jobs.MaxConcurrent)
+	}
+	if c.Daemon.Interval != nil && *c.Daemon.Interval <= 0 {
+		addErr("daemon.interval", "must be greater than 0, got %s", *c.Daemon.Interval)
+	}
+
+	if c.Output.Mode != "" {
+		outputs, err := Outputs(c.Output.Mode)
+		if err != nil {
+			addErr("output.mode", "%v", err)
+		}
+		if outputs["prometheus"] && c.Daemon.Enabled != nil && !*c.Daemon.Enabled {
+			addErr("output.mode", "prometheus requires the daemon mode to be enabled")
+		}
+	}
+	otlp := c.Output.OTLP
+	if otlp.Endpoint != "" {
+		if err := validateURL(otlp.Endpoint); err != nil {
+			addErr("output.otlp.endpoint", "%v", err)
+		}
+	}
+	if otlp.Protocol != "" && !contains(OTLPProtocols, otlp.Protocol) {
+		addErr("output.otlp.protocol", "must be one of %s, got %q", strings.Join(OTLPProtocols, ", "), otlp.Protocol)
+	}
+	if otlp.Timeout != nil && *otlp.Timeout <= 0 {
+		addErr("output.otlp.timeout", "must be greater than 0, got %s", *otlp.Timeout)
+	}
+
+	if c.Health.ListenAddress != "" && c.Daemon.Enabled != nil && !*c.Daemon.Enabled {
+		addErr("health.listen_address", "requires the daemon mode to be enabled")
+	}
+	if c.Health.MaxCollectionAge != nil && *c.Health.MaxCollectionAge < 0 {
+		addErr("health.max_collection_age", "must not be negative, got %s", *c.Health.MaxCollectionAge)
+	}
+
+	le := c.LeaderElection
+	if le.LeaseDuration != nil && *le.LeaseDuration < time.Second {
+		addErr("leader_election.lease_duration", "must be at least 1s, got %s", *le.LeaseDuration)
+	}
+	if le.Enabled != nil && *le.Enabled && c.KubeStateMetrics.Distributed != nil && *c.KubeStateMetrics.Distributed {
+		addErr("leader_election.enabled", "can not be set together with kube_state_metrics.distributed")
+	}
+
+	errs = append(errs, c.CustomMetrics.validate()...)
+	errs = append(errs, c.Filters.validate()...)
+	errs = append(errs, c.Attributes.validate()...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c CustomMetrics) validate() []string {
+	var errs []string
+	validateGroups := func(section string, groups map[string][]MetricSpec, prometheusSource bool) {
+		for _, group := range sortedKeys(groups) {
+			for i, spec := range groups[group] {
+				field := fmt.Sprintf("custom_metrics.%s.%s[%d]", section, group, i)
+				for _, e := range spec.validate(prometheusSource) {
+					errs = append(errs, fmt.Sprintf("%s: %s", field, e))
+				}
+			}
+		}
+	}
+
+	validateGroups("kube_state_metrics", c.KubeStateMetrics, true)
+	validateGroups("kubelet", c.Kubelet, false)
+	validateGroups("cadvisor", c.Cadvisor, true)
+	validateGroups("control_plane", c.ControlPlane, true)
+
+	for _, group := range sortedKeys(c.Cadvisor) {
+		if group != "container" {
+			errs = append(errs, fmt.Sprintf("custom_metrics.cadvisor.%s: only the container group is supported", group))
+		}
+	}
+	for _, component := range sortedKeys(c.ControlPlane) {
+		if !contains(ControlPlaneComponents, component) {
+			errs = append(errs, fmt.Sprintf(
+				"custom_metrics.control_plane.%s: unknown component, must be one of %s",
+				component,
+				strings.Join(ControlPlaneComponents, ", "),
+			))
+		}
+	}
+	return errs
+}
+
+func (f Filters) validate() []string {
+	var errs []string
+	validateRules := func(section string, rules []FilterRule) {
+		for i, r := range rules {
+			field := fmt.Sprintf("filters.%s[%d]", section, i)
+			if len(r.Namespaces) == 0 && len(r.NamespaceLabels) == 0 && len(r.Groups) == 0 &&
+				len(r.PodLabels) == 0 && len(r.Metrics) == 0 {
+				errs = append(errs, fmt.Sprintf("%s: must set at least one of namespaces, namespace_labels, groups, pod_labels or metrics", field))
+			}
+			lists := []struct {
+				name     string
+				patterns []string
+			}{{"namespaces", r.Namespaces}, {"groups", r.Groups}, {"metrics", r.Metrics}}
+			for _, l := range lists {
+				for _, p := range l.patterns {
+					if p == "" {
+						errs = append(errs, fmt.Sprintf("%s.%s: must not contain empty names", field, l.name))
+						break
+					}
+				}
+			}
+			if _, ok := r.NamespaceLabels[""]; ok {
+				errs = append(errs, fmt.Sprintf("%s.namespace_labels: must not contain empty label names", field))
+			}
+			if _, ok := r.PodLabels[""]; ok {
+				errs = append(errs, fmt.Sprintf("%s.pod_labels: must not contain empty label names", field))
+			}
+		}
+	}
+
+	validateRules("include", f.Include)
+	validateRules("exclude", f.Exclude)
+	return errs
+}
+
+func (a Attributes) validate() []string {
+	var errs []string
+	errs = append(errs, validatePatterns("attributes.labels.include", a.Labels.Include)...)
+	errs = append(errs, validatePatterns("attributes.labels.exclude", a.Labels.Exclude)...)
+	errs = append(errs, validatePatterns("attributes.annotations.include", a.Annotations.Include)...)
+	errs = append(errs, validatePatterns("attributes.annotations.exclude", a.Annotations.Exclude)...)
+	if a.MaxPerEntity < 0 {
+		errs = append(errs, fmt.Sprintf("attributes.max_per_entity: must not be negative, got %d", a.MaxPerEntity))
+	}
+	return errs
+}
+
+// validatePatterns checks the given name patterns, which are globs or regular expressions enclosed in slashes.
+func validatePatterns(field string, patterns []string) []string {
+	var errs []string
+	for _, p := range patterns {
+		_, err := pattern.Compile(p)
+		if err == pattern.ErrEmpty {
+			errs = append(errs, fmt.Sprintf("%s: must not contain empty patterns", field))
+		} else if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	return errs
+}
+
+func (s MetricSpec) validate(prometheusSource bool) []string {
+	var errs []string
+	if s.Name == "" {
+		errs = append(errs, "name is mandatory")
+	}
+	if s.Source == "" {
+		errs = append(errs, "source is mandatory")
+	}
+	if !contains(SourceTypes, strings.ToUpper(s.Type)) {
+		errs = append(errs, fmt.Sprintf("type must be one of %s, got %q", strings.Join(SourceTypes, ", "), s.Type))
+	}
+	if len(s.IncludeLabels) > 0 && len(s.IgnoreLabels) > 0 {
+		errs = append(errs, "include_labels and ignore_labels can not both be set")
+	}
+	if s.Label != "" && strings.ToUpper(s.Type) != "ATTRIBUTE" {
+		errs = append(errs, "label can only be set for ATTRIBUTE metrics")
+	}
+	if !prometheusSource && (s.Label != "" || len(s.IncludeLabels) > 0 || len(s.IgnoreLabels) > 0) {
+		errs = append(errs, "label, include_labels and ignore_labels are only supported for Prometheus sources")
+	}
+	return errs
+}
+
+func sortedKeys(m map[string][]MetricSpec) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid URL: %v", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must use the http or https scheme", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", raw)
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	c, err := Load("testdata/config.yml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "production", c.ClusterName)
+	assert.True(t, *c.Verbose)
+	assert.Equal(t, 10*time.Second, *c.Timeout)
+	assert.False(t, *c.Kubelet.EnableVolumeMetrics)
+	assert.Equal(t, "/host/proc/net/route", c.Kubelet.NetworkRouteFile)
+	assert.Equal(t, "auto", c.Kubelet.UsageSource)
+	assert.Equal(t, NetworkInterfaces{
+		Enabled: boolPtr(true),
+		Include: []string{"eth*", "/^bond[0-9]+$/"},
+		Exclude: []string{"eth9"},
+	}, c.Kubelet.NetworkInterfaces)
+	assert.Equal(t, KubeStateMetrics{
+		Enabled:     boolPtr(true),
+		PodLabel:    "kube-state-metrics",
+		Port:        8443,
+		Scheme:      "https",
+		Distributed: boolPtr(true),
+	}, c.KubeStateMetrics)
+	assert.Equal(t, Cadvisor{
+		Source:         "auto",
+		PodLabel:       "cadvisor",
+		Port:           8443,
+		Scheme:         "https",
+		Authentication: "service_account",
+		TLS: CadvisorTLS{
+			InsecureSkipVerify: boolPtr(true),
+			CAFile:             "/etc/cadvisor/ca.crt",
+		},
+	}, c.Cadvisor)
+	assert.Equal(t, "6443", c.ControlPlane.APIServer.SecurePort)
+	assert.Equal(t, "https://localhost:4001", c.ControlPlane.Etcd.EndpointURL)
+	assert.Equal(t, "etcd-tls", c.ControlPlane.Etcd.TLSSecretName)
+	assert.Equal(t, "kube-system", c.ControlPlane.Etcd.TLSSecretNamespace)
+	assert.Equal(t, "https://localhost:10259", c.ControlPlane.Scheduler.EndpointURL)
+	assert.Equal(t, "https://localhost:10257", c.ControlPlane.ControllerManager.EndpointURL)
+	assert.Equal(t, "/tmp/nr-kubernetes", c.Cache.Dir)
+	assert.Equal(t, 2*time.Hour, *c.Cache.DiscoveryTTL)
+	assert.Equal(t, time.Duration(0), *c.Cache.APIServerTTL)
+	assert.Equal(t, time.Hour, *c.Cache.APIServerK8sVersionTTL)
+	assert.Equal(t, 3*time.Second, *c.Jobs.Timeout)
+	assert.Equal(t, 2, *c.Jobs.MaxConcurrent)
+	assert.False(t, *c.SelfMetrics.Enabled)
+	assert.True(t, *c.Daemon.Enabled)
+	assert.Equal(t, 30*time.Second, *c.Daemon.Interval)
+	assert.Equal(t, CustomMetrics{
+		KubeStateMetrics: map[string][]MetricSpec{
+			"deployment": {{
+				Name:     "podsMaxSurge",
+				Source:   "kube_deployment_spec_strategy_rollingupdate_max_surge",
+				Type:     "GAUGE",
+				Optional: true,
+			}},
+		},
+		Kubelet: map[string][]MetricSpec{
+			"node": {{Name: "allocatableCpuCores", Source: "allocatableCpuCores", Type: "gauge"}},
+		},
+		Cadvisor: map[string][]MetricSpec{
+			"container": {{Name: "memoryMaxUsageBytes", Source: "container_memory_max_usage_bytes", Type: "gauge"}},
+		},
+		ControlPlane: map[string][]MetricSpec{
+			"api_server": {{
+				Name:          "apiserverDroppedRequestsDelta",
+				Source:        "apiserver_dropped_requests_total",
+				Type:          "DELTA",
+				IncludeLabels: []string{"requestKind"},
+			}},
+		},
+	}, c.CustomMetrics)
+	otlpTimeout := 10 * time.Second
+	assert.Equal(t, Output{
+		Mode:                    "stdout,prometheus,otlp",
+		PrometheusListenAddress: ":9296",
+		OTLP: OTLP{
+			Endpoint: "http://otel-collector.monitoring:4317",
+			Protocol: "grpc",
+			Timeout:  &otlpTimeout,
+			Headers:  map[string]string{"api-key": "secret"},
+		},
+	}, c.Output)
+	maxCollectionAge := 2 * time.Minute
+	assert.Equal(t, Health{ListenAddress: ":9296", MaxCollectionAge: &maxCollectionAge}, c.Health)
+	leaseDuration := 2 * time.Minute
+	assert.Equal(t, LeaderElection{
+		Enabled:       boolPtr(false),
+		Namespace:     "newrelic",
+		LeaseName:     "nri-kubernetes-leader",
+		LeaseDuration: &leaseDuration,
+	}, c.LeaderElection)
+	assert.Equal(t, Filters{
+		Exclude: []FilterRule{
+			{Namespaces: []string{"kube-system", "ci-*"}},
+			{NamespaceLabels: map[string]string{"team": "ci"}},
+			{Groups: []string{"volume"}, PodLabels: map[string]string{"app": "batch-*"}},
+			{Groups: []string{"container"}, Metrics: []string{"label.*"}},
+		},
+	}, c.Filters)
+	assert.Equal(t, Attributes{
+		Labels: AttributeSelector{
+			Exclude: []string{"pod-template-hash", "controller-revision-hash", "/^pod-template-generation$/"},
+		},
+		Annotations:        AttributeSelector{Include: []string{"prometheus.io/*"}},
+		CollectAnnotations: boolPtr(true),
+		MaxPerEntity:       50,
+	}, c.Attributes)
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := Load("testdata/missing.yml")
+	assert.Error(t, err)
+}
+
+func TestParse_EmptyConfig(t *testing.T) {
+	c, err := Parse([]byte(""))
+	require.NoError(t, err)
+	assert.Equal(t, &Config{}, c)
+}
+
+func TestParse_UnknownFieldsAreRejected(t *testing.T) {
+	_, err := Parse([]byte("kube_state_metrics:\n  schema: https\n"))
+	assert.Error(t, err)
+}
+
+func TestParse_InvalidDuration(t *testing.T) {
+	_, err := Parse([]byte("cache:\n  discovery_ttl: one hour\n"))
+	assert.Error(t, err)
+}
+
+func TestOutputs(t *testing.T) {
+	outputs, err := Outputs("both")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"stdout": true, "prometheus": true}, outputs)
+
+	outputs, err = Outputs("stdout, otlp")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"stdout": true, "otlp": true}, outputs)
+
+	_, err = Outputs("stdout,")
+	assert.Error(t, err)
+}
+
+func TestParse_OTLPOutputWithoutDaemon(t *testing.T) {
+	_, err := Parse([]byte("daemon:\n  enabled: false\noutput:\n  mode: otlp"))
+	assert.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+		errors []string
+	}{
+		{
+			name:   "timeout not positive",
+			config: "timeout: 0s",
+			errors: []string{"timeout: must be greater than 0, got 0s"},
+		},
+		{
+			name: "invalid kubelet settings",
+			config: `
+kubelet:
+  usage_source: cadvisor
+  network_interfaces:
+    include: [""]
+    exclude: ["/veth(/"]`,
+			errors: []string{
+				`kubelet.usage_source: must be "summary", "resource" or "auto", got "cadvisor"`,
+				"kubelet.network_interfaces.include: must not contain empty patterns",
+				"kubelet.network_interfaces.exclude: invalid regular expression \"/veth(/\": error parsing regexp: missing closing ): `veth(`",
+			},
+		},
+		{
+			name: "invalid kube-state-metrics settings",
+			config: `
+kube_state_metrics:
+  url: kube-state-metrics:8080
+  scheme: ftp
+  port: 70000
+  distributed: true`,
+			errors: []string{
+				`kube_state_metrics.url: "kube-state-metrics:8080" must use the http or https scheme`,
+				`kube_state_metrics.scheme: must be "http" or "https", got "ftp"`,
+				"kube_state_metrics.port: must be a valid port number, got 70000",
+				"kube_state_metrics.distributed: requires kube_state_metrics.pod_label to be set",
+			},
+		},
+		{
+			name: "invalid cadvisor settings",
+			config: `
+cadvisor:
+  url: cadvisor:8080
+  scheme: ftp
+  port: 70000
+  authentication: basic`,
+			errors: []string{
+				`cadvisor.url: "cadvisor:8080" must use the http or https scheme`,
+				`cadvisor.scheme: must be "http" or "https", got "ftp"`,
+				"cadvisor.port: must be a valid port number, got 70000",
+				`cadvisor.authentication: must be "none" or "service_account", got "basic"`,
+			},
+		},
+		{
+			name: "invalid cadvisor source",
+			config: `
+cadvisor:
+  source: standalone`,
+			errors: []string{
+				`cadvisor.source: "standalone" requires cadvisor.url, cadvisor.pod_label or cadvisor.port to be set`,
+			},
+		},
+		{
+			name: "unknown cadvisor source",
+			config: `
+cadvisor:
+  source: daemonset`,
+			errors: []string{
+				`cadvisor.source: must be "kubelet", "standalone" or "auto", got "daemonset"`,
+			},
+		},
+		{
+			name: "invalid control plane settings",
+			config: `
+control_plane:
+  api_server:
+    secure_port: https
+    endpoint_url: https://localhost:6443
+  etcd:
+    tls_secret_namespace: kube-system
+  scheduler:
+    endpoint_url: http://
+`,
+			errors: []string{
+				"control_plane.api_server: secure_port and endpoint_url can not both be set",
+				`control_plane.api_server.secure_port: must be a valid port number, got "https"`,
+				`control_plane.scheduler.endpoint_url: "http://" has no host`,
+				"control_plane.etcd.tls_secret_namespace: requires control_plane.etcd.tls_secret_name to be set",
+			},
+		},
+		{
+			name: "negative durations",
+			config: `
+cache:
+  discovery_ttl: -1h
+jobs:
+  timeout: -1s
+  max_concurrent: -1
+daemon:
+  interval: 0s
+`,
+			errors: []string{
+				"cache.discovery_ttl: must not be negative, got -1h0m0s",
+				"jobs.timeout: must not be negative, got -1s",
+				"jobs.max_concurrent: must not be negative, got -1",
+				"daemon.interval: must be greater than 0, got 0s",
+			},
+		},
+		{
+			name:   "unknown output mode",
+			config: "output:\n  mode: statsd",
+			errors: []string{`output.mode: must list some of stdout, prometheus, otlp, both separated by commas, got "statsd"`},
+		},
+		{
+			name:   "prometheus output without daemon",
+			config: "daemon:\n  enabled: false\noutput:\n  mode: stdout,prometheus",
+			errors: []string{"output.mode: prometheus requires the daemon mode to be enabled"},
+		},
+		{
+			name:   "health endpoints without daemon",
+			config: "daemon:\n  enabled: false\nhealth:\n  listen_address: \":8080\"\n  max_collection_age: -1m",
+			errors: []string{
+				"health.listen_address: requires the daemon mode to be enabled",
+				"health.max_collection_age: must not be negative, got -1m0s",
+			},
+		},
+		{
+			name: "invalid leader election",
+			config: `
+kube_state_metrics:
+  pod_label: kube-state-metrics
+  distributed: true
+leader_election:
+  enabled: true
+  lease_duration: 500ms
+`,
+			errors: []string{
+				"leader_election.lease_duration: must be at least 1s, got 500ms",
+				"leader_election.enabled: can not be set together with kube_state_metrics.distributed",
+			},
+		},
+		{
+			name: "invalid otlp output",
+			config: `
+output:
+  otlp:
+    endpoint: localhost:4317
+    protocol: http/json
+    timeout: 0s
+`,
+			errors: []string{
+				`output.otlp.endpoint: "localhost:4317" must use the http or https scheme`,
+				`output.otlp.protocol: must be one of grpc, http/protobuf, got "http/json"`,
+				"output.otlp.timeout: must be greater than 0, got 0s",
+			},
+		},
+		{
+			name: "invalid filters",
+			config: `
+filters:
+  include:
+    - {}
+  exclude:
+    - namespaces: [""]
+      pod_labels:
+        "": web
+`,
+			errors: []string{
+				"filters.include[0]: must set at least one of namespaces, namespace_labels, groups, pod_labels or metrics",
+				"filters.exclude[0].namespaces: must not contain empty names",
+				"filters.exclude[0].pod_labels: must not contain empty label names",
+			},
+		},
+		{
+			name: "invalid attributes",
+			config: `
+attributes:
+  labels:
+    include: [""]
+  annotations:
+    exclude: ["/team-(/"]
+  max_per_entity: -1
+`,
+			errors: []string{
+				"attributes.labels.include: must not contain empty patterns",
+				"attributes.annotations.exclude: invalid regular expression \"/team-(/\": error parsing regexp: missing closing ): `team-(`",
+				"attributes.max_per_entity: must not be negative, got -1",
+			},
+		},
+		{
+			name: "invalid custom metrics",
+			config: `
+custom_metrics:
+  kube_state_metrics:
+    deployment:
+      - type: counter
+        include_labels: [condition]
+        ignore_labels: [status]
+      - name: paused
+        source: kube_deployment_spec_paused
+        type: gauge
+        label: deployment
+  kubelet:
+    pod:
+      - name: restarts
+        source: restarts
+        type: gauge
+        ignore_labels: [container]
+  cadvisor:
+    pod:
+      - name: memoryCacheBytes
+        source: container_memory_cache
+        type: gauge
+  control_plane:
+    kubelet:
+      - name: up
+        source: up
+        type: gauge
+`,
+			errors: []string{
+				"custom_metrics.kube_state_metrics.deployment[0]: name is mandatory",
+				"custom_metrics.kube_state_metrics.deployment[0]: source is mandatory",
+				`custom_metrics.kube_state_metrics.deployment[0]: type must be one of GAUGE, RATE, DELTA, ATTRIBUTE, got "counter"`,
+				"custom_metrics.kube_state_metrics.deployment[0]: include_labels and ignore_labels can not both be set",
+				"custom_metrics.kube_state_metrics.deployment[1]: label can only be set for ATTRIBUTE metrics",
+				"custom_metrics.kubelet.pod[0]: label, include_labels and ignore_labels are only supported for Prometheus sources",
+				"custom_metrics.cadvisor.pod: only the container group is supported",
+				"custom_metrics.control_plane.kubelet: unknown component, must be one of api_server, etcd, scheduler, controller_manager",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.config))
+			require.Error(t, err)
+			verr, ok := err.(ValidationError)
+			require.True(t, ok, "expected a ValidationError, got %v", err)
+			assert.Equal(t, ValidationError(tc.errors), verr)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/newrelic/nri-kubernetes/src/config"
+	"github.com/newrelic/nri-kubernetes/src/controlplane"
+	"github.com/newrelic/nri-kubernetes/src/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *testing.T) {
+	cfg, err := config.Load("config/testdata/config.yml")
+	require.NoError(t, err)
+
+	a := argumentList{
+		ClusterName:                 "from-flags",
+		Timeout:                     5000,
+		DiscoveryCacheTTL:           "1h",
+		KubeStateMetricsPort:        8080,
+		KubeStateMetricsScheme:      "http",
+		EnableVolumeMetrics:         true,
+		MaxConcurrentJobs:           4,
+		APIServerCacheTTL:           "5m",
+		APIServerCacheK8SVersionTTL: "3h",
+	}
+	applyConfig(&a, cfg, map[string]bool{"cluster_name": true, "kube_state_metrics_port": true})
+
+	// explicitly set arguments take precedence over the configuration
+	assert.Equal(t, "from-flags", a.ClusterName)
+	assert.Equal(t, 8080, a.KubeStateMetricsPort)
+
+	assert.True(t, a.Verbose)
+	assert.Equal(t, 10000, a.Timeout)
+	assert.False(t, a.EnableVolumeMetrics)
+	assert.True(t, a.EnableNetworkInterfaces)
+	assert.Equal(t, "/host/proc/net/route", a.NetworkRouteFile)
+	assert.Equal(t, "auto", a.KubeletUsageSource)
+	assert.False(t, a.DisableKubeStateMetrics)
+	assert.Equal(t, "kube-state-metrics", a.KubeStateMetricsPodLabel)
+	assert.Equal(t, "https", a.KubeStateMetricsScheme)
+	assert.True(t, a.DistributedKubeStateMetrics)
+	assert.Equal(t, "auto", a.CadvisorSource)
+	assert.Empty(t, a.CadvisorURL)
+	assert.Equal(t, "cadvisor", a.CadvisorPodLabel)
+	assert.Equal(t, 8443, a.CadvisorPort)
+	assert.Equal(t, "https", a.CadvisorScheme)
+	assert.Equal(t, "service_account", a.CadvisorAuthentication)
+	assert.True(t, a.CadvisorInsecureSkipVerify)
+	assert.Equal(t, "/etc/cadvisor/ca.crt", a.CadvisorCAFile)
+	assert.Equal(t, "6443", a.APIServerSecurePort)
+	assert.Equal(t, "https://localhost:4001", a.EtcdEndpointURL)
+	assert.Equal(t, "etcd-tls", a.EtcdTLSSecretName)
+	assert.Equal(t, "kube-system", a.EtcdTLSSecretNamespace)
+	assert.Equal(t, "https://localhost:10259", a.SchedulerEndpointURL)
+	assert.Equal(t, "https://localhost:10257", a.ControllerManagerEndpointURL)
+	assert.Equal(t, "/tmp/nr-kubernetes", a.CacheDir)
+	assert.Equal(t, "2h0m0s", a.DiscoveryCacheTTL)
+	assert.Equal(t, "0s", a.APIServerCacheTTL)
+	assert.Equal(t, "1h0m0s", a.APIServerCacheK8SVersionTTL)
+	assert.Equal(t, 3000, a.JobTimeout)
+	assert.Equal(t, 2, a.MaxConcurrentJobs)
+	assert.False(t, a.EnableSelfMetrics)
+	assert.True(t, a.Daemon)
+	assert.Equal(t, "30s", a.DaemonInterval)
+	assert.Equal(t, "stdout,prometheus,otlp", a.Output)
+	assert.Equal(t, ":9296", a.PrometheusListenAddress)
+	assert.Equal(t, "http://otel-collector.monitoring:4317", a.OtlpEndpoint)
+	assert.Equal(t, "grpc", a.OtlpProtocol)
+	assert.Equal(t, 10000, a.OtlpTimeout)
+	assert.Equal(t, "api-key=secret", a.OtlpHeaders)
+	assert.Equal(t, ":9296", a.HealthListenAddress)
+	assert.False(t, a.LeaderElection)
+	assert.Equal(t, "newrelic", a.LeaderElectionNamespace)
+	assert.Equal(t, "nri-kubernetes-leader", a.LeaderElectionLeaseName)
+	assert.Equal(t, "2m0s", a.LeaderElectionLeaseDuration)
+	assert.Equal(t, "2m0s", a.HealthMaxCollectionAge)
+}
+
+func TestApplyConfig_EmptyConfigKeepsArguments(t *testing.T) {
+	a := argumentList{
+		ClusterName:          "cluster",
+		Timeout:              5000,
+		KubeStateMetricsPort: 8080,
+		EnableVolumeMetrics:  true,
+		MaxConcurrentJobs:    4,
+	}
+	expected := a
+
+	applyConfig(&a, &config.Config{}, map[string]bool{})
+
+	assert.Equal(t, expected, a)
+}
+
+func TestNewMetricDefinitions(t *testing.T) {
+	cfg, err := config.Load("config/testdata/config.yml")
+	require.NoError(t, err)
+
+	d, err := newMetricDefinitions(cfg.CustomMetrics, config.NetworkInterfaces{})
+	require.NoError(t, err)
+
+	assert.Len(t, d.ksmSpecs["deployment"].Specs, len(metric.KSMSpecs["deployment"].Specs)+1)
+	assert.Len(t, d.ksmQueries, len(metric.KSMQueries)+1)
+	assert.Len(t, d.kubeletSpecs["node"].Specs, len(metric.KubeletSpecs["node"].Specs)+1)
+	assert.Len(t, d.kubeletSpecs["container"].Specs, len(metric.KubeletSpecs["container"].Specs)+1)
+	assert.Len(t, d.cadvisorQueries, len(metric.CadvisorQueries)+1)
+
+	require.Len(t, d.controlPlaneSpecs, 1)
+	components := controlplane.BuildComponentList(d.controlPlaneSpecs...)
+	for _, c := range components {
+		if c.Name != controlplane.APIServer {
+			continue
+		}
+		assert.Len(t, c.Specs["api-server"].Specs, len(metric.APIServerSpecs["api-server"].Specs)+1)
+		assert.Len(t, c.Queries, len(metric.APIServerQueries)+1)
+	}
+}
+
+func TestNewMetricDefinitions_UnknownGroup(t *testing.T) {
+	_, err := newMetricDefinitions(config.CustomMetrics{
+		Kubelet: map[string][]config.MetricSpec{
+			"cronjob": {{Name: "createdAt", Source: "createdAt", Type: "GAUGE"}},
+		},
+	}, config.NetworkInterfaces{})
+	assert.EqualError(t, err, `custom kubelet metrics: unknown spec group "cronjob" for custom metrics`)
+}
+
+func TestNewMetricDefinitions_NetworkInterfaces(t *testing.T) {
+	enabled := true
+	d, err := newMetricDefinitions(config.CustomMetrics{}, config.NetworkInterfaces{
+		Enabled: &enabled,
+		Exclude: []string{"veth*"},
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, d.kubeletSpecs["node"].Specs, len(metric.KubeletSpecs["node"].Specs)+3)
+	assert.Len(t, d.kubeletSpecs["pod"].Specs, len(metric.KubeletSpecs["pod"].Specs)+3)
+	assert.Len(t, d.kubeletSpecs["container"].Specs, len(metric.KubeletSpecs["container"].Specs))
+}
+
+func TestNewMetricDefinitions_NetworkInterfacesDisabled(t *testing.T) {
+	d, err := newMetricDefinitions(config.CustomMetrics{}, config.NetworkInterfaces{Include: []string{"eth*"}})
+	require.NoError(t, err)
+
+	assert.Len(t, d.kubeletSpecs["node"].Specs, len(metric.KubeletSpecs["node"].Specs))
+}
@@ -94,6 +94,20 @@ func (c *ControlPlaneComponentClient) Do(method, urlPath string) (*http.Response
 	return resp, err
 }
 
+// Describe returns the endpoint of the component and how the client authenticates against it. The secure
+// endpoint is described when the component has one, as it is the one called first.
+func (c *ControlPlaneComponentClient) Describe() client.Description {
+	e := c.secureEndpoint
+	if e.String() == "" {
+		e = c.endpoint
+	}
+	d := client.Description{Endpoint: e.String(), Authentication: string(c.authenticationMethod)}
+	if e != c.endpoint && c.InsecureFallback {
+		d.Authentication += ", falling back to " + c.endpoint.String()
+	}
+	return d
+}
+
 func (c *ControlPlaneComponentClient) buildPrometheusRequest(method string, e url.URL, urlPath string) (*http.Request, error) {
 	e.Path = path.Join(e.Path, urlPath)
 	r, err := prometheus.NewRequest(method, e.String())
@@ -107,6 +107,20 @@ func WithEndpointURL(name ComponentName, endpointURL string) ComponentOption {
 	}
 }
 
+// WithSpecs configures the component to collect the given metric specs, querying its metrics endpoint with
+// the given queries
+func WithSpecs(name ComponentName, specs definition.SpecGroups, queries []prometheus.Query) ComponentOption {
+	return func(components []Component) {
+		component := findComponentByName(name, components)
+		if component == nil {
+			panic(fmt.Sprintf("expected component %s in list of components, but not found", string(name)))
+		}
+
+		component.Specs = specs
+		component.Queries = queries
+	}
+}
+
 // findComponentByName will find the component with the given name
 func findComponentByName(name ComponentName, components []Component) *Component {
 	for i := range components {
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/newrelic/nri-kubernetes/src/health"
+)
+
+// runDaemon executes the collect function immediately and then once per interval, until the process receives
+// an interrupt or termination signal. Errors returned by the collect function are logged and do not stop the
+// daemon.
+func runDaemon(logger *logrus.Logger, interval time.Duration, collect func() error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Debugf("Running as a daemon, collecting every %s", interval)
+	for {
+		if err := collect(); err != nil {
+			logger.WithError(err).Error("collecting metrics")
+		}
+
+		select {
+		case <-ticker.C:
+		case s := <-signals:
+			logger.Debugf("Received %s signal, stopping", s)
+			return
+		}
+	}
+}
+
+// healthMaxMissedCollections is the amount of daemon intervals without a successful collection after which the
+// integration is reported as unhealthy, unless a maximum collection age is set.
+const healthMaxMissedCollections = 3
+
+// newHealthChecker creates the checker backing the health endpoints, or returns nil if they are disabled.
+func newHealthChecker(interval time.Duration) (*health.Checker, error) {
+	if args.HealthListenAddress == "" {
+		return nil, nil
+	}
+	if !args.Daemon {
+		return nil, errors.New("the health endpoints require running as a daemon")
+	}
+
+	maxAge, err := time.ParseDuration(args.HealthMaxCollectionAge)
+	if err != nil || maxAge < 0 {
+		return nil, fmt.Errorf("invalid health max collection age %q, it must be a non negative duration", args.HealthMaxCollectionAge)
+	}
+	if maxAge == 0 {
+		maxAge = healthMaxMissedCollections * interval
+	}
+	return health.NewChecker(maxAge), nil
+}
+
+// startServers starts serving the Prometheus metrics and the health endpoints, when they are enabled. They share
+// the server when they are configured on the same address.
+func startServers(logger *logrus.Logger, outputs *outputs, checker *health.Checker) []*http.Server {
+	muxes := make(map[string]*http.ServeMux)
+	mux := func(address string) *http.ServeMux {
+		if _, ok := muxes[address]; !ok {
+			muxes[address] = http.NewServeMux()
+		}
+		return muxes[address]
+	}
+
+	if outputs.prometheus != nil {
+		mux(args.PrometheusListenAddress).Handle("/metrics", outputs.prometheus)
+	}
+	if checker != nil {
+		m := mux(args.HealthListenAddress)
+		m.Handle("/healthz", checker.LivenessHandler())
+		m.Handle("/readyz", checker.ReadinessHandler())
+	}
+
+	servers := make([]*http.Server, 0, len(muxes))
+	for address, m := range muxes {
+		servers = append(servers, serve(logger, address, m))
+	}
+	return servers
+}
+
+// serve starts serving the given handler on the given address. Errors while serving are logged, as they should
+// not stop the collection of metrics for the rest of the outputs.
+func serve(logger *logrus.Logger, address string, handler http.Handler) *http.Server {
+	server := &http.Server{Addr: address, Handler: handler}
+
+	go func() {
+		logger.Debugf("Serving on %s", address)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Errorf("serving on %s", address)
+		}
+	}()
+
+	return server
+}
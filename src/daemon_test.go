@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/src/prometheus/exposition"
+)
+
+func TestNewHealthChecker(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+
+	args = argumentList{}
+	checker, err := newHealthChecker(time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, checker)
+
+	args = argumentList{HealthListenAddress: ":8080", HealthMaxCollectionAge: "0s"}
+	_, err = newHealthChecker(time.Minute)
+	assert.EqualError(t, err, "the health endpoints require running as a daemon")
+
+	args = argumentList{HealthListenAddress: ":8080", HealthMaxCollectionAge: "-1m", Daemon: true}
+	_, err = newHealthChecker(time.Minute)
+	assert.Error(t, err)
+
+	args = argumentList{HealthListenAddress: ":8080", HealthMaxCollectionAge: "0s", Daemon: true}
+	checker, err = newHealthChecker(time.Minute)
+	require.NoError(t, err)
+	assert.NotNil(t, checker)
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close() // nolint: errcheck
+	return l.Addr().String()
+}
+
+func TestStartServers_SharedAddress(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+
+	address := freeAddress(t)
+	args = argumentList{
+		Daemon:                  true,
+		PrometheusListenAddress: address,
+		HealthListenAddress:     address,
+		HealthMaxCollectionAge:  "0s",
+	}
+	checker, err := newHealthChecker(time.Minute)
+	require.NoError(t, err)
+
+	servers := startServers(logger, &outputs{prometheus: exposition.NewExporter()}, checker)
+	require.Len(t, servers, 1)
+	defer servers[0].Close() // nolint: errcheck
+
+	expected := map[string]int{
+		"/metrics": http.StatusOK,
+		"/healthz": http.StatusOK,
+		"/readyz":  http.StatusServiceUnavailable,
+	}
+	for path, status := range expected {
+		resp, err := get("http://" + address + path)
+		require.NoError(t, err, path)
+		resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, status, resp.StatusCode, path)
+	}
+}
+
+// get retries the request for a while, as the servers are started in the background.
+func get(url string) (resp *http.Response, err error) {
+	for i := 0; i < 100; i++ {
+		if resp, err = http.Get(url); err == nil {
+			return resp, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil, err
+}
@@ -0,0 +1,40 @@
+package data
+
+import (
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+)
+
+// MetricSetsSnapshot holds how many metric sets each entity of an integration held when it was taken, to find
+// out what has been populated since then.
+type MetricSetsSnapshot map[*sdk.EntityData]int
+
+// SnapshotMetricSets takes a snapshot of the metric sets held by the entities of the given integration.
+func SnapshotMetricSets(integration *sdk.IntegrationProtocol2) MetricSetsSnapshot {
+	s := make(MetricSetsSnapshot, len(integration.Data))
+	for _, e := range integration.Data {
+		s[e] = len(e.Metrics)
+	}
+	return s
+}
+
+// NewMetricSetsPerEntity returns the metric sets the entities of the integration got since the snapshot was
+// taken, one slice per entity with new metric sets, in the order of the entities of the integration.
+func (s MetricSetsSnapshot) NewMetricSetsPerEntity(integration *sdk.IntegrationProtocol2) [][]sdkMetric.MetricSet {
+	var perEntity [][]sdkMetric.MetricSet
+	for _, e := range integration.Data {
+		if newMetricSets := e.Metrics[s[e]:]; len(newMetricSets) > 0 {
+			perEntity = append(perEntity, newMetricSets)
+		}
+	}
+	return perEntity
+}
+
+// NewMetricSets returns the metric sets the entities of the integration got since the snapshot was taken.
+func (s MetricSetsSnapshot) NewMetricSets(integration *sdk.IntegrationProtocol2) []sdkMetric.MetricSet {
+	var metricSets []sdkMetric.MetricSet
+	for _, entityMetricSets := range s.NewMetricSetsPerEntity(integration) {
+		metricSets = append(metricSets, entityMetricSets...)
+	}
+	return metricSets
+}
@@ -0,0 +1,43 @@
+package data
+
+import (
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricSetsSnapshot(t *testing.T) {
+	i, err := sdk.NewIntegrationProtocol2("nr.test", "1.0.0", new(struct{}))
+	require.NoError(t, err)
+
+	newMetricSet := func(entityName string, value int) {
+		e, err := i.Entity(entityName, "test")
+		require.NoError(t, err)
+		e.NewMetricSet("TestSample").SetMetric("value", value, sdkMetric.GAUGE) // nolint: errcheck
+	}
+
+	newMetricSet("unchanged", 1)
+	newMetricSet("updated", 1)
+	snapshot := SnapshotMetricSets(i)
+	assert.Empty(t, snapshot.NewMetricSets(i))
+
+	newMetricSet("updated", 2)
+	newMetricSet("new", 1)
+	newMetricSet("new", 2)
+
+	perEntity := snapshot.NewMetricSetsPerEntity(i)
+	require.Len(t, perEntity, 2)
+	assert.Len(t, perEntity[0], 1)
+	assert.Equal(t, 2, perEntity[0][0]["value"])
+	assert.Len(t, perEntity[1], 2)
+	assert.Equal(t, "test:new", perEntity[1][0]["entityName"])
+
+	metricSets := snapshot.NewMetricSets(i)
+	require.Len(t, metricSets, 3)
+	for i, value := range []int{2, 1, 2} {
+		assert.Equal(t, value, metricSets[i]["value"])
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/leader"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+)
+
+// newElector creates the leader elector deciding which instance of the integration runs the cluster scoped jobs,
+// or returns nil if the leader election is disabled. Each node competes with its own name.
+func newElector(logger *logrus.Logger, k8s client.Kubernetes, nodeName string, interval time.Duration) (*leader.Elector, error) {
+	if !args.LeaderElection {
+		return nil, nil
+	}
+	if args.DistributedKubeStateMetrics {
+		return nil, errors.New("leader election and distributed kube-state-metrics can not both be enabled")
+	}
+
+	leaseDuration, err := time.ParseDuration(args.LeaderElectionLeaseDuration)
+	if err != nil || leaseDuration < time.Second {
+		return nil, fmt.Errorf("invalid leader election lease duration %q, it must be at least 1s", args.LeaderElectionLeaseDuration)
+	}
+	// The leader renews the lease on every collection.
+	if args.Daemon && leaseDuration <= interval {
+		return nil, fmt.Errorf("the leader election lease duration %s must be longer than the daemon interval %s", leaseDuration, interval)
+	}
+
+	clientset, err := kubernetes.NewForConfig(k8s.Config())
+	if err != nil {
+		return nil, err
+	}
+	return leader.NewElector(
+		leader.NewLeaseClient(clientset.CoreV1().RESTClient()),
+		args.LeaderElectionNamespace,
+		args.LeaderElectionLeaseName,
+		nodeName,
+		leaseDuration,
+		logger,
+	), nil
+}
+
+// scheduledJobs returns the jobs to run in a collection: all of them if there is no elector, and only the ones
+// that are not cluster scoped if this instance is not the leader. Failing to get the lease is treated as not
+// being the leader, so the cluster data is not reported twice.
+func scheduledJobs(logger *logrus.Logger, jobs []*scrape.Job, elector *leader.Elector) []*scrape.Job {
+	if elector == nil {
+		return jobs
+	}
+
+	isLeader, err := elector.IsLeader()
+	if err != nil {
+		logger.WithError(err).Warn("checking the leader election lease, skipping the cluster scoped jobs")
+	}
+	if isLeader {
+		return jobs
+	}
+
+	var scheduled []*scrape.Job
+	for _, job := range jobs {
+		if !job.ClusterScoped {
+			scheduled = append(scheduled, job)
+		}
+	}
+	return scheduled
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/newrelic/nri-kubernetes/src/leader"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+)
+
+func TestNewElector_Errors(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+
+	args = argumentList{}
+	elector, err := newElector(logger, nil, "node-1", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, elector)
+
+	testCases := map[string]argumentList{
+		"distributed kube-state-metrics": {LeaderElection: true, DistributedKubeStateMetrics: true, LeaderElectionLeaseDuration: "1m"},
+		"invalid lease duration":         {LeaderElection: true, LeaderElectionLeaseDuration: "one minute"},
+		"lease shorter than interval":    {LeaderElection: true, LeaderElectionLeaseDuration: "30s", Daemon: true},
+	}
+	for name, a := range testCases {
+		t.Run(name, func(t *testing.T) {
+			args = a
+			_, err := newElector(logger, nil, "node-1", time.Minute)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// leases keeps the leases in memory.
+type leases map[string]leader.Lease
+
+func (l leases) Get(namespace, name string) (*leader.Lease, error) {
+	lease, ok := l[namespace+"/"+name]
+	if !ok {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, name)
+	}
+	return &lease, nil
+}
+
+func (l leases) Create(lease *leader.Lease) (*leader.Lease, error) {
+	return l.Update(lease)
+}
+
+func (l leases) Update(lease *leader.Lease) (*leader.Lease, error) {
+	l[lease.Namespace+"/"+lease.Name] = *lease
+	return lease, nil
+}
+
+func TestScheduledJobs(t *testing.T) {
+	kubelet := &scrape.Job{Name: "kubelet"}
+	ksm := &scrape.Job{Name: "kube-state-metrics", ClusterScoped: true}
+	jobs := []*scrape.Job{ksm, kubelet}
+
+	// Without leader election, every job runs
+	assert.Equal(t, jobs, scheduledJobs(logger, jobs, nil))
+
+	client := leases{}
+	node1 := leader.NewElector(client, "default", "nri-kubernetes-leader", "node-1", time.Minute, logger)
+	node2 := leader.NewElector(client, "default", "nri-kubernetes-leader", "node-2", time.Minute, logger)
+
+	assert.Equal(t, jobs, scheduledJobs(logger, jobs, node1))
+	assert.Equal(t, []*scrape.Job{kubelet}, scheduledJobs(logger, jobs, node2))
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+
+	"github.com/newrelic/nri-kubernetes/src/explain"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+)
+
+// explainCommand runs the discovery and the scrape jobs, and writes a report of what they would publish instead
+// of publishing it.
+const explainCommand = "explain"
+
+// explainJobs writes to the standard output a human readable report of what each job would populate, once limited
+// and filtered by the given chain.
+func explainJobs(jobs []*scrape.Job, clusterName string, chain explain.Chain) error {
+	reports := make([]explain.JobReport, 0, len(jobs))
+	for _, job := range jobs {
+		reports = append(reports, explain.Job(job, clusterName, chain))
+	}
+	return explain.Write(os.Stdout, reports)
+}
@@ -0,0 +1,244 @@
+// Package explain describes what the scrape jobs would report, and why some metrics would be missing, without
+// populating nor publishing anything.
+package explain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/metric"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// JobReport describes what a scrape job fetched and what it would report.
+type JobReport struct {
+	Name   string
+	Client client.Description
+	// Errors are the errors grouping the raw data. When they are not recoverable, there are no entities.
+	Errors      []string
+	Recoverable bool
+	// PopulateErrors are the errors of the populators limiting and filtering the data, like the ones looking up
+	// the labels of the namespaces.
+	PopulateErrors []string
+	Entities       []EntityReport
+}
+
+// EntityReport describes an entity of a scrape job.
+type EntityReport struct {
+	Group string
+	RawID string
+	// ID and Type are the ones generated by the IDGenerator and TypeGenerator of the group. Error tells why they
+	// could not be generated, in which case the entity is not reported.
+	ID        string
+	Type      string
+	EventType string
+	Error     string
+	Metrics   []MetricReport
+	// Dropped are the raw values discarded, while being fetched, because New Relic does not support them.
+	Dropped map[string]float64
+}
+
+// MetricReport describes a metric of an entity.
+type MetricReport struct {
+	Name  string
+	Type  sdkMetric.SourceType
+	Value interface{}
+	// Skipped tells why the metric is not reported. It is empty for reported metrics.
+	Skipped string
+}
+
+// Chain wraps a populator with the ones limiting and filtering the data before populating it, like the populators
+// of the scrape jobs are wrapped.
+type Chain func(data.Populator) data.Populator
+
+// Job runs the grouper of the given job and describes what it would report. The raw groups and the specs are
+// passed through the given chain, so the entities, metrics and attributes dropped by the filters and the
+// attribute limits are not described. A nil chain describes everything the grouper fetched.
+func Job(job *scrape.Job, clusterName string, chain Chain) JobReport {
+	report := JobReport{Name: job.Name, Client: client.Describe(job.Client)}
+	if job.Client == nil {
+		report.Client = client.Description{Endpoint: "not applicable", Authentication: "not applicable"}
+	}
+
+	groups, errs := job.Grouper.Group(job.Specs)
+	if errs != nil && len(errs.Errors) > 0 {
+		report.Recoverable = errs.Recoverable
+		for _, err := range errs.Errors {
+			report.Errors = append(report.Errors, err.Error())
+		}
+		if !errs.Recoverable {
+			return report
+		}
+	}
+
+	specs := job.Specs
+	if chain != nil {
+		r := &recorder{}
+		result := chain(r).Populate(groups, specs, nil, clusterName, nil)
+		for _, err := range result.Errors {
+			report.PopulateErrors = append(report.PopulateErrors, err.Error())
+		}
+		groups, specs = r.groups, r.specs
+	}
+
+	report.Entities = Entities(groups, specs, clusterName)
+	return report
+}
+
+// recorder is a populator keeping the raw groups and the specs it is given, instead of populating them.
+type recorder struct {
+	groups definition.RawGroups
+	specs  definition.SpecGroups
+}
+
+func (r *recorder) Populate(
+	groups definition.RawGroups,
+	specs definition.SpecGroups,
+	_ *sdk.IntegrationProtocol2,
+	_ string,
+	_ *version.Info,
+) data.PopulateResult {
+	r.groups, r.specs = groups, specs
+	return data.PopulateResult{Populated: true}
+}
+
+// Entities describes the entities populated from the given raw groups and the metrics they would report. It
+// follows the same steps as the populate functions of the definition package.
+func Entities(groups definition.RawGroups, specs definition.SpecGroups, clusterName string) []EntityReport {
+	var entities []EntityReport
+	for _, groupLabel := range sortedKeys(groups) {
+		specGroup, ok := specs[groupLabel]
+		if !ok {
+			continue
+		}
+
+		rawEntityIDs := make([]string, 0, len(groups[groupLabel]))
+		for rawEntityID := range groups[groupLabel] {
+			rawEntityIDs = append(rawEntityIDs, rawEntityID)
+		}
+		sort.Strings(rawEntityIDs)
+
+		for _, rawEntityID := range rawEntityIDs {
+			entities = append(entities, entity(groups, specGroup, groupLabel, rawEntityID, clusterName))
+		}
+	}
+	return entities
+}
+
+func entity(
+	groups definition.RawGroups,
+	specGroup definition.SpecGroup,
+	groupLabel, rawEntityID, clusterName string,
+) EntityReport {
+	e := EntityReport{Group: groupLabel, RawID: rawEntityID, ID: rawEntityID}
+
+	if generator := specGroup.IDGenerator; generator != nil {
+		id, err := generator(groupLabel, rawEntityID, groups)
+		if err != nil {
+			e.Error = fmt.Sprintf("generating the entity ID: %s", err)
+			return e
+		}
+		e.ID = id
+	}
+	if generator := specGroup.TypeGenerator; generator != nil {
+		entityType, err := generator(groupLabel, rawEntityID, groups, clusterName)
+		if err != nil {
+			e.Error = fmt.Sprintf("generating the entity type: %s", err)
+			return e
+		}
+		e.Type = entityType
+	}
+	eventType, err := metric.K8sMetricSetTypeGuesser(clusterName, groupLabel, rawEntityID, groups)
+	if err != nil {
+		e.Error = fmt.Sprintf("guessing the event type: %s", err)
+		return e
+	}
+	e.EventType = eventType
+
+	for _, spec := range specGroup.Specs {
+		e.Metrics = append(e.Metrics, metrics(spec, groupLabel, rawEntityID, groups)...)
+	}
+
+	for rawName, rawValue := range groups[groupLabel][rawEntityID] {
+		for name, value := range prometheus.DroppedSummaryValues(rawName, rawValue) {
+			if e.Dropped == nil {
+				e.Dropped = make(map[string]float64)
+			}
+			e.Dropped[name] = value
+		}
+	}
+	return e
+}
+
+// metrics describes the metrics fetched by the given spec.
+func metrics(spec definition.Spec, groupLabel, rawEntityID string, groups definition.RawGroups) []MetricReport {
+	value, err := spec.ValueFunc(groupLabel, rawEntityID, groups)
+	if err != nil {
+		reason := fmt.Sprintf("fetching the value failed: %s", err)
+		if spec.Optional {
+			reason = fmt.Sprintf("optional, error ignored: %s", err)
+		}
+		return []MetricReport{{Name: spec.Name, Type: spec.Type, Skipped: reason}}
+	}
+
+	multiple, ok := value.(definition.FetchedValues)
+	if !ok {
+		return []MetricReport{check(MetricReport{Name: spec.Name, Type: spec.Type, Value: value}, spec.Optional)}
+	}
+
+	if len(multiple) == 0 {
+		return []MetricReport{{Name: spec.Name, Type: spec.Type, Skipped: "no values were fetched"}}
+	}
+	names := make([]string, 0, len(multiple))
+	for name := range multiple {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reports := make([]MetricReport, 0, len(names))
+	for _, name := range names {
+		reports = append(reports, check(MetricReport{Name: name, Type: spec.Type, Value: multiple[name]}, spec.Optional))
+	}
+	return reports
+}
+
+// check sets why the given metric would be skipped, if it would.
+func check(m MetricReport, optional bool) MetricReport {
+	// RATE and DELTA values are checked as GAUGE ones, so the SDK does not store them to compute the next values.
+	sourceType := m.Type
+	if sourceType == sdkMetric.RATE || sourceType == sdkMetric.DELTA {
+		sourceType = sdkMetric.GAUGE
+	}
+	err := sdkMetric.MetricSet{}.SetMetric(m.Name, m.Value, sourceType)
+	switch {
+	case err != nil && optional:
+		m.Skipped = fmt.Sprintf("optional, error ignored: %s", err)
+	case err != nil:
+		m.Skipped = fmt.Sprintf("setting the value failed: %s", err)
+	case invalidValue(m.Value):
+		m.Skipped = "not a valid New Relic value, it would make publishing the payload fail"
+	}
+	return m
+}
+
+func invalidValue(v interface{}) bool {
+	f, ok := v.(float64)
+	return ok && (math.IsNaN(f) || math.IsInf(f, 0))
+}
+
+func sortedKeys(groups definition.RawGroups) []string {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
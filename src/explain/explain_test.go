@@ -0,0 +1,204 @@
+package explain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/filter"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+	prometheusModel "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var specs = definition.SpecGroups{
+	"pod": {
+		IDGenerator: func(groupLabel, rawEntityID string, g definition.RawGroups) (string, error) {
+			if rawEntityID == "broken" {
+				return "", errors.New("namespace not found")
+			}
+			return "default:" + rawEntityID, nil
+		},
+		TypeGenerator: func(groupLabel, rawEntityID string, g definition.RawGroups, prefix string) (string, error) {
+			return fmt.Sprintf("k8s:%s:%s", prefix, groupLabel), nil
+		},
+		Specs: []definition.Spec{
+			{Name: "cpuUsedCores", ValueFunc: definition.FromRaw("cpu"), Type: sdkMetric.GAUGE},
+			{Name: "memoryUsedBytes", ValueFunc: definition.FromRaw("memory"), Type: sdkMetric.GAUGE},
+			{Name: "reason", ValueFunc: definition.FromRaw("reason"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "restartCountDelta", ValueFunc: definition.FromRaw("restarts"), Type: sdkMetric.DELTA},
+			{Name: "status", ValueFunc: definition.FromRaw("status"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "load", ValueFunc: definition.FromRaw("load"), Type: sdkMetric.GAUGE},
+		},
+	},
+}
+
+var groups = definition.RawGroups{
+	"pod": {
+		"nginx": {
+			"cpu":      0.5,
+			"restarts": 3,
+			"status":   2,
+			"load":     math.NaN(),
+			"latency": []prometheus.Metric{{
+				Labels: prometheus.Labels{},
+				Value: &prometheusModel.Summary{
+					SampleCount: uint64Ptr(1),
+					SampleSum:   float64Ptr(math.Inf(1)),
+				},
+			}},
+		},
+		"broken": {},
+	},
+	"unknown": {
+		"something": {},
+	},
+}
+
+func uint64Ptr(u uint64) *uint64 {
+	return &u
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestEntities(t *testing.T) {
+	entities := Entities(groups, specs, "cluster")
+	require.Len(t, entities, 2)
+
+	broken := entities[0]
+	assert.Equal(t, "broken", broken.RawID)
+	assert.Equal(t, "generating the entity ID: namespace not found", broken.Error)
+	assert.Empty(t, broken.Metrics)
+
+	nginx := entities[1]
+	assert.Equal(t, "pod", nginx.Group)
+	assert.Equal(t, "nginx", nginx.RawID)
+	assert.Equal(t, "default:nginx", nginx.ID)
+	assert.Equal(t, "k8s:cluster:pod", nginx.Type)
+	assert.Equal(t, "K8sPodSample", nginx.EventType)
+	assert.Empty(t, nginx.Error)
+
+	require.Len(t, nginx.Metrics, 6)
+	assert.Equal(t, MetricReport{Name: "cpuUsedCores", Type: sdkMetric.GAUGE, Value: 0.5}, nginx.Metrics[0])
+	assert.Equal(t, "fetching the value failed: metric not found", nginx.Metrics[1].Skipped)
+	assert.Equal(t, "optional, error ignored: metric not found", nginx.Metrics[2].Skipped)
+	assert.Equal(t, MetricReport{Name: "restartCountDelta", Type: sdkMetric.DELTA, Value: 3}, nginx.Metrics[3])
+	assert.Equal(t, "setting the value failed: Invalid data type for attribute status", nginx.Metrics[4].Skipped)
+	assert.Equal(t, "not a valid New Relic value, it would make publishing the payload fail", nginx.Metrics[5].Skipped)
+
+	require.Len(t, nginx.Dropped, 1)
+	assert.True(t, math.IsInf(nginx.Dropped["latency_sum"], 1))
+}
+
+type grouper struct {
+	groups definition.RawGroups
+	errs   *data.ErrorGroup
+}
+
+func (g grouper) Group(definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
+	return g.groups, g.errs
+}
+
+type describedClient struct{}
+
+func (describedClient) Do(method, path string) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (describedClient) NodeIP() string {
+	return "1.2.3.4"
+}
+
+func (describedClient) Describe() client.Description {
+	return client.Description{Endpoint: "https://1.2.3.4:10250", Authentication: "None"}
+}
+
+func TestJob_NonRecoverableErrors(t *testing.T) {
+	job := scrape.NewScrapeJob("kubelet", grouper{
+		groups: groups,
+		errs:   &data.ErrorGroup{Errors: []error{errors.New("connection refused")}},
+	}, specs)
+	job.Client = describedClient{}
+
+	report := Job(job, "cluster", nil)
+	assert.Equal(t, JobReport{
+		Name:   "kubelet",
+		Client: client.Description{Endpoint: "https://1.2.3.4:10250", Authentication: "None"},
+		Errors: []string{"connection refused"},
+	}, report)
+}
+
+func TestJob_Filtered(t *testing.T) {
+	podSpecs := definition.SpecGroups{
+		"pod": {Specs: []definition.Spec{
+			{Name: "cpuUsedCores", ValueFunc: definition.FromRaw("cpu"), Type: sdkMetric.GAUGE},
+			{Name: "memoryUsedBytes", ValueFunc: definition.FromRaw("memory"), Type: sdkMetric.GAUGE},
+		}},
+	}
+	job := scrape.NewScrapeJob("kubelet", grouper{groups: definition.RawGroups{
+		"pod": {
+			"default_nginx":       {"namespace": "default", "cpu": 0.5, "memory": 100},
+			"kube-system_coredns": {"namespace": "kube-system", "cpu": 0.1, "memory": 50},
+		},
+	}}, podSpecs)
+	f := filter.New(nil, []filter.Rule{
+		{Namespaces: []string{"kube-system"}},
+		{Metrics: []string{"memory*"}},
+	}, nil)
+	chain := func(p data.Populator) data.Populator {
+		return filter.NewPopulator(p, f)
+	}
+
+	report := Job(job, "cluster", chain)
+	require.Len(t, report.Entities, 1)
+	assert.Equal(t, "default_nginx", report.Entities[0].RawID)
+	assert.Equal(t, []MetricReport{
+		{Name: "cpuUsedCores", Type: sdkMetric.GAUGE, Value: 0.5},
+		{Name: "memoryUsedBytes", Type: sdkMetric.GAUGE, Skipped: "no values were fetched"},
+	}, report.Entities[0].Metrics)
+	assert.Empty(t, report.PopulateErrors)
+
+	// The errors of the filter are reported
+	f = filter.New(nil, []filter.Rule{{NamespaceLabels: map[string]string{"team": "platform"}}}, nil)
+	report = Job(job, "cluster", chain)
+	assert.Len(t, report.Entities, 2)
+	assert.Len(t, report.PopulateErrors, 2)
+}
+
+func TestWrite(t *testing.T) {
+	job := scrape.NewScrapeJob("kubelet", grouper{groups: groups}, specs)
+	job.Client = describedClient{}
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, []JobReport{Job(job, "cluster", nil)}))
+
+	assert.Equal(t, `Job kubelet
+  Endpoint: https://1.2.3.4:10250
+  Authentication: None
+  Entity "broken" of group "pod"
+    not reported, generating the entity ID: namespace not found
+  Entity "nginx" of group "pod"
+    ID: default:nginx
+    Type: k8s:cluster:pod
+    Event type: K8sPodSample
+    - cpuUsedCores (GAUGE): 0.5
+    - memoryUsedBytes (GAUGE): skipped, fetching the value failed: metric not found
+    - reason (ATTRIBUTE): skipped, optional, error ignored: metric not found
+    - restartCountDelta (DELTA): 3, reported as its change since the previous collection
+    - status (ATTRIBUTE): skipped, setting the value failed: Invalid data type for attribute status
+    - load (GAUGE): skipped, not a valid New Relic value, it would make publishing the payload fail
+    - latency_sum: dropped, +Inf is not a valid New Relic value
+  2 entities, 2 metrics reported, 5 skipped
+`, b.String())
+}
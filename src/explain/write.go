@@ -0,0 +1,106 @@
+package explain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+)
+
+// Write writes a human readable version of the given reports.
+func Write(w io.Writer, reports []JobReport) error {
+	var b bytes.Buffer
+	for i, r := range reports {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		writeJob(&b, r)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeJob(b *bytes.Buffer, r JobReport) {
+	fmt.Fprintf(b, "Job %s\n", r.Name)
+	fmt.Fprintf(b, "  Endpoint: %s\n", r.Client.Endpoint)
+	fmt.Fprintf(b, "  Authentication: %s\n", r.Client.Authentication)
+
+	if len(r.Errors) > 0 {
+		if r.Recoverable {
+			fmt.Fprintln(b, "  Recoverable errors fetching the data:")
+		} else {
+			fmt.Fprintln(b, "  Errors fetching the data, nothing would be reported:")
+		}
+		for _, err := range r.Errors {
+			fmt.Fprintf(b, "    - %s\n", err)
+		}
+	}
+	if len(r.PopulateErrors) > 0 {
+		fmt.Fprintln(b, "  Errors filtering the data:")
+		for _, err := range r.PopulateErrors {
+			fmt.Fprintf(b, "    - %s\n", err)
+		}
+	}
+
+	var reported, skipped int
+	for _, e := range r.Entities {
+		fmt.Fprintf(b, "  Entity %q of group %q\n", e.RawID, e.Group)
+		if e.Error != "" {
+			fmt.Fprintf(b, "    not reported, %s\n", e.Error)
+			continue
+		}
+		fmt.Fprintf(b, "    ID: %s\n", e.ID)
+		fmt.Fprintf(b, "    Type: %s\n", e.Type)
+		fmt.Fprintf(b, "    Event type: %s\n", e.EventType)
+
+		for _, m := range e.Metrics {
+			if m.Skipped != "" {
+				skipped++
+				fmt.Fprintf(b, "    - %s (%s): skipped, %s\n", m.Name, typeName(m.Type), m.Skipped)
+				continue
+			}
+			reported++
+			fmt.Fprintf(b, "    - %s (%s): %v%s\n", m.Name, typeName(m.Type), m.Value, sampledNote(m.Type))
+		}
+
+		names := make([]string, 0, len(e.Dropped))
+		for name := range e.Dropped {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			skipped++
+			fmt.Fprintf(b, "    - %s: dropped, %v is not a valid New Relic value\n", name, e.Dropped[name])
+		}
+	}
+
+	fmt.Fprintf(b, "  %d entities, %d metrics reported, %d skipped\n", len(r.Entities), reported, skipped)
+}
+
+// sampledNote tells that RATE and DELTA metrics do not report the fetched value, but its change since the previous
+// collection.
+func sampledNote(sourceType sdkMetric.SourceType) string {
+	switch sourceType {
+	case sdkMetric.RATE:
+		return ", reported as its rate of change since the previous collection"
+	case sdkMetric.DELTA:
+		return ", reported as its change since the previous collection"
+	}
+	return ""
+}
+
+func typeName(sourceType sdkMetric.SourceType) string {
+	switch sourceType {
+	case sdkMetric.GAUGE:
+		return "GAUGE"
+	case sdkMetric.RATE:
+		return "RATE"
+	case sdkMetric.DELTA:
+		return "DELTA"
+	case sdkMetric.ATTRIBUTE:
+		return "ATTRIBUTE"
+	}
+	return fmt.Sprintf("unknown type %d", sourceType)
+}
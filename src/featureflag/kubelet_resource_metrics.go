@@ -0,0 +1,21 @@
+package featureflag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KubeletResourceMetrics checks that the kubelet version, like v1.18.3, is
+// at least 1.18, which is when the kubelet started to serve the CPU and
+// memory usage of the node, pods and containers in /metrics/resource.
+// https://github.com/kubernetes/kubernetes/pull/86282
+func KubeletResourceMetrics(kubeletVersion string) bool {
+	var major, minor int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(kubeletVersion, "v"), "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	if major > 1 {
+		return true
+	}
+	return major == 1 && minor >= 18
+}
@@ -0,0 +1,47 @@
+package featureflag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeletResourceMetrics(t *testing.T) {
+	testCases := []struct {
+		name     string
+		version  string
+		expected bool
+	}{
+		{
+			name:     "couldn't retrieve version",
+			version:  "",
+			expected: false,
+		},
+		{
+			name:     "major is greater",
+			version:  "v2.0.0",
+			expected: true,
+		},
+		{
+			name:     "major is the same and minor is supported",
+			version:  "v1.18.3",
+			expected: true,
+		},
+		{
+			name:     "version of a managed cluster",
+			version:  "v1.21.2-eks-0389ca3",
+			expected: true,
+		},
+		{
+			name:     "major is the same and minor is less than supported",
+			version:  "v1.17.9",
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, KubeletResourceMetrics(testCase.version))
+		})
+	}
+}
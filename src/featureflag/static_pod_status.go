@@ -3,9 +3,9 @@ Package featureflag holds the feature flags for the integration.
 Feature flags are conditionals that tell us if a feature should be
 enable or disable.
 
-To keep it simple, since we only have one feature flag right now, it just
-a single function. In case we add more feature flags we can refactor it
-into a more robust and flexible implementation.
+To keep it simple, every feature flag is just a single function. In case
+we add more feature flags we can refactor it into a more robust and
+flexible implementation.
 */
 package featureflag
 
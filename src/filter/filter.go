@@ -0,0 +1,379 @@
+// Package filter drops entities and metrics before they are populated, following include and exclude rules, so
+// the data that is not wanted is never sent.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/pattern"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+// Rule selects entities and, optionally, some of their metrics. An entity matches the rule when it meets all the
+// conditions that are set. Names and label values are globs, where '*' matches any sequence of characters and
+// '?' any single character.
+type Rule struct {
+	// Namespaces are matched against the namespace of the entity. Entities that do not belong to a namespace, like
+	// nodes, do not match any.
+	Namespaces []string
+	// NamespaceLabels are matched against the labels of the namespace of the entity, which are looked up in the
+	// API server.
+	NamespaceLabels map[string]string
+	// Groups are matched against the raw group of the entity: pod, container, volume, node, deployment...
+	Groups []string
+	// PodLabels are matched against the labels of the pod of the entity, which is the entity itself for pods.
+	// Entities that do not belong to a pod do not match them.
+	PodLabels map[string]string
+	// Metrics are matched against the names of the metrics. When set, the rule selects these metrics of the
+	// matching entities, instead of the entities themselves.
+	Metrics []string
+}
+
+// Filter decides which entities and metrics are populated. An entity is kept when it matches any of the include
+// rules, or there are none, and it does not match any exclude rule selecting whole entities. Its metrics are the
+// ones selected by the include rules it matches, if all of them select metrics, minus the ones selected by the
+// exclude rules it matches.
+type Filter struct {
+	include   []rule
+	exclude   []rule
+	apiServer apiserver.Client
+}
+
+type rule struct {
+	namespaces      pattern.List
+	namespaceLabels map[string]*pattern.Matcher
+	groups          pattern.List
+	podLabels       map[string]*pattern.Matcher
+	metrics         pattern.List
+}
+
+// New creates a Filter applying the given rules. The namespace labels are looked up with the given client.
+func New(include, exclude []Rule, apiServer apiserver.Client) *Filter {
+	f := &Filter{apiServer: apiServer}
+	for _, r := range include {
+		f.include = append(f.include, compile(r))
+	}
+	for _, r := range exclude {
+		f.exclude = append(f.exclude, compile(r))
+	}
+	return f
+}
+
+func compile(r Rule) rule {
+	return rule{
+		namespaces:      pattern.Globs(r.Namespaces),
+		namespaceLabels: labelGlobs(r.NamespaceLabels),
+		groups:          pattern.Globs(r.Groups),
+		podLabels:       labelGlobs(r.PodLabels),
+		metrics:         pattern.Globs(r.Metrics),
+	}
+}
+
+func labelGlobs(labels map[string]string) map[string]*pattern.Matcher {
+	compiled := make(map[string]*pattern.Matcher, len(labels))
+	for k, v := range labels {
+		compiled[k] = pattern.Glob(v)
+	}
+	return compiled
+}
+
+// entity gives the attributes of a raw entity the rules are matched against. The labels are only computed when
+// a rule needs them.
+type entity struct {
+	group  string
+	id     string
+	groups definition.RawGroups
+	filter *Filter
+	// namespaceLabels caches the labels of the namespaces looked up during a single application of the filter.
+	namespaceLabels map[string]map[string]string
+	errs            *[]error
+}
+
+// matches returns whether the entity meets all the conditions of the rule.
+func (e entity) matches(r rule) bool {
+	if len(r.groups) > 0 && !r.groups.MatchesAny(e.group) {
+		return false
+	}
+
+	namespace, hasNamespace := namespaceOf(e.groups[e.group][e.id])
+	if len(r.namespaces) > 0 && (!hasNamespace || !r.namespaces.MatchesAny(namespace)) {
+		return false
+	}
+	if len(r.namespaceLabels) > 0 {
+		if !hasNamespace || !labelsMatch(r.namespaceLabels, e.labelsOfNamespace(namespace)) {
+			return false
+		}
+	}
+
+	if len(r.podLabels) > 0 {
+		labels, ok := podLabels(e.group, e.id, e.groups)
+		if !ok || !labelsMatch(r.podLabels, labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e entity) labelsOfNamespace(namespace string) map[string]string {
+	if labels, ok := e.namespaceLabels[namespace]; ok {
+		return labels
+	}
+
+	var labels map[string]string
+	if e.filter.apiServer == nil {
+		*e.errs = append(*e.errs, fmt.Errorf("can not look up the labels of the namespace %s without an API server client", namespace))
+	} else if info, err := e.filter.apiServer.GetNamespaceInfo(namespace); err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("filtering by namespace labels: %v", err))
+	} else {
+		labels = info.Labels
+	}
+	e.namespaceLabels[namespace] = labels
+	return labels
+}
+
+// labelsMatch returns whether the given labels have all the expected ones. Labels coming from kube-state-metrics
+// have their names sanitized, so the sanitized name of the expected labels is also looked up.
+func labelsMatch(expected map[string]*pattern.Matcher, labels map[string]string) bool {
+	for name, value := range expected {
+		v, ok := labels[name]
+		if !ok {
+			v, ok = labels[sanitize(name)]
+		}
+		if !ok || !value.Matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+var invalidLabelChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// sanitize returns the given label name as exposed by kube-state-metrics.
+func sanitize(name string) string {
+	return invalidLabelChars.ReplaceAllString(name, "_")
+}
+
+// namespaceOf returns the namespace of a raw entity: its namespace value, set by the kubelet groupers, or the
+// namespace label of its Prometheus metrics.
+func namespaceOf(raw definition.RawMetrics) (string, bool) {
+	if namespace, ok := raw["namespace"].(string); ok {
+		return namespace, true
+	}
+	return prometheusLabel(raw, "namespace")
+}
+
+// prometheusLabel returns the given label from any of the Prometheus metrics of the raw entity.
+func prometheusLabel(raw definition.RawMetrics, label string) (string, bool) {
+	for _, value := range raw {
+		var metrics []prometheus.Metric
+		switch v := value.(type) {
+		case prometheus.Metric:
+			metrics = []prometheus.Metric{v}
+		case []prometheus.Metric:
+			metrics = v
+		}
+		for _, m := range metrics {
+			if l, ok := m.Labels[label]; ok {
+				return l, true
+			}
+		}
+	}
+	return "", false
+}
+
+// podLabels returns the labels of the pod of a raw entity: the ones of the entity itself, for pods, or the ones
+// of the pod with the same namespace and pod name otherwise.
+func podLabels(groupLabel, entityID string, groups definition.RawGroups) (map[string]string, bool) {
+	raw := groups[groupLabel][entityID]
+	if labels, ok := ownPodLabels(raw); ok || groupLabel == "pod" {
+		return labels, ok
+	}
+
+	namespace, ok := namespaceOf(raw)
+	if !ok {
+		return nil, false
+	}
+	podName, ok := raw["podName"].(string)
+	if !ok {
+		if podName, ok = prometheusLabel(raw, "pod"); !ok {
+			return nil, false
+		}
+	}
+	return ownPodLabels(groups["pod"][fmt.Sprintf("%s_%s", namespace, podName)])
+}
+
+// ownPodLabels returns the pod labels held by the raw entity: the labels value of the kubelet pods and
+// containers, or the labels of the kube_pod_labels metric of the kube-state-metrics pods.
+func ownPodLabels(raw definition.RawMetrics) (map[string]string, bool) {
+	if labels, ok := raw["labels"].(map[string]string); ok {
+		return labels, true
+	}
+	m, ok := raw["kube_pod_labels"].(prometheus.Metric)
+	if !ok {
+		return nil, false
+	}
+	labels := make(map[string]string)
+	for name, value := range m.Labels {
+		if strings.HasPrefix(name, "label_") {
+			labels[strings.TrimPrefix(name, "label_")] = value
+		}
+	}
+	return labels, true
+}
+
+// selection is what the filter keeps from an entity.
+type selection struct {
+	keep bool
+	// include, unless includeAll, and exclude are the globs of the metrics kept and dropped.
+	includeAll bool
+	include    pattern.List
+	exclude    pattern.List
+}
+
+// keepsMetric returns whether the metric with the given name is kept.
+func (s selection) keepsMetric(name string) bool {
+	return (s.includeAll || s.include.MatchesAny(name)) && !s.exclude.MatchesAny(name)
+}
+
+// allMetrics returns whether every metric is kept.
+func (s selection) allMetrics() bool {
+	return s.includeAll && len(s.exclude) == 0
+}
+
+func (f *Filter) selection(e entity) selection {
+	s := selection{keep: len(f.include) == 0, includeAll: len(f.include) == 0}
+	for _, r := range f.include {
+		if !e.matches(r) {
+			continue
+		}
+		s.keep = true
+		if len(r.metrics) == 0 {
+			s.includeAll = true
+		}
+		s.include = append(s.include, r.metrics...)
+	}
+	if !s.keep {
+		return s
+	}
+
+	for _, r := range f.exclude {
+		if !e.matches(r) {
+			continue
+		}
+		if len(r.metrics) == 0 {
+			return selection{}
+		}
+		s.exclude = append(s.exclude, r.metrics...)
+	}
+	return s
+}
+
+// Apply returns the raw groups to populate, without the dropped entities, and the specs wrapped so they do not
+// fetch the dropped metrics. The given raw groups are not modified, and the returned specs read them instead of
+// the ones they are called with, so the values looked up in other entities, like the labels a container inherits
+// from its pod, are still found when those entities are dropped. The errors are the ones looking up the namespace
+// labels.
+func (f *Filter) Apply(groups definition.RawGroups, specs definition.SpecGroups) (definition.RawGroups, definition.SpecGroups, []error) {
+	var errs []error
+	namespaceLabels := make(map[string]map[string]string)
+	dropped := false
+
+	kept := make(definition.RawGroups, len(groups))
+	// partial holds the selection of the kept entities that do not keep all their metrics.
+	partial := make(map[string]map[string]selection)
+	for groupLabel, entities := range groups {
+		kept[groupLabel] = make(map[string]definition.RawMetrics, len(entities))
+		for id, raw := range entities {
+			// Only the groups with specs are populated, the rest of them are only used to fetch values.
+			if _, ok := specs[groupLabel]; !ok {
+				kept[groupLabel][id] = raw
+				continue
+			}
+
+			e := entity{group: groupLabel, id: id, groups: groups, filter: f, namespaceLabels: namespaceLabels, errs: &errs}
+			s := f.selection(e)
+			if !s.keep {
+				dropped = true
+				continue
+			}
+			kept[groupLabel][id] = raw
+			if !s.allMetrics() {
+				if partial[groupLabel] == nil {
+					partial[groupLabel] = make(map[string]selection)
+				}
+				partial[groupLabel][id] = s
+			}
+		}
+	}
+
+	if !dropped && len(partial) == 0 {
+		return kept, specs, errs
+	}
+
+	filteredSpecs := make(definition.SpecGroups, len(specs))
+	for groupLabel, specGroup := range specs {
+		wrapped := readingFrom(groups, specGroup)
+		if selections, ok := partial[groupLabel]; ok {
+			for i, spec := range wrapped.Specs {
+				wrapped.Specs[i].ValueFunc = filterValues(spec.Name, spec.ValueFunc, selections)
+			}
+		}
+		filteredSpecs[groupLabel] = wrapped
+	}
+	return kept, filteredSpecs, errs
+}
+
+// readingFrom wraps the generators and the value functions of the spec group so they read the given raw groups,
+// instead of the ones they are called with.
+func readingFrom(groups definition.RawGroups, specGroup definition.SpecGroup) definition.SpecGroup {
+	wrapped := definition.SpecGroup{Specs: make([]definition.Spec, 0, len(specGroup.Specs))}
+	if generator := specGroup.IDGenerator; generator != nil {
+		wrapped.IDGenerator = func(groupLabel, rawEntityID string, _ definition.RawGroups) (string, error) {
+			return generator(groupLabel, rawEntityID, groups)
+		}
+	}
+	if generator := specGroup.TypeGenerator; generator != nil {
+		wrapped.TypeGenerator = func(groupLabel, rawEntityID string, _ definition.RawGroups, prefix string) (string, error) {
+			return generator(groupLabel, rawEntityID, groups, prefix)
+		}
+	}
+	for _, spec := range specGroup.Specs {
+		fetch := spec.ValueFunc
+		spec.ValueFunc = func(groupLabel, entityID string, _ definition.RawGroups) (definition.FetchedValue, error) {
+			return fetch(groupLabel, entityID, groups)
+		}
+		wrapped.Specs = append(wrapped.Specs, spec)
+	}
+	return wrapped
+}
+
+// filterValues wraps the FetchFunc of a spec so it only returns the metrics kept for each entity. Dropped metrics
+// are returned as an empty set of values, so they are neither populated nor reported as errors.
+func filterValues(name string, fetch definition.FetchFunc, selections map[string]selection) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		s, ok := selections[entityID]
+		if !ok {
+			return fetch(groupLabel, entityID, groups)
+		}
+
+		value, err := fetch(groupLabel, entityID, groups)
+		multiple, isMultiple := value.(definition.FetchedValues)
+		switch {
+		case err == nil && isMultiple:
+			kept := make(definition.FetchedValues, len(multiple))
+			for k, v := range multiple {
+				if s.keepsMetric(k) {
+					kept[k] = v
+				}
+			}
+			return kept, nil
+		case !s.keepsMetric(name):
+			return definition.FetchedValues{}, nil
+		}
+		return value, err
+	}
+}
@@ -0,0 +1,262 @@
+package filter
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+// kubeletGroups mimics the raw groups of the kubelet, where the namespace and the labels are raw values.
+func kubeletGroups() definition.RawGroups {
+	return definition.RawGroups{
+		"pod": {
+			"default_web-1":         {"namespace": "default", "podName": "web-1", "labels": map[string]string{"app": "web"}, "cpu": 1},
+			"kube-system_coredns-1": {"namespace": "kube-system", "podName": "coredns-1", "labels": map[string]string{"app": "coredns"}, "cpu": 2},
+			"ci-42_runner-1":        {"namespace": "ci-42", "podName": "runner-1", "labels": map[string]string{"app": "runner"}, "cpu": 3},
+		},
+		"volume": {
+			"default_web-1_data": {"namespace": "default", "podName": "web-1", "used": 10},
+		},
+		"node": {
+			"node-1": {"cpu": 4},
+		},
+		"network": {
+			"interfaces": {"default": "eth0"},
+		},
+	}
+}
+
+var specs = definition.SpecGroups{
+	"pod": {Specs: []definition.Spec{
+		{Name: "cpu", ValueFunc: definition.FromRaw("cpu"), Type: sdkMetric.GAUGE},
+		{Name: "label.*", ValueFunc: labels, Type: sdkMetric.ATTRIBUTE},
+	}},
+	"volume": {Specs: []definition.Spec{{Name: "used", ValueFunc: definition.FromRaw("used"), Type: sdkMetric.GAUGE}}},
+	"node":   {Specs: []definition.Spec{{Name: "cpu", ValueFunc: definition.FromRaw("cpu"), Type: sdkMetric.GAUGE}}},
+}
+
+func labels(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+	raw, ok := groups[groupLabel][entityID]["labels"].(map[string]string)
+	if !ok {
+		return nil, errors.New("no labels")
+	}
+	values := make(definition.FetchedValues)
+	for k, v := range raw {
+		values["label."+k] = v
+	}
+	return values, nil
+}
+
+func entityIDs(groups definition.RawGroups, groupLabel string) []string {
+	ids := make([]string, 0, len(groups[groupLabel]))
+	for id := range groups[groupLabel] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func fetch(t *testing.T, specs definition.SpecGroups, groups definition.RawGroups, groupLabel, entityID string) map[string]definition.FetchedValue {
+	values := make(map[string]definition.FetchedValue)
+	for _, spec := range specs[groupLabel].Specs {
+		v, err := spec.ValueFunc(groupLabel, entityID, groups)
+		require.NoError(t, err)
+		if multiple, ok := v.(definition.FetchedValues); ok {
+			for k, v := range multiple {
+				values[k] = v
+			}
+			continue
+		}
+		values[spec.Name] = v
+	}
+	return values
+}
+
+func TestApply_NoRules(t *testing.T) {
+	groups := kubeletGroups()
+	kept, keptSpecs, errs := New(nil, nil, nil).Apply(groups, specs)
+	assert.Empty(t, errs)
+	assert.Equal(t, groups, kept)
+	assert.Equal(t, fetch(t, specs, groups, "pod", "default_web-1"), fetch(t, keptSpecs, kept, "pod", "default_web-1"))
+}
+
+func TestApply_ExcludeNamespaces(t *testing.T) {
+	f := New(nil, []Rule{{Namespaces: []string{"kube-system", "ci-*"}}}, nil)
+	kept, _, errs := f.Apply(kubeletGroups(), specs)
+	assert.Empty(t, errs)
+	assert.Equal(t, []string{"default_web-1"}, entityIDs(kept, "pod"))
+	// Entities outside of a namespace, and groups without specs, are kept
+	assert.Equal(t, []string{"node-1"}, entityIDs(kept, "node"))
+	assert.Equal(t, []string{"interfaces"}, entityIDs(kept, "network"))
+}
+
+func TestApply_IncludeNamespaces(t *testing.T) {
+	f := New([]Rule{{Namespaces: []string{"default"}}, {Groups: []string{"node"}}}, nil, nil)
+	kept, _, _ := f.Apply(kubeletGroups(), specs)
+	assert.Equal(t, []string{"default_web-1"}, entityIDs(kept, "pod"))
+	assert.Equal(t, []string{"default_web-1_data"}, entityIDs(kept, "volume"))
+	assert.Equal(t, []string{"node-1"}, entityIDs(kept, "node"))
+}
+
+func TestApply_GroupsAndPodLabels(t *testing.T) {
+	// Volumes inherit the labels of their pod
+	f := New(nil, []Rule{{Groups: []string{"volume"}, PodLabels: map[string]string{"app": "w*"}}}, nil)
+	kept, _, _ := f.Apply(kubeletGroups(), specs)
+	assert.Empty(t, entityIDs(kept, "volume"))
+	assert.Len(t, entityIDs(kept, "pod"), 3)
+
+	f = New(nil, []Rule{{PodLabels: map[string]string{"app": "coredns"}}}, nil)
+	kept, _, _ = f.Apply(kubeletGroups(), specs)
+	assert.Equal(t, []string{"ci-42_runner-1", "default_web-1"}, entityIDs(kept, "pod"))
+	assert.Equal(t, []string{"node-1"}, entityIDs(kept, "node"))
+}
+
+func TestApply_NamespaceLabels(t *testing.T) {
+	apiServer := apiserver.TestAPIServer{Namespaces: map[string]*apiserver.NamespaceInfo{
+		"default":     {Name: "default"},
+		"kube-system": {Name: "kube-system", Labels: map[string]string{"team": "platform"}},
+	}}
+	f := New(nil, []Rule{{NamespaceLabels: map[string]string{"team": "platform"}}}, apiServer)
+	kept, _, errs := f.Apply(kubeletGroups(), specs)
+	assert.Equal(t, []string{"ci-42_runner-1", "default_web-1"}, entityIDs(kept, "pod"))
+	// The namespace that can not be looked up is reported once, and its entities are not excluded
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "ci-42")
+}
+
+func TestApply_Metrics(t *testing.T) {
+	f := New(
+		[]Rule{{Groups: []string{"pod"}, Metrics: []string{"cpu", "label.*"}}, {Groups: []string{"node", "volume"}}},
+		[]Rule{{Namespaces: []string{"default"}, Metrics: []string{"label.*"}}},
+		nil,
+	)
+	groups := kubeletGroups()
+	kept, keptSpecs, _ := f.Apply(groups, specs)
+	assert.Len(t, entityIDs(kept, "pod"), 3)
+
+	assert.Equal(t, map[string]definition.FetchedValue{"cpu": 1}, fetch(t, keptSpecs, kept, "pod", "default_web-1"))
+	assert.Equal(t, map[string]definition.FetchedValue{"cpu": 2, "label.app": "coredns"}, fetch(t, keptSpecs, kept, "pod", "kube-system_coredns-1"))
+	assert.Equal(t, map[string]definition.FetchedValue{"cpu": 4}, fetch(t, keptSpecs, kept, "node", "node-1"))
+
+	// The specs given to Apply are not modified
+	assert.Equal(t, map[string]definition.FetchedValue{"cpu": 1, "label.app": "web"}, fetch(t, specs, groups, "pod", "default_web-1"))
+}
+
+func TestApply_KubeStateMetrics(t *testing.T) {
+	podLabels := prometheus.Metric{
+		Labels: prometheus.Labels{"namespace": "default", "pod": "web-1", "label_app_kubernetes_io_name": "web"},
+		Value:  prometheus.GaugeValue(1),
+	}
+	groups := definition.RawGroups{
+		"pod": {
+			"default_web-1": {"kube_pod_labels": podLabels},
+		},
+		"container": {
+			"default_web-1_nginx": {"kube_pod_container_info": prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": "default", "pod": "web-1", "container": "nginx"},
+				Value:  prometheus.GaugeValue(1),
+			}},
+		},
+		"namespace": {
+			"default": {"kube_namespace_created": []prometheus.Metric{{Labels: prometheus.Labels{"namespace": "default"}}}},
+		},
+	}
+	ksmSpecs := definition.SpecGroups{"pod": {}, "container": {}, "namespace": {}}
+
+	// The labels of kube-state-metrics have sanitized names
+	f := New(nil, []Rule{{PodLabels: map[string]string{"app.kubernetes.io/name": "web"}}}, nil)
+	kept, _, _ := f.Apply(groups, ksmSpecs)
+	assert.Empty(t, entityIDs(kept, "pod"))
+	assert.Empty(t, entityIDs(kept, "container"))
+	assert.Equal(t, []string{"default"}, entityIDs(kept, "namespace"))
+
+	f = New(nil, []Rule{{Namespaces: []string{"default"}}}, nil)
+	kept, _, _ = f.Apply(groups, ksmSpecs)
+	assert.Empty(t, entityIDs(kept, "pod"))
+	assert.Empty(t, entityIDs(kept, "container"))
+	assert.Empty(t, entityIDs(kept, "namespace"))
+}
+
+func TestApply_ExcludedPodsKeepTheirContainers(t *testing.T) {
+	groups := definition.RawGroups{
+		"pod": {
+			"default_web-1": {"kube_pod_labels": prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": "default", "pod": "web-1", "label_app": "web"},
+				Value:  prometheus.GaugeValue(1),
+			}},
+		},
+		"container": {
+			"default_web-1_nginx": {"kube_pod_container_info": prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": "default", "pod": "web-1", "container": "nginx"},
+				Value:  prometheus.GaugeValue(1),
+			}},
+		},
+	}
+	ksmSpecs := definition.SpecGroups{
+		"pod": {Specs: []definition.Spec{
+			{Name: "label.*", ValueFunc: prometheus.InheritAllLabelsFrom("pod", "kube_pod_labels"), Type: sdkMetric.ATTRIBUTE},
+		}},
+		"container": {
+			IDGenerator: func(groupLabel, rawEntityID string, g definition.RawGroups) (string, error) {
+				if _, ok := g["pod"]["default_web-1"]; !ok {
+					return "", errors.New("pod not found")
+				}
+				return rawEntityID, nil
+			},
+			Specs: []definition.Spec{
+				{Name: "label.*", ValueFunc: prometheus.InheritAllLabelsFrom("pod", "kube_pod_labels"), Type: sdkMetric.ATTRIBUTE},
+			},
+		},
+	}
+
+	f := New(nil, []Rule{{Groups: []string{"pod"}}}, nil)
+	kept, keptSpecs, errs := f.Apply(groups, ksmSpecs)
+	assert.Empty(t, errs)
+	assert.Empty(t, entityIDs(kept, "pod"))
+	assert.Equal(t, []string{"default_web-1_nginx"}, entityIDs(kept, "container"))
+
+	// The containers still inherit the labels of their dropped pod, which is kept in the given raw groups
+	assert.Equal(t, "web", fetch(t, keptSpecs, kept, "container", "default_web-1_nginx")["label.app"])
+	id, err := keptSpecs["container"].IDGenerator("container", "default_web-1_nginx", kept)
+	require.NoError(t, err)
+	assert.Equal(t, "default_web-1_nginx", id)
+	assert.Len(t, groups["pod"], 1)
+}
+
+// recordingPopulator keeps the raw groups it is asked to populate.
+type recordingPopulator struct {
+	groups definition.RawGroups
+}
+
+func (r *recordingPopulator) Populate(
+	groups definition.RawGroups,
+	_ definition.SpecGroups,
+	_ *sdk.IntegrationProtocol2,
+	_ string,
+	_ *version.Info,
+) data.PopulateResult {
+	r.groups = groups
+	return data.PopulateResult{Populated: true}
+}
+
+func TestPopulator(t *testing.T) {
+	inner := &recordingPopulator{}
+	p := NewPopulator(inner, New(nil, []Rule{{NamespaceLabels: map[string]string{"team": "ci"}}}, apiserver.TestAPIServer{}))
+
+	result := p.Populate(kubeletGroups(), specs, nil, "", nil)
+	assert.True(t, result.Populated)
+	// The namespaces can not be looked up, so nothing is excluded and the errors are reported
+	assert.Len(t, entityIDs(inner.groups, "pod"), 3)
+	assert.Len(t, result.Errors, 3)
+}
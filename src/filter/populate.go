@@ -0,0 +1,35 @@
+package filter
+
+import (
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+)
+
+type populator struct {
+	populator data.Populator
+	filter    *Filter
+}
+
+// NewPopulator creates a data.Populator that applies the given Filter to the raw groups and the specs before
+// populating them with the given populator.
+func NewPopulator(p data.Populator, f *Filter) data.Populator {
+	return &populator{populator: p, filter: f}
+}
+
+// Populate populates the integration with the entities and metrics kept by the filter. Errors looking up the
+// labels of the namespaces are added to the result.
+func (p *populator) Populate(
+	groups definition.RawGroups,
+	specGroups definition.SpecGroups,
+	i *sdk.IntegrationProtocol2,
+	clusterName string,
+	k8sVersion *version.Info,
+) data.PopulateResult {
+	groups, specGroups, errs := p.filter.Apply(groups, specGroups)
+	result := p.populator.Populate(groups, specGroups, i, clusterName, k8sVersion)
+	result.Errors = append(result.Errors, errs...)
+	return result
+}
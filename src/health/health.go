@@ -0,0 +1,136 @@
+// Package health tracks the state of the integration running as a daemon, so Kubernetes can probe whether it is
+// ready to report metrics and whether it is still collecting them.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+)
+
+// Checker keeps track of the discovery and of the collections of the integration. Its handlers report it as ready
+// once the kubelet has been discovered and a collection has populated data, and as alive as long as the last
+// successful collection, or the start of the checker if there has been none yet, is not older than the maximum age.
+type Checker struct {
+	lock        sync.RWMutex
+	maxAge      time.Duration
+	started     time.Time
+	discovered  bool
+	lastSuccess time.Time
+	jobs        map[string]*jobStatus
+	now         func() time.Time
+}
+
+type jobStatus struct {
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// Status is the body of the responses of the handlers.
+type Status struct {
+	Status string `json:"status"`
+	// Reason tells why the integration is not healthy or not ready.
+	Reason      string                `json:"reason,omitempty"`
+	LastSuccess *time.Time            `json:"lastSuccessfulCollection,omitempty"`
+	Jobs        map[string]*jobStatus `json:"jobs,omitempty"`
+}
+
+// NewChecker creates a Checker considering the integration stuck when it has not completed a successful collection
+// for longer than maxAge.
+func NewChecker(maxAge time.Duration) *Checker {
+	return &Checker{
+		maxAge:  maxAge,
+		started: time.Now(),
+		jobs:    make(map[string]*jobStatus),
+		now:     time.Now,
+	}
+}
+
+// Discovered records that the kubelet has been discovered.
+func (c *Checker) Discovered() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.discovered = true
+}
+
+// Collected records the results of a collection. The collection is successful when any of the jobs has
+// populated data.
+func (c *Checker) Collected(results []scrape.JobResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	for _, r := range results {
+		s, ok := c.jobs[r.Name]
+		if !ok {
+			s = &jobStatus{}
+			c.jobs[r.Name] = s
+		}
+
+		if r.Populated {
+			c.lastSuccess = now
+			s.LastSuccess = &now
+		}
+		s.LastError = ""
+		if len(r.Errors) > 0 {
+			s.LastError = r.Errors[len(r.Errors)-1].Error()
+		}
+	}
+}
+
+// LivenessHandler returns an http.Handler responding with a 503 status when no collection has succeeded for
+// longer than the maximum age.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+
+		since := c.lastSuccess
+		if since.IsZero() {
+			since = c.started
+		}
+		reason := ""
+		if age := c.now().Sub(since); age > c.maxAge {
+			reason = fmt.Sprintf("no successful collection for %s, more than %s", age.Round(time.Second), c.maxAge)
+		}
+		c.respond(w, reason)
+	})
+}
+
+// ReadinessHandler returns an http.Handler responding with a 503 status until the kubelet has been discovered and
+// a collection has succeeded.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+
+		reason := ""
+		switch {
+		case !c.discovered:
+			reason = "the kubelet has not been discovered yet"
+		case c.lastSuccess.IsZero():
+			reason = "no collection has succeeded yet"
+		}
+		c.respond(w, reason)
+	})
+}
+
+// respond writes the status of the checker. It must be called holding the lock.
+func (c *Checker) respond(w http.ResponseWriter, reason string) {
+	status := Status{Status: "ok", Reason: reason, Jobs: c.jobs}
+	if !c.lastSuccess.IsZero() {
+		lastSuccess := c.lastSuccess
+		status.LastSuccess = &lastSuccess
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if reason != "" {
+		status.Status = "failing"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status) // nolint: errcheck
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler) (int, Status) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	return rec.Code, status
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	c := NewChecker(time.Minute)
+
+	code, status := probe(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "the kubelet has not been discovered yet", status.Reason)
+
+	c.Discovered()
+	code, status = probe(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "no collection has succeeded yet", status.Reason)
+
+	// A collection where no job populated data is not successful
+	c.Collected([]scrape.JobResult{{Name: "kubelet", PopulateResult: data.PopulateResult{Errors: []error{errors.New("timeout")}}}})
+	code, _ = probe(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	c.Collected([]scrape.JobResult{{Name: "kubelet", PopulateResult: data.PopulateResult{Populated: true}}})
+	code, status = probe(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", status.Status)
+	assert.Empty(t, status.Reason)
+}
+
+func TestChecker_Liveness(t *testing.T) {
+	now := time.Now()
+	c := NewChecker(time.Minute)
+	c.started = now
+	c.now = func() time.Time { return now }
+
+	// The integration is alive while starting
+	code, _ := probe(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code)
+
+	c.Collected([]scrape.JobResult{
+		{Name: "kubelet", PopulateResult: data.PopulateResult{Populated: true}},
+		{Name: "kube-state-metrics", PopulateResult: data.PopulateResult{Errors: []error{errors.New("connection refused")}}},
+	})
+
+	now = now.Add(30 * time.Second)
+	code, status := probe(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	require.NotNil(t, status.LastSuccess)
+	require.Contains(t, status.Jobs, "kube-state-metrics")
+	assert.Equal(t, "connection refused", status.Jobs["kube-state-metrics"].LastError)
+	assert.Nil(t, status.Jobs["kube-state-metrics"].LastSuccess)
+	assert.NotNil(t, status.Jobs["kubelet"].LastSuccess)
+
+	// Failed collections do not keep the integration alive
+	c.Collected([]scrape.JobResult{{Name: "kubelet", PopulateResult: data.PopulateResult{Errors: []error{errors.New("timeout")}}}})
+	now = now.Add(time.Minute)
+	code, status = probe(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "failing", status.Status)
+	assert.Equal(t, "no successful collection for 1m30s, more than 1m0s", status.Reason)
+	assert.Equal(t, "timeout", status.Jobs["kubelet"].LastError)
+}
+
+func TestChecker_LivenessWithoutCollections(t *testing.T) {
+	now := time.Now()
+	c := NewChecker(time.Minute)
+	c.started = now
+	c.now = func() time.Time { return now.Add(2 * time.Minute) }
+
+	code, status := probe(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "no successful collection for 2m0s, more than 1m0s", status.Reason)
+}
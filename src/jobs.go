@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/explain"
+	"github.com/newrelic/nri-kubernetes/src/ksm"
+	clientKsm "github.com/newrelic/nri-kubernetes/src/ksm/client"
+	"github.com/newrelic/nri-kubernetes/src/kubelet"
+	clientKubelet "github.com/newrelic/nri-kubernetes/src/kubelet/client"
+	metric2 "github.com/newrelic/nri-kubernetes/src/kubelet/metric"
+	"github.com/newrelic/nri-kubernetes/src/leader"
+	"github.com/newrelic/nri-kubernetes/src/network"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+	"github.com/newrelic/nri-kubernetes/src/storage"
+)
+
+// jobSetup discovers the data sources of the integration and creates the scrape jobs fetching from them. The
+// discovered endpoints are cached for the discovery TTL, so the daemon runs the setup again once the TTL has
+// expired, to find the data sources that moved in the meantime.
+type jobSetup struct {
+	logger                 *logrus.Logger
+	rec                    *recording
+	nodeName               string
+	cacheStorage           storage.Storage
+	ttl                    time.Duration
+	timeout                time.Duration
+	k8s                    client.Kubernetes
+	elector                *leader.Elector
+	apiServerClient        apiserver.Client
+	enableStaticPodsStatus bool
+	definitions            *metricDefinitions
+	chain                  explain.Chain
+	outputs                *outputs
+	// discoveryCaches are indexed by the name of the job that uses the discovered clients. The discoverers are
+	// created by the first setup and reused by the next ones, so their cache stats are kept.
+	discoveryCaches map[string]client.CacheStatsReporter
+
+	kubeletDiscoverer  client.Discoverer
+	ksmDiscoverer      client.Discoverer
+	multiKSMDiscoverer client.MultiDiscoverer
+}
+
+// scrapeJobs are the jobs created by a setup.
+type scrapeJobs struct {
+	jobs []*scrape.Job
+	// pods caches the pods of the kubelet for a single collection, so it must be reset before each of them.
+	pods *metric2.PodsFetcher
+	// discoveredAt is the time the data sources were discovered.
+	discoveredAt time.Time
+}
+
+// refresh runs the setup again when the data sources of the given jobs were discovered longer than the discovery
+// TTL ago, and returns the new jobs. The given jobs are returned when they have not expired yet or the discovery
+// fails, so the collections go on with the data sources discovered before.
+func (s *jobSetup) refresh(current *scrapeJobs) *scrapeJobs {
+	if time.Since(current.discoveredAt) < s.ttl {
+		return current
+	}
+
+	s.logger.Debug("Discovering the data sources again")
+	rediscovered, err := s.run()
+	if err != nil {
+		s.logger.WithError(err).Warn("discovering the data sources, scraping the ones discovered before")
+		return current
+	}
+	return rediscovered
+}
+
+// run discovers the data sources and creates the scrape jobs, with their populators wrapped by the chain and the
+// outputs.
+func (s *jobSetup) run() (*scrapeJobs, error) {
+	discoveredAt := time.Now()
+	var jobs []*scrape.Job
+
+	// When replaying, the data sources are not discovered: the recorded responses are used instead.
+	var kubeletClient client.HTTPClient
+	var defaultNetworkInterface string
+	if s.rec.replaying() {
+		defaultNetworkInterface = s.rec.replayer.DefaultInterface()
+	} else {
+		if s.kubeletDiscoverer == nil {
+			innerKubeletDiscoverer, err := clientKubelet.NewDiscoverer(s.nodeName, s.logger)
+			if err != nil {
+				return nil, fmt.Errorf("error during Kubelet auto discovering process. %s", err)
+			}
+			kubeletDiscoverer := clientKubelet.NewDiscoveryCacher(innerKubeletDiscoverer, s.cacheStorage, s.ttl, s.logger)
+			s.discoveryCaches["kubelet"] = kubeletDiscoverer
+			s.kubeletDiscoverer = kubeletDiscoverer
+		}
+
+		var err error
+		defaultNetworkInterface, err = network.CachedDefaultInterface(
+			s.logger, args.NetworkRouteFile, s.cacheStorage, s.ttl)
+		if err != nil {
+			s.logger.Warn(err)
+		}
+
+		kubeletClient, err = s.kubeletDiscoverer.Discover(s.timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.rec.recorder != nil {
+		s.rec.recorder.Node(s.nodeName, defaultNetworkInterface)
+	}
+	kubeletClient = s.rec.httpClient(kubeletSource, kubeletClient)
+	kubeletNodeIP := kubeletClient.NodeIP()
+	s.logger.Debugf("Kubelet node IP = %s", kubeletNodeIP)
+
+	if !args.DisableKubeStateMetrics {
+		ksmJobs, err := s.ksmJobs(kubeletNodeIP)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, ksmJobs...)
+	}
+
+	cachedPodsFetcher := metric2.NewPodsFetcher(s.logger, kubeletClient, s.enableStaticPodsStatus)
+	podsFetcher := cachedPodsFetcher.FetchFuncWithCache()
+	cpJobs, err := controlPlaneJobs(
+		s.logger,
+		s.apiServerClient,
+		s.nodeName,
+		s.timeout,
+		kubeletNodeIP,
+		podsFetcher,
+		s.k8s,
+		args.EtcdTLSSecretName,
+		args.EtcdTLSSecretNamespace,
+		args.APIServerSecurePort,
+		args.SchedulerEndpointURL,
+		args.EtcdEndpointURL,
+		args.ControllerManagerEndpointURL,
+		args.APIServerEndpointURL,
+		s.rec,
+		s.definitions.controlPlaneSpecs...,
+	)
+
+	if err != nil {
+		s.logger.Errorf("couldn't configure control plane components jobs: %v", err)
+	} else {
+		jobs = append(jobs, cpJobs...)
+	}
+
+	var cadvisorClient client.HTTPClient
+	source := resolveCadvisorSource(args.CadvisorSource, args.CadvisorURL, args.CadvisorPodLabel, args.CadvisorPort)
+	if source != "kubelet" {
+		if s.rec.replaying() {
+			cadvisorClient = s.rec.httpClient(cadvisorSource, nil)
+		} else if c, err := getCadvisorClient(kubeletNodeIP, s.k8s, s.cacheStorage, s.ttl, s.timeout, s.logger); err != nil {
+			s.logger.WithError(err).Warnf("discovering the standalone cadvisor, fetching the cadvisor metrics from %s", metric2.KubeletCAdvisorMetricsPath)
+		} else {
+			cadvisorClient = s.rec.httpClient(cadvisorSource, c)
+		}
+	}
+
+	// Kubelet is always scraped, on each node
+	kubeletGrouper := kubelet.NewGrouper(
+		kubeletClient,
+		s.logger,
+		s.apiServerClient,
+		defaultNetworkInterface,
+		args.EnableVolumeMetrics,
+		kubeletUsageFetcher(args.KubeletUsageSource, kubeletClient, s.apiServerClient, s.nodeName, s.cacheStorage, s.logger),
+		podsFetcher,
+		cadvisorFetcher(source, kubeletClient, cadvisorClient, s.definitions.cadvisorQueries, s.logger),
+		metric2.KubeletMetricsFetchFunc(kubeletClient, s.nodeName, s.definitions.kubeletQueries),
+	)
+	kubeletJob := scrape.NewScrapeJob("kubelet", kubeletGrouper, s.definitions.kubeletSpecs)
+	kubeletJob.Client = kubeletClient
+	jobs = append(jobs, kubeletJob)
+
+	for _, job := range jobs {
+		job.Populator = s.chain(job.Populator)
+	}
+	s.outputs.wrap(jobs)
+
+	return &scrapeJobs{jobs: jobs, pods: cachedPodsFetcher, discoveredAt: discoveredAt}, nil
+}
+
+// ksmJobs discovers the kube-state-metrics instances and creates the jobs scraping them. When replaying, the
+// discovery runs against the recorded lookups, and all the recorded instances are replayed if it fails.
+func (s *jobSetup) ksmJobs(kubeletNodeIP string) ([]*scrape.Job, error) {
+	ksmClients, ksmNodeIP, err := s.discoverKSM(kubeletNodeIP)
+	if err != nil {
+		if !s.rec.replaying() {
+			return nil, err
+		}
+		s.logger.WithError(err).Warn("discovering kube-state-metrics with the recorded lookups, replaying all the recorded instances")
+		ksmClients = nil
+		for _, source := range s.rec.ksmSources() {
+			ksmClients = append(ksmClients, s.rec.httpClient(source, nil))
+		}
+		ksmNodeIP = kubeletNodeIP
+	}
+	s.logger.Debugf("KSM Node = %s", ksmNodeIP)
+
+	var jobs []*scrape.Job
+	for _, ksmClient := range ksmClients {
+		ksmGrouper := ksm.NewGrouper(ksmClient, s.definitions.ksmQueries, s.logger, s.k8s)
+		job := scrape.NewScrapeJob("kube-state-metrics", ksmGrouper, s.definitions.ksmSpecs)
+		job.Client = ksmClient
+		job.ClusterScoped = !args.DistributedKubeStateMetrics
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// discoverKSM returns the clients of the kube-state-metrics instances to scrape and the IP of the node of the
+// instance, which are not cached on disk when replaying so the recorded lookups are always used.
+func (s *jobSetup) discoverKSM(kubeletNodeIP string) ([]client.HTTPClient, string, error) {
+	if args.DistributedKubeStateMetrics {
+		if s.multiKSMDiscoverer == nil {
+			ksmDiscoverer, err := getMultiKSMDiscoverer(kubeletNodeIP, s.logger, s.k8s)
+			if err != nil {
+				return nil, "", err
+			}
+			s.multiKSMDiscoverer = ksmDiscoverer
+			if !s.rec.replaying() {
+				s.multiKSMDiscoverer = clientKsm.NewDistributedDiscoveryCacher(ksmDiscoverer, s.cacheStorage, s.ttl, s.logger)
+			}
+			if r, ok := s.multiKSMDiscoverer.(client.CacheStatsReporter); ok {
+				s.discoveryCaches["kube-state-metrics"] = r
+			}
+		}
+		ksmClients, err := s.multiKSMDiscoverer.Discover(s.timeout)
+		s.logger.Debugf("found %d KSM clients:", len(ksmClients))
+		for _, c := range ksmClients {
+			s.logger.Debugf("- node IP: %s", c.NodeIP())
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		for i, c := range ksmClients {
+			ksmClients[i] = s.rec.httpClient(path.Join(ksmSource, c.NodeIP()), c)
+		}
+		return ksmClients, kubeletNodeIP, nil
+	}
+
+	if s.ksmDiscoverer == nil {
+		innerKSMDiscoverer, err := getKSMDiscoverer(s.logger, s.k8s)
+		if err != nil {
+			return nil, "", err
+		}
+		s.ksmDiscoverer = innerKSMDiscoverer
+		if !s.rec.replaying() {
+			s.ksmDiscoverer = clientKsm.NewDiscoveryCacher(innerKSMDiscoverer, s.cacheStorage, s.ttl, s.logger)
+		}
+		if r, ok := s.ksmDiscoverer.(client.CacheStatsReporter); ok {
+			s.discoveryCaches["kube-state-metrics"] = r
+		}
+	}
+	ksmClient, err := s.ksmDiscoverer.Discover(s.timeout)
+	if err != nil {
+		return nil, "", err
+	}
+	ksmNodeIP := ksmClient.NodeIP()
+	// Without leader election, we only scrape KSM when we are on the same Node as KSM. With it, every
+	// instance has the job but only the leader runs it.
+	if s.elector != nil || kubeletNodeIP == ksmNodeIP {
+		return []client.HTTPClient{s.rec.httpClient(ksmSource, ksmClient)}, ksmNodeIP, nil
+	}
+	return nil, ksmNodeIP, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/config"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/record"
+	"github.com/newrelic/nri-kubernetes/src/storage"
+)
+
+// replayingSetup returns a setup replaying a recording of the kubelet of a node, stored in the given directory,
+// along with whatever the given functions record.
+func replayingSetup(t *testing.T, dir string, recordings ...func(*record.Recorder)) *jobSetup {
+	recorder, err := record.NewRecorder(dir, logger)
+	require.NoError(t, err)
+	recorder.Node("node-1", "eth0")
+	kubelet := new(client.MockDiscoveredHTTPClient)
+	kubelet.On("NodeIP").Return("1.2.3.4")
+	recorder.HTTPClient(kubeletSource, kubelet)
+	for _, r := range recordings {
+		r(recorder)
+	}
+
+	args = argumentList{Replay: dir, DisableKubeStateMetrics: true, CadvisorSource: "kubelet"}
+	rec, err := newRecording(logger)
+	require.NoError(t, err)
+	definitions, err := newMetricDefinitions(config.CustomMetrics{}, config.NetworkInterfaces{})
+	require.NoError(t, err)
+
+	return &jobSetup{
+		logger:          logger,
+		rec:             rec,
+		nodeName:        "node-1",
+		cacheStorage:    storage.NewJSONDiskStorage(dir),
+		ttl:             time.Hour,
+		timeout:         time.Second,
+		k8s:             rec.kubernetes(nil),
+		apiServerClient: apiserver.TestAPIServer{},
+		definitions:     definitions,
+		chain:           func(p data.Populator) data.Populator { return p },
+		outputs:         &outputs{},
+		discoveryCaches: map[string]client.CacheStatsReporter{},
+	}
+}
+
+func TestJobSetup_Refresh(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+	dir, err := ioutil.TempDir("", "test_job_setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setup := replayingSetup(t, dir)
+	current, err := setup.run()
+	require.NoError(t, err)
+	require.Len(t, current.jobs, 1)
+	assert.Equal(t, "kubelet", current.jobs[0].Name)
+
+	// The jobs are kept until the discovery TTL expires
+	assert.True(t, current == setup.refresh(current))
+
+	current.discoveredAt = time.Now().Add(-setup.ttl)
+	refreshed := setup.refresh(current)
+	assert.False(t, current == refreshed)
+	require.Len(t, refreshed.jobs, 1)
+	assert.False(t, current.jobs[0] == refreshed.jobs[0])
+	assert.True(t, refreshed.discoveredAt.After(current.discoveredAt))
+}
+
+// recordKSM records a kube-state-metrics instance on another node, along with its lookup by the given pod label.
+func recordKSM(t *testing.T, label string) func(*record.Recorder) {
+	return func(recorder *record.Recorder) {
+		ksmClient := new(client.MockDiscoveredHTTPClient)
+		ksmClient.On("NodeIP").Return("5.6.7.8")
+		recorder.HTTPClient(ksmSource, ksmClient)
+
+		pods := &v1.PodList{Items: []v1.Pod{{Status: v1.PodStatus{HostIP: "5.6.7.8", PodIP: "10.0.0.1"}}}}
+		k8s := new(client.MockedKubernetes)
+		k8s.On("FindPodsByLabel", label).Return(pods, nil)
+		_, err := recorder.Kubernetes(k8s).FindPodsByLabel(label, "true")
+		require.NoError(t, err)
+	}
+}
+
+func TestJobSetup_ReplaysTheKSMDiscovery(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+	dir, err := ioutil.TempDir("", "test_job_setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setup := replayingSetup(t, dir, recordKSM(t, "ksm"))
+	args.DisableKubeStateMetrics = false
+	args.KubeStateMetricsPodLabel = "ksm"
+	args.KubeStateMetricsPort = 8080
+	args.KubeStateMetricsScheme = "http"
+
+	// The recorded lookup finds kube-state-metrics on another node, so only the kubelet is scraped
+	current, err := setup.run()
+	require.NoError(t, err)
+	require.Len(t, current.jobs, 1)
+	assert.Equal(t, "kubelet", current.jobs[0].Name)
+}
+
+func TestJobSetup_ReplaysTheRecordedKSMWhenTheDiscoveryFails(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+	dir, err := ioutil.TempDir("", "test_job_setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setup := replayingSetup(t, dir, recordKSM(t, "ksm"))
+	args.DisableKubeStateMetrics = false
+	args.KubeStateMetricsPodLabel = "other-ksm"
+
+	current, err := setup.run()
+	require.NoError(t, err)
+	require.Len(t, current.jobs, 2)
+	assert.Equal(t, "kube-state-metrics", current.jobs[0].Name)
+	assert.Equal(t, "5.6.7.8", current.jobs[0].Client.NodeIP())
+	assert.Equal(t, "kubelet", current.jobs[1].Name)
+}
+
+func TestJobSetup_RefreshKeepsTheJobsWhenTheDiscoveryFails(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+	args = argumentList{}
+
+	discoverer := new(client.MockDiscoverer)
+	discoverer.On("Discover", mock.Anything).Return(new(client.MockDiscoveredHTTPClient), errors.New("kubelet not found"))
+	setup := &jobSetup{
+		logger:            logger,
+		rec:               &recording{},
+		nodeName:          "node-1",
+		cacheStorage:      storage.NewJSONDiskStorage(os.TempDir()),
+		ttl:               time.Minute,
+		kubeletDiscoverer: discoverer,
+	}
+
+	current := &scrapeJobs{discoveredAt: time.Now().Add(-time.Hour)}
+	assert.True(t, current == setup.refresh(current))
+	discoverer.AssertCalled(t, "Discover", mock.Anything)
+}
@@ -106,6 +106,11 @@ func (c *ksm) NodeIP() string {
 	return c.nodeIP
 }
 
+// Describe returns the discovered kube-state-metrics endpoint. No authentication is used to call it.
+func (c *ksm) Describe() client.Description {
+	return client.Description{Endpoint: c.endpoint.String(), Authentication: "None"}
+}
+
 func (c *ksm) Do(method, urlPath string) (*http.Response, error) {
 	e := c.endpoint
 	e.Path = path.Join(c.endpoint.Path, urlPath)
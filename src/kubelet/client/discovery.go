@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
 	"time"
 
@@ -61,6 +60,20 @@ func (c *kubelet) NodeIP() string {
 	return c.nodeIP
 }
 
+// Describe returns the discovered kubelet endpoint and how the client authenticates against it.
+func (c *kubelet) Describe() client.Description {
+	d := client.Description{Endpoint: c.endpoint.String()}
+	switch c.httpType {
+	case httpBasic:
+		d.Authentication = "None (http)"
+	case httpInsecure:
+		d.Authentication = "Service account (Bearer token), without verifying the kubelet certificate"
+	case httpSecure:
+		d.Authentication = "Service account (Bearer token), proxied through the API server"
+	}
+	return d
+}
+
 // Do method calls discovered kubelet endpoint with specified method and path, i.e. "/stats/summary
 func (c *kubelet) Do(method, urlPath string) (*http.Response, error) {
 	e := c.endpoint
@@ -69,17 +82,7 @@ func (c *kubelet) Do(method, urlPath string) (*http.Response, error) {
 	var r *http.Request
 	var err error
 
-	// TODO Create a new discoverer and client for cadvisor
 	if urlPath == metric.KubeletCAdvisorMetricsPath {
-		if port := os.Getenv("CADVISOR_PORT"); port != "" {
-			// We force to call the standalone cadvisor because k8s < 1.7.6 do not have /metrics/cadvisor kubelet endpoint.
-			e.Scheme = "http"
-			e.Host = fmt.Sprintf("%s:%s", c.nodeIP, port)
-			e.Path = metric.StandaloneCAdvisorMetricsPath
-
-			c.logger.Debugf("Using standalone cadvisor on port %s", port)
-		}
-
 		r, err = prometheus.NewRequest(method, e.String())
 	} else {
 		r, err = http.NewRequest(method, e.String(), nil)
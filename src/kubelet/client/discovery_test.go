@@ -197,6 +197,11 @@ func TestDiscoverHTTPS_ApiConnection(t *testing.T) {
 	assert.Equal(t, "1.2.3.4", kclient.NodeIP())
 	assert.Equal(t, fakeDiscoveredAPIHost, kclient.(*kubelet).endpoint.Host)
 	assert.Equal(t, "https", kclient.(*kubelet).endpoint.Scheme)
+	// And the client describes how it connects to the Kubelet
+	assert.Equal(t, client.Description{
+		Endpoint:       "https://" + fakeDiscoveredAPIHost + "/api/v1/nodes/" + defaultNodeName + "/proxy/",
+		Authentication: "Service account (Bearer token), proxied through the API server",
+	}, client.Describe(kclient))
 }
 
 func TestDiscover_NodeNotFoundError(t *testing.T) {
@@ -15,10 +15,12 @@ import (
 type kubelet struct {
 	apiServer               apiserver.Client
 	client                  client.HTTPClient
+	usage                   data.FetchFunc
 	fetchers                []data.FetchFunc
 	logger                  *logrus.Logger
 	defaultNetworkInterface string
 	enableVolumeMetrics     bool
+	owners                  *ownerResolver
 }
 
 func (r *kubelet) Group(definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
@@ -39,28 +41,34 @@ func (r *kubelet) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 					Errors:      []error{fmt.Errorf("error querying Kubelet. %s", err)},
 				}
 			}
-
+			r.logger.Debugf("recoverable error fetching kubelet metrics: %s", err)
 		}
 		fillGroupsAndMergeNonExistent(rawGroups, g)
 	}
 
-	// TODO wrap this process in a new fetchFunc
-	response, err := metric.GetMetricsData(r.client)
-	if err != nil {
-		return nil, &data.ErrorGroup{
-			Recoverable: false,
-			Errors:      []error{fmt.Errorf("error querying Kubelet. %s", err)},
+	r.owners.resolve(rawGroups)
+
+	nodeName, ok := r.usageGroups(rawGroups)
+	if !ok {
+		// TODO wrap this process in a new fetchFunc
+		response, err := metric.GetMetricsData(r.client)
+		if err != nil {
+			return nil, &data.ErrorGroup{
+				Recoverable: false,
+				Errors:      []error{fmt.Errorf("error querying Kubelet. %s", err)},
+			}
 		}
-	}
 
-	resources, errs := metric.GroupStatsSummary(response, r.enableVolumeMetrics)
-	if len(errs) != 0 {
-		return nil, &data.ErrorGroup{Recoverable: true, Errors: errs}
-	}
+		resources, errs := metric.GroupStatsSummary(response, r.enableVolumeMetrics)
+		if len(errs) != 0 {
+			return nil, &data.ErrorGroup{Recoverable: true, Errors: errs}
+		}
 
-	fillGroupsAndMergeNonExistent(rawGroups, resources)
+		fillGroupsAndMergeNonExistent(rawGroups, resources)
+		nodeName = response.Node.NodeName
+	}
 
-	nodeInfo, err := r.apiServer.GetNodeInfo(response.Node.NodeName)
+	nodeInfo, err := r.apiServer.GetNodeInfo(nodeName)
 	if err != nil {
 		return nil, &data.ErrorGroup{
 			Recoverable: false,
@@ -69,10 +77,13 @@ func (r *kubelet) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 	}
 	g := definition.RawGroups{
 		"node": {
-			response.Node.NodeName: definition.RawMetrics{
-				"labels":      nodeInfo.Labels,
-				"allocatable": nodeInfo.Allocatable,
-				"capacity":    nodeInfo.Capacity,
+			nodeName: definition.RawMetrics{
+				"labels":        nodeInfo.Labels,
+				"allocatable":   nodeInfo.Allocatable,
+				"capacity":      nodeInfo.Capacity,
+				"conditions":    nodeInfo.Conditions,
+				"taints":        nodeInfo.Taints,
+				"unschedulable": nodeInfo.Unschedulable,
 			},
 		},
 	}
@@ -81,15 +92,41 @@ func (r *kubelet) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 	return rawGroups, nil
 }
 
-// NewGrouper creates a grouper aware of Kubelet raw metrics.
-func NewGrouper(c client.HTTPClient, logger *logrus.Logger, apiServer apiserver.Client, defaultNetworkInterface string, enableVolumeMetrics bool, fetchers ...data.FetchFunc) data.Grouper {
+// usageGroups adds the node, pod and container usage fetched by the usage FetchFunc to the groups, returning the
+// name of the node. It returns false if there is no usage FetchFunc, so the usage is taken from /stats/summary.
+func (r *kubelet) usageGroups(rawGroups definition.RawGroups) (string, bool) {
+	if r.usage == nil {
+		return "", false
+	}
+
+	g, err := r.usage()
+	if err != nil {
+		if _, ok := err.(data.ErrorGroup); !ok {
+			r.logger.Warnf("error querying Kubelet usage, falling back to %s: %s", metric.StatsSummaryPath, err)
+			return "", false
+		}
+		r.logger.Debugf("recoverable error fetching kubelet usage: %s", err)
+	}
+	fillGroupsAndMergeNonExistent(rawGroups, g)
+
+	for nodeName := range g["node"] {
+		return nodeName, true
+	}
+	return "", false
+}
+
+// NewGrouper creates a grouper aware of Kubelet raw metrics. The usage of the node, pods and containers is fetched
+// from /stats/summary, unless a usage FetchFunc is given.
+func NewGrouper(c client.HTTPClient, logger *logrus.Logger, apiServer apiserver.Client, defaultNetworkInterface string, enableVolumeMetrics bool, usage data.FetchFunc, fetchers ...data.FetchFunc) data.Grouper {
 	return &kubelet{
 		apiServer:               apiServer,
 		client:                  c,
 		logger:                  logger,
+		usage:                   usage,
 		fetchers:                fetchers,
 		defaultNetworkInterface: defaultNetworkInterface,
 		enableVolumeMetrics:     enableVolumeMetrics,
+		owners:                  newOwnerResolver(apiServer, logger),
 	}
 }
 
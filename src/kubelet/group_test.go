@@ -6,11 +6,13 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/newrelic/nri-kubernetes/src/apiserver"
 	"github.com/newrelic/nri-kubernetes/src/definition"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/newrelic/nri-kubernetes/src/kubelet/metric"
 	"github.com/newrelic/nri-kubernetes/src/kubelet/metric/testdata"
@@ -94,8 +96,31 @@ func TestGroup(t *testing.T) {
 				v1.ResourceEphemeralStorage: *resource.NewQuantity(18211586048, resource.BinarySI),
 				v1.ResourceMemory:           *resource.NewQuantity(2033283072, resource.BinarySI),
 			},
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+			},
+			Taints: []v1.Taint{
+				{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule},
+			},
 		},
-	}}
+	},
+		// The ReplicaSet of the sh pod is not found, so its deployment is still guessed from its name
+		Owners: map[string]*apiserver.OwnerInfo{
+			"ReplicaSet/kube-system/kube-state-metrics-57f4659995": {
+				Kind:      "ReplicaSet",
+				Name:      "kube-state-metrics-57f4659995",
+				Namespace: "kube-system",
+				OwnerKind: "Deployment",
+				OwnerName: "kube-state-metrics",
+			},
+			"Deployment/kube-system/kube-state-metrics": {
+				Kind:      "Deployment",
+				Name:      "kube-state-metrics",
+				Namespace: "kube-system",
+			},
+		},
+	}
 	queries := []prometheus.Query{
 		{
 			MetricName: "container_memory_usage_bytes",
@@ -138,6 +163,7 @@ func TestGroup(t *testing.T) {
 				a,
 				"eth0",
 				true,
+				nil,
 				podsFetcher.FetchFuncWithCache(),
 				metric.CadvisorFetchFunc(&c, queries),
 			)
@@ -148,3 +174,46 @@ func TestGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestGroup_UsageFetchFunc(t *testing.T) {
+	c := testClient{
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == metric.StatsSummaryPath {
+				t.Errorf("%s must not be requested when there is a usage FetchFunc", metric.StatsSummaryPath)
+			}
+			rawGroupsHandlerFunc(w, r)
+		},
+	}
+	a := apiserver.TestAPIServer{Mem: map[string]*apiserver.NodeInfo{
+		"minikube": {NodeName: "minikube", Labels: map[string]string{"kubernetes.io/os": "linux"}},
+	}}
+	usage := func() (definition.RawGroups, error) {
+		return definition.RawGroups{
+			"node": {
+				"minikube": {"nodeName": "minikube", "memoryWorkingSetBytes": uint64(1073741824)},
+			},
+			"pod": {
+				"kube-system_newrelic-infra-rz225": {"usageNanoCores": uint64(100000000)},
+			},
+		}, nil
+	}
+
+	podsFetcher := metric.NewPodsFetcher(logrus.StandardLogger(), &c, true)
+	grouper := NewGrouper(&c, logrus.StandardLogger(), a, "eth0", true, usage, podsFetcher.FetchFuncWithCache())
+	r, errGroup := grouper.Group(nil)
+	assert.Nil(t, errGroup)
+
+	assert.Equal(t, definition.RawMetrics{
+		"nodeName":              "minikube",
+		"memoryWorkingSetBytes": uint64(1073741824),
+		"labels":                map[string]string{"kubernetes.io/os": "linux"},
+		"allocatable":           v1.ResourceList(nil),
+		"capacity":              v1.ResourceList(nil),
+		"conditions":            []v1.NodeCondition(nil),
+		"taints":                []v1.Taint(nil),
+		"unschedulable":         false,
+	}, r["node"]["minikube"])
+	assert.Equal(t, uint64(100000000), r["pod"]["kube-system_newrelic-infra-rz225"]["usageNanoCores"])
+	assert.Equal(t, "newrelic-infra-rz225", r["pod"]["kube-system_newrelic-infra-rz225"]["podName"])
+	assert.NotContains(t, r, "volume")
+}
@@ -3,7 +3,6 @@ package metric
 import (
 	"errors"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/newrelic/nri-kubernetes/src/client"
@@ -20,28 +19,134 @@ const (
 	StandaloneCAdvisorMetricsPath = "/metrics"
 )
 
+// summedCadvisorMetrics are the cAdvisor metrics reported once per device or network interface of a container,
+// which are summed up into a single value for the container.
+var summedCadvisorMetrics = map[string]bool{
+	"container_fs_reads_bytes_total":                   true,
+	"container_fs_writes_bytes_total":                  true,
+	"container_fs_reads_total":                         true,
+	"container_fs_writes_total":                        true,
+	"container_network_receive_bytes_total":            true,
+	"container_network_transmit_bytes_total":           true,
+	"container_network_receive_errors_total":           true,
+	"container_network_transmit_errors_total":          true,
+	"container_network_receive_packets_dropped_total":  true,
+	"container_network_transmit_packets_dropped_total": true,
+}
+
+// networkCadvisorMetrics are the cAdvisor metrics of the network usage. The containers of a pod share its network
+// namespace, so most runtimes only report them for the pod sandbox: the "POD" container with Docker, or the cgroup
+// of the pod, without container name, with containerd and CRI-O. They are attached to every container of the pod
+// not reporting them itself.
+var networkCadvisorMetrics = map[string]bool{
+	"container_network_receive_bytes_total":            true,
+	"container_network_transmit_bytes_total":           true,
+	"container_network_receive_errors_total":           true,
+	"container_network_transmit_errors_total":          true,
+	"container_network_receive_packets_dropped_total":  true,
+	"container_network_transmit_packets_dropped_total": true,
+}
+
+// sandboxNetwork holds the network metrics of a pod, summed over its interfaces. The ones of the "POD" container
+// are preferred over the ones of the pod cgroup, as some cAdvisor versions report both.
+type sandboxNetwork struct {
+	pause     definition.RawMetrics
+	podCgroup definition.RawMetrics
+}
+
+// add sums the given value of a network metric of the pause container, or the pod cgroup.
+func (n *sandboxNetwork) add(pause bool, name string, value prometheus.Value) error {
+	metrics := &n.podCgroup
+	if pause {
+		metrics = &n.pause
+	}
+	if *metrics == nil {
+		*metrics = make(definition.RawMetrics)
+	}
+	if previous, ok := (*metrics)[name]; ok {
+		sum, err := addValues(previous, value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		value = sum
+	}
+	(*metrics)[name] = value
+	return nil
+}
+
+func (n *sandboxNetwork) metrics() definition.RawMetrics {
+	if n.pause != nil {
+		return n.pause
+	}
+	return n.podCgroup
+}
+
+// Names of the labels identifying the container of a cAdvisor metric. The kubelet adds the container, pod and
+// namespace ones, named after the version of Kubernetes, while a standalone cAdvisor exposes the Kubernetes labels
+// of the container set by the runtime.
 var (
-	dockerNativeWithoutSystemD = regexp.MustCompile("^.*([0-9a-f]+)$")
-	dockerNativeWithSystemD    = regexp.MustCompile("^.*\\w+-([0-9a-f]+)\\.scope$")
-	dockerGeneric              = regexp.MustCompile("^([0-9a-f]+)$")
+	containerNameLabels = []string{"container_name", "container", "container_label_io_kubernetes_container_name"}
+	podNameLabels       = []string{"pod_name", "pod", "container_label_io_kubernetes_pod_name"}
+	namespaceLabels     = []string{"namespace", "container_label_io_kubernetes_pod_namespace"}
 )
 
-// getLabel returns the first label it finds by the given names
+// getLabel returns the first non empty label it finds by the given names, and whether any of them is present
 func getLabel(labels prometheus.Labels, names ...string) (string, bool) {
+	var found bool
 	for _, name := range names {
 		if labels.Has(name) {
-			return labels[name], true
+			if labels[name] != "" {
+				return labels[name], true
+			}
+			found = true
 		}
 	}
-	return "", false
+	return "", found
 }
 
 // CadvisorFetchFunc creates a FetchFunc that fetches data from the kubelet cadvisor metrics path.
 func CadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.FetchFunc {
+	return cadvisorFetchFunc(c, KubeletCAdvisorMetricsPath, queries, false)
+}
+
+// StandaloneCadvisorFetchFunc creates a FetchFunc that fetches data from the metrics path of a standalone cadvisor,
+// producing the same groups as CadvisorFetchFunc. The metrics of the cgroups that do not belong to a Kubernetes
+// container, which a standalone cadvisor reports for the whole node, are skipped.
+func StandaloneCadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.FetchFunc {
+	return cadvisorFetchFunc(c, StandaloneCAdvisorMetricsPath, queries, true)
+}
+
+// CadvisorFallbackFetchFunc creates a FetchFunc that fetches the cadvisor data with the first of the given
+// FetchFuncs that does not fail and finds some container. Recoverable errors, like the ones of metrics missing
+// some labels, do not make it fall back to the next FetchFunc as long as some container is found. The result of
+// the last FetchFunc is returned as is.
+func CadvisorFallbackFetchFunc(fetchFuncs ...data.FetchFunc) data.FetchFunc {
+	return func() (definition.RawGroups, error) {
+		var errs []string
+		for i, fetch := range fetchFuncs {
+			g, err := fetch()
+			if errGroup, ok := err.(data.ErrorGroup); err != nil && (!ok || !errGroup.Recoverable) {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if len(g["container"]) == 0 && i < len(fetchFuncs)-1 {
+				errs = append(errs, "no container found in cadvisor metrics")
+				if err != nil {
+					errs[len(errs)-1] += ". " + err.Error()
+				}
+				continue
+			}
+			return g, err
+		}
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+}
+
+func cadvisorFetchFunc(c client.HTTPClient, metricsPath string, queries []prometheus.Query, standalone bool) data.FetchFunc {
 	return func() (definition.RawGroups, error) {
-		families, err := prometheus.Do(c, KubeletCAdvisorMetricsPath, queries)
+		families, err := prometheus.Do(c, metricsPath, queries)
 		if err != nil {
-			return nil, fmt.Errorf("error requesting cadvisor metrics endpoint. %s. Try setting the CADVISOR_PORT env variable in the configuration", err)
+			return nil, fmt.Errorf("error requesting cadvisor metrics endpoint %s. %s", metricsPath, err)
 		}
 
 		var errs []error
@@ -49,14 +154,35 @@ func CadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.Fet
 		g := definition.RawGroups{
 			"container": make(map[string]definition.RawMetrics),
 		}
+		// sandboxes and podContainers are indexed by the namespace and name of the pods.
+		sandboxes := make(map[string]*sandboxNetwork)
+		podContainers := make(map[string][]string)
 
 		for _, f := range families {
 			for _, m := range f.Metrics {
 
-				if label, _ := getLabel(m.Labels, "container_name", "container"); label == "POD" {
+				containerName, labeled := getLabel(m.Labels, containerNameLabels...)
+				if labeled && (containerName == "POD" || containerName == "") && networkCadvisorMetrics[f.Name] {
+					pod, ok := podKey(m.Labels)
+					if !ok {
+						continue
+					}
+					if sandboxes[pod] == nil {
+						sandboxes[pod] = &sandboxNetwork{}
+					}
+					if err := sandboxes[pod].add(containerName == "POD", f.Name, m.Value); err != nil {
+						errs = append(errs, err)
+					}
+					continue
+				}
+				if containerName == "POD" {
 					// skipping metrics from pod containers
 					continue
 				}
+				if !labeled && standalone {
+					// A standalone cadvisor reports the cgroups of the node not belonging to any container
+					continue
+				}
 
 				rawEntityID, err := createRawEntityID(m)
 				if err != nil {
@@ -69,7 +195,7 @@ func CadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.Fet
 					continue
 				}
 
-				containerID := extractContainerID(m.Labels["id"])
+				containerID, runtime := containerIDFromCgroup(m.Labels["id"])
 				if containerID == "" {
 					errs = append(errs, errors.New("container id not found in cAdvisor metrics"))
 					continue
@@ -83,7 +209,12 @@ func CadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.Fet
 				if metrics, ok = g["container"][rawEntityID]; !ok {
 					metrics = make(definition.RawMetrics)
 					metrics["containerID"] = containerID
+					if runtime != "" {
+						metrics["containerRuntime"] = runtime
+					}
 					g["container"][rawEntityID] = metrics
+					pod, _ := podKey(m.Labels)
+					podContainers[pod] = append(podContainers[pod], rawEntityID)
 				}
 
 				switch f.Name {
@@ -95,12 +226,32 @@ func CadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.Fet
 					}
 					metrics["containerImageID"] = m.Labels["image"]
 				default:
+					if previous, ok := metrics[f.Name]; ok && summedCadvisorMetrics[f.Name] {
+						sum, err := addValues(previous, m.Value)
+						if err != nil {
+							errs = append(errs, fmt.Errorf("%s: %v", f.Name, err))
+							continue
+						}
+						metrics[f.Name] = sum
+						continue
+					}
 					// by default, we want the actual metric
 					metrics[f.Name] = m.Value
 				}
 			}
 		}
 
+		for pod, sandbox := range sandboxes {
+			for _, rawEntityID := range podContainers[pod] {
+				metrics := g["container"][rawEntityID]
+				for name, value := range sandbox.metrics() {
+					if _, ok := metrics[name]; !ok {
+						metrics[name] = value
+					}
+				}
+			}
+		}
+
 		if len(errs) > 0 {
 			return g, data.ErrorGroup{
 				Errors:      errs,
@@ -112,8 +263,32 @@ func CadvisorFetchFunc(c client.HTTPClient, queries []prometheus.Query) data.Fet
 	}
 }
 
+// addValues adds up two values of the same metric.
+func addValues(a definition.FetchedValue, b prometheus.Value) (prometheus.Value, error) {
+	switch value := b.(type) {
+	case prometheus.CounterValue:
+		if previous, ok := a.(prometheus.CounterValue); ok {
+			return previous + value, nil
+		}
+	case prometheus.GaugeValue:
+		if previous, ok := a.(prometheus.GaugeValue); ok {
+			return previous + value, nil
+		}
+	default:
+		return nil, fmt.Errorf("unexpected value type %T", b)
+	}
+	return nil, fmt.Errorf("incompatible value types %T and %T", a, b)
+}
+
+// podKey returns the namespace and name of the pod of a cAdvisor metric, and whether both are set.
+func podKey(labels prometheus.Labels) (string, bool) {
+	namespace, _ := getLabel(labels, namespaceLabels...)
+	podName, _ := getLabel(labels, podNameLabels...)
+	return namespace + "_" + podName, namespace != "" && podName != ""
+}
+
 func createRawEntityID(m prometheus.Metric) (string, error) {
-	containerName, ok := getLabel(m.Labels, "container_name", "container")
+	containerName, ok := getLabel(m.Labels, containerNameLabels...)
 	if !ok {
 		return "", errors.New("container name not found in cAdvisor metrics")
 	}
@@ -121,36 +296,16 @@ func createRawEntityID(m prometheus.Metric) (string, error) {
 		return "", nil
 	}
 
-	namespace := m.Labels["namespace"]
+	namespace, _ := getLabel(m.Labels, namespaceLabels...)
 	if namespace == "" {
 		return "", errors.New("namespace not found in cAdvisor metrics")
 
 	}
 
-	podName, _ := getLabel(m.Labels, "pod_name", "pod")
+	podName, _ := getLabel(m.Labels, podNameLabels...)
 	if podName == "" {
 		return "", errors.New("pod name not found in cAdvisor metrics")
 	}
 
 	return fmt.Sprintf("%s_%s_%s", namespace, podName, containerName), nil
 }
-
-// /kubepods/besteffort/podba8b34d7-11a3-11e8-a084-080027352a02/a949bd136c1397b9f52905538ee11450427be33648abe38db06be2e5cfbeca49
-// /docker/ae17ce6dcd2f27905cedf80609044290eccd98115b4e1ded08fcf6852cf939ae/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod13118b761000f8fe2c4662d5f32d9532.slice/crio-ebccdd64bb3ef5dfa9d9b167cb5e30f9b696c2694fb7e0783af5575c28be3d1b.scope
-// /docker/d44b560aba016229fd4f87a33bf81e8eaf6c81932a0623530456e8f80f9675ad/kubepods/besteffort/pod6edbcc6c66e4b5af53005f91bf0bc1fd/7588a02459ef3166ba043c5a605c9ce65e4dd250d7ee40428a28d806c4116e97
-func extractContainerID(v string) string {
-	containerID := v[strings.LastIndex(v, "/")+1:]
-	matches := dockerNativeWithSystemD.FindStringSubmatch(containerID)
-	if len(matches) > 0 {
-		return matches[1]
-	}
-	matches = dockerNativeWithoutSystemD.FindStringSubmatch(containerID)
-	if len(matches) > 0 {
-		return matches[0]
-	}
-	matches = dockerGeneric.FindStringSubmatch(containerID)
-	if len(matches) > 0 {
-		return matches[0]
-	}
-	return containerID
-}
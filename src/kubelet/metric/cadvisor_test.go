@@ -11,9 +11,11 @@ import (
 	"strings"
 
 	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
 	"github.com/newrelic/nri-kubernetes/src/kubelet/metric/testdata"
 	"github.com/newrelic/nri-kubernetes/src/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var cadvisorQueries = []prometheus.Query{
@@ -89,35 +91,204 @@ container_memory_usage_bytes{container_name="influxdb",id="/kubepods/besteffort/
 	assert.ElementsMatch(t, expectedErrs, err.(data.ErrorGroup).Errors)
 }
 
-func TestExtractContainerIdWithoutSystemD_ReturnsCorrectId(t *testing.T) {
-	// given
-	fullContainerID := "/docker/d44b560aba016229fd4f87a33bf81e8eaf6c81932a0623530456e8f80f9675ad/kubepods/besteffort/pod6edbcc6c66e4b5af53005f91bf0bc1fd/7588a02459ef3166ba043c5a605c9ce65e4dd250d7ee40428a28d806c4116e97"
-	expected := "7588a02459ef3166ba043c5a605c9ce65e4dd250d7ee40428a28d806c4116e97"
+func TestCadvisorFetchFunc_SumsDevicesAndInterfaces(t *testing.T) {
+	f := strings.NewReader(`# TYPE container_fs_reads_bytes_total counter
+container_fs_reads_bytes_total{container="app",device="/dev/sda",id="/kubepods/burstable/pod1/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",namespace="default",pod="app-1"} 1000
+container_fs_reads_bytes_total{container="app",device="/dev/sdb",id="/kubepods/burstable/pod1/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",namespace="default",pod="app-1"} 500
+# TYPE container_network_receive_bytes_total counter
+container_network_receive_bytes_total{container="app",id="/kubepods/burstable/pod1/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",interface="eth0",namespace="default",pod="app-1"} 2000
+container_network_receive_bytes_total{container="app",id="/kubepods/burstable/pod1/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",interface="net1",namespace="default",pod="app-1"} 300
+container_network_receive_bytes_total{container="POD",id="/kubepods/burstable/pod1/81de1e9aba1c051a2f9780a5db594a899c9e4e76613d4c95da4561cc48e8658f",image="pause:3.1",interface="eth0",namespace="default",pod="app-1"} 2000
+# TYPE container_memory_cache gauge
+container_memory_cache{container="app",id="/kubepods/burstable/pod1/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",namespace="default",pod="app-1"} 4096
+`)
+
+	c := testClient{
+		handler: readerToHandler(f),
+	}
+
+	g, err := CadvisorFetchFunc(&c, []prometheus.Query{
+		{MetricName: "container_fs_reads_bytes_total"},
+		{MetricName: "container_network_receive_bytes_total"},
+		{MetricName: "container_memory_cache"},
+	})()
+	assert.NoError(t, err)
+
+	assert.Equal(t, definition.RawGroups{
+		"container": {
+			"default_app-1_app": {
+				"containerID":                           "3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",
+				"container_fs_reads_bytes_total":        prometheus.CounterValue(1500),
+				"container_network_receive_bytes_total": prometheus.CounterValue(2300),
+				"container_memory_cache":                prometheus.GaugeValue(4096),
+			},
+		},
+	}, g)
+}
+
+func TestCadvisorFetchFunc_SandboxNetwork(t *testing.T) {
+	f := strings.NewReader(`# TYPE container_network_receive_packets_dropped_total counter
+container_network_receive_packets_dropped_total{container="POD",id="/kubepods/burstable/pod1/81de1e9aba1c051a2f9780a5db594a899c9e4e76613d4c95da4561cc48e8658f",image="pause:3.1",interface="eth0",namespace="default",pod="app-1"} 5
+container_network_receive_packets_dropped_total{container="POD",id="/kubepods/burstable/pod1/81de1e9aba1c051a2f9780a5db594a899c9e4e76613d4c95da4561cc48e8658f",image="pause:3.1",interface="net1",namespace="default",pod="app-1"} 2
+container_network_receive_packets_dropped_total{container="",id="/kubepods/burstable/pod1",image="",interface="eth0",namespace="default",pod="app-1"} 100
+container_network_receive_packets_dropped_total{container="",id="/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod2.slice",image="",interface="eth0",namespace="default",pod="db-1"} 3
+# TYPE container_memory_cache gauge
+container_memory_cache{container="app",id="/kubepods/burstable/pod1/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",namespace="default",pod="app-1"} 4096
+container_memory_cache{container="sidecar",id="/kubepods/burstable/pod1/7f092105225a729f4917aa6950b5b90236c720fc411eee80ba9f7ca0f639525f",image="sidecar:1",namespace="default",pod="app-1"} 1024
+container_memory_cache{container="db",id="/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod2.slice/cri-containerd-fd0ca055e308e5d11b0c8fbf273b733d1166aa2823bf7fd724a6b70c72959774.scope",image="db:1",namespace="default",pod="db-1"} 2048
+`)
+
+	c := testClient{
+		handler: readerToHandler(f),
+	}
+
+	g, err := CadvisorFetchFunc(&c, []prometheus.Query{
+		{MetricName: "container_network_receive_packets_dropped_total"},
+		{MetricName: "container_memory_cache"},
+	})()
+	assert.NoError(t, err)
+
+	// The containers of a pod get the network metrics of its sandbox, summed over its interfaces. The ones of the
+	// POD container are preferred over the ones of the pod cgroup.
+	dropped := "container_network_receive_packets_dropped_total"
+	require.Len(t, g["container"], 3)
+	assert.Equal(t, prometheus.CounterValue(7), g["container"]["default_app-1_app"][dropped])
+	assert.Equal(t, prometheus.CounterValue(7), g["container"]["default_app-1_sidecar"][dropped])
+	assert.Equal(t, prometheus.CounterValue(3), g["container"]["default_db-1_db"][dropped])
+}
+
+func TestCadvisorFetchFunc_ContainerRuntime(t *testing.T) {
+	f := strings.NewReader(`# TYPE container_memory_cache gauge
+container_memory_cache{container="app",id="/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod13118b76_1000_f8fe.slice/cri-containerd-3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1.scope",image="app:1",namespace="default",pod="app-1"} 4096
+`)
+
+	c := testClient{
+		handler: readerToHandler(f),
+	}
+
+	g, err := CadvisorFetchFunc(&c, []prometheus.Query{{MetricName: "container_memory_cache"}})()
+	assert.NoError(t, err)
+
+	assert.Equal(t, definition.RawMetrics{
+		"containerID":            "3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",
+		"containerRuntime":       RuntimeContainerd,
+		"container_memory_cache": prometheus.GaugeValue(4096),
+	}, g["container"]["default_app-1_app"])
+}
+
+func TestStandaloneCadvisorFetchFunc(t *testing.T) {
+	f, err := os.Open("testdata/standalone_cadvisor_metrics_payload_plain.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	var path string
+	c := testClient{
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			io.Copy(w, f) // nolint: errcheck
+		},
+	}
 
-	// when
-	actual := extractContainerID(fullContainerID)
+	queries := []prometheus.Query{
+		{MetricName: "container_memory_usage_bytes"},
+		{MetricName: "container_cpu_cfs_periods_total"},
+		{MetricName: "container_memory_cache"},
+		{MetricName: "container_fs_reads_bytes_total"},
+		{MetricName: "container_spec_memory_limit_bytes"},
+	}
+	g, err := StandaloneCadvisorFetchFunc(&c, queries)()
+	assert.NoError(t, err)
 
-	assert.Equal(t, expected, actual)
+	assert.Equal(t, StandaloneCAdvisorMetricsPath, path)
+	assert.Equal(t, definition.RawGroups{
+		"container": {
+			"default_nginx-6799fc88d8-5jx7v_nginx": {
+				"containerID":                       "4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b",
+				"containerImageID":                  "nginx@sha256:6926dd802f40e5e7257fded83e0d8030039642e4e10c4a98a6478e9c6fe06153",
+				"container_memory_cache":            prometheus.GaugeValue(8192),
+				"container_fs_reads_bytes_total":    prometheus.CounterValue(1053696),
+				"container_spec_memory_limit_bytes": prometheus.GaugeValue(0),
+			},
+			"kube-system_coredns-74ff55c5b-x7k2p_coredns": {
+				"containerID":                       "c0ffee0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+				"containerImageID":                  "k8s.gcr.io/coredns@sha256:73ca82b4ce829766d4f1f10947c3a338888f876fbed0540dc849c89ff256e90c",
+				"container_cpu_cfs_periods_total":   prometheus.CounterValue(48211),
+				"container_memory_cache":            prometheus.GaugeValue(1536000),
+				"container_fs_reads_bytes_total":    prometheus.CounterValue(2625536),
+				"container_spec_memory_limit_bytes": prometheus.GaugeValue(178257920),
+			},
+		},
+	}, g)
 }
 
-func TestExtractContainerIdWithSystemD_ReturnsCorrectId(t *testing.T) {
-	// given
-	fullContainerID := "/docker/ae17ce6dcd2f27905cedf80609044290eccd98115b4e1ded08fcf6852cf939ae/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod13118b761000f8fe2c4662d5f32d9532.slice/crio-ebccdd64bb3ef5dfa9d9b167cb5e30f9b696c2694fb7e0783af5575c28be3d1b.scope"
-	expected := "ebccdd64bb3ef5dfa9d9b167cb5e30f9b696c2694fb7e0783af5575c28be3d1b"
+func TestStandaloneCadvisorFetchFunc_SkipsCgroupsWithoutLabels(t *testing.T) {
+	f := strings.NewReader(`# TYPE container_memory_cache gauge
+container_memory_cache{id="/",image="",name=""} 2.109718528e+09
+container_memory_cache{id="/system.slice/containerd.service",image="",name=""} 1.153024e+07
+container_memory_cache{container_label_io_kubernetes_container_name="app",container_label_io_kubernetes_pod_name="app-1",container_label_io_kubernetes_pod_namespace="default",id="/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod13118b76_1000_f8fe.slice/cri-containerd-3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1.scope",image="app:1",name="3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1"} 4096
+`)
+	c := testClient{
+		handler: readerToHandler(f),
+	}
 
-	// when
-	actual := extractContainerID(fullContainerID)
+	g, err := StandaloneCadvisorFetchFunc(&c, []prometheus.Query{{MetricName: "container_memory_cache"}})()
+	assert.NoError(t, err)
 
-	assert.Equal(t, expected, actual)
+	assert.Equal(t, definition.RawGroups{
+		"container": {
+			"default_app-1_app": {
+				"containerID":            "3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",
+				"containerRuntime":       RuntimeContainerd,
+				"container_memory_cache": prometheus.GaugeValue(4096),
+			},
+		},
+	}, g)
 }
 
-func TestExtractContainerIdDockerGeneric_ReturnsCorrectId(t *testing.T) {
-	// given
-	fullContainerID := "docker://5a4eefc5b30f6f12402665bd920ee8e889ba9e14bdcc623e2865a79d40f58412"
-	expected := "5a4eefc5b30f6f12402665bd920ee8e889ba9e14bdcc623e2865a79d40f58412"
+func TestCadvisorFallbackFetchFunc(t *testing.T) {
+	groups := definition.RawGroups{"container": {"default_app-1_app": {"containerName": "app"}}}
+	succeeding := func() (definition.RawGroups, error) { return groups, nil }
+	failing := func() (definition.RawGroups, error) { return nil, errors.New("connection refused") }
+	recoverable := func() (definition.RawGroups, error) {
+		return groups, data.ErrorGroup{Recoverable: true, Errors: []error{errors.New("pod name not found in cAdvisor metrics")}}
+	}
 
-	// when
-	actual := extractContainerID(fullContainerID)
+	t.Run("first succeeds", func(t *testing.T) {
+		g, err := CadvisorFallbackFetchFunc(succeeding, failing)()
+		assert.NoError(t, err)
+		assert.Equal(t, groups, g)
+	})
+	t.Run("falls back to the next one", func(t *testing.T) {
+		g, err := CadvisorFallbackFetchFunc(failing, succeeding)()
+		assert.NoError(t, err)
+		assert.Equal(t, groups, g)
+	})
+	t.Run("recoverable errors do not fall back", func(t *testing.T) {
+		g, err := CadvisorFallbackFetchFunc(recoverable, failing)()
+		assert.Error(t, err)
+		assert.Equal(t, groups, g)
+	})
+	t.Run("falls back when no container is found", func(t *testing.T) {
+		empty := func() (definition.RawGroups, error) {
+			return definition.RawGroups{"container": {}}, data.ErrorGroup{Recoverable: true, Errors: []error{errors.New("container name not found in cAdvisor metrics")}}
+		}
+		g, err := CadvisorFallbackFetchFunc(empty, succeeding)()
+		assert.NoError(t, err)
+		assert.Equal(t, groups, g)
 
-	assert.Equal(t, expected, actual)
+		g, err = CadvisorFallbackFetchFunc(succeeding, empty)()
+		assert.NoError(t, err)
+		assert.Equal(t, groups, g)
+	})
+	t.Run("last result is returned even without containers", func(t *testing.T) {
+		empty := func() (definition.RawGroups, error) { return definition.RawGroups{"container": {}}, nil }
+		g, err := CadvisorFallbackFetchFunc(failing, empty)()
+		assert.NoError(t, err)
+		assert.Equal(t, definition.RawGroups{"container": {}}, g)
+	})
+	t.Run("all fail", func(t *testing.T) {
+		g, err := CadvisorFallbackFetchFunc(failing, failing)()
+		assert.EqualError(t, err, "connection refused; connection refused")
+		assert.Nil(t, g)
+	})
 }
@@ -0,0 +1,79 @@
+package metric
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Container runtimes that can be told from the cgroup of a container or from its ID in the pod status.
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+	RuntimeCRIO       = "cri-o"
+	RuntimePodman     = "podman"
+)
+
+// cgroupContainerID matches the cgroup names of containers, which are the container ID optionally prefixed by the
+// runtime, like docker-<id>.scope or cri-containerd-<id>.scope with the systemd cgroup driver, or just <id> with the
+// cgroupfs one. Runtimes not supporting the systemd driver natively use names like
+// kubepods-besteffort-pod<uid>.slice:cri-containerd:<id>.
+var cgroupContainerID = regexp.MustCompile(`(?:^|:)(?:([a-z-]+?)[-:])?([0-9a-f]{64})(?:\.scope)?$`)
+
+// cgroupRuntimePrefixes are the prefixes each runtime adds to the cgroup names of containers.
+var cgroupRuntimePrefixes = map[string]string{
+	"docker":         RuntimeDocker,
+	"cri-containerd": RuntimeContainerd,
+	"containerd":     RuntimeContainerd,
+	"crio":           RuntimeCRIO,
+	"libpod":         RuntimePodman,
+}
+
+// statusRuntimes are the runtimes reported as prefixes of the container IDs in the pod status, like containerd://<id>.
+var statusRuntimes = map[string]string{
+	"docker":     RuntimeDocker,
+	"containerd": RuntimeContainerd,
+	"cri-o":      RuntimeCRIO,
+	"podman":     RuntimePodman,
+}
+
+// containerIDFromCgroup returns the ID of the container running in the given cgroup, and the runtime running it when
+// it can be told from the cgroup. It supports the cgroup v1 and v2 hierarchies with both the systemd and cgroupfs
+// drivers, like:
+//
+//	/kubepods/besteffort/pod6edbcc6c66e4b5af53005f91bf0bc1fd/<id>
+//	/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod13118b76.slice/cri-containerd-<id>.scope
+//	/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod6edbcc6c.slice/crio-<id>.scope/container
+//	/machine.slice/libpod-<id>.scope
+//
+// Cgroups nested in other containers, like in kind or minikube, are supported too. If no container ID is found, the
+// last element of the path is returned.
+func containerIDFromCgroup(cgroup string) (string, string) {
+	segments := strings.Split(strings.TrimSuffix(cgroup, "/"), "/")
+	last := len(segments) - 1
+	// The processes of a container can be moved to a child cgroup, as crun does with cgroup v2.
+	if last > 0 && segments[last] == "container" {
+		last--
+	}
+
+	matches := cgroupContainerID.FindStringSubmatch(segments[last])
+	if matches == nil {
+		return segments[len(segments)-1], ""
+	}
+
+	runtime := cgroupRuntimePrefixes[matches[1]]
+	if matches[1] == "" && last > 0 && segments[last-1] == "docker" {
+		// Containers run by Docker with the cgroupfs driver outside of Kubernetes, like /docker/<id>.
+		runtime = RuntimeDocker
+	}
+	return matches[2], runtime
+}
+
+// containerIDFromStatus returns the ID and the runtime of a container from the ID reported in its pod status, which
+// is prefixed by the runtime, like containerd://<id> or cri-o://<id>.
+func containerIDFromStatus(statusID string) (string, string) {
+	parts := strings.SplitN(statusID, "://", 2)
+	if len(parts) < 2 {
+		return statusID, ""
+	}
+	return parts[1], statusRuntimes[parts[0]]
+}
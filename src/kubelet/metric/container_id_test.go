@@ -0,0 +1,111 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testContainerID = "7588a02459ef3166ba043c5a605c9ce65e4dd250d7ee40428a28d806c4116e97"
+
+func TestContainerIDFromCgroup(t *testing.T) {
+	testCases := []struct {
+		name            string
+		cgroup          string
+		expectedID      string
+		expectedRuntime string
+	}{
+		{
+			name:       "cgroupfs driver",
+			cgroup:     "/kubepods/besteffort/pod6edbcc6c66e4b5af53005f91bf0bc1fd/" + testContainerID,
+			expectedID: testContainerID,
+		},
+		{
+			name:       "cgroupfs driver nested in a docker container",
+			cgroup:     "/docker/d44b560aba016229fd4f87a33bf81e8eaf6c81932a0623530456e8f80f9675ad/kubepods/besteffort/pod6edbcc6c66e4b5af53005f91bf0bc1fd/" + testContainerID,
+			expectedID: testContainerID,
+		},
+		{
+			name:            "docker with systemd driver",
+			cgroup:          "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod13118b761000f8fe2c4662d5f32d9532.slice/docker-" + testContainerID + ".scope",
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimeDocker,
+		},
+		{
+			name:            "containerd with systemd driver",
+			cgroup:          "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod13118b761000f8fe2c4662d5f32d9532.slice/cri-containerd-" + testContainerID + ".scope",
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimeContainerd,
+		},
+		{
+			name:            "cri-o with systemd driver nested in a docker container",
+			cgroup:          "/docker/ae17ce6dcd2f27905cedf80609044290eccd98115b4e1ded08fcf6852cf939ae/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod13118b761000f8fe2c4662d5f32d9532.slice/crio-" + testContainerID + ".scope",
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimeCRIO,
+		},
+		{
+			name:            "cri-o with cgroup v2 container sub-cgroup",
+			cgroup:          "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod13118b761000f8fe2c4662d5f32d9532.slice/crio-" + testContainerID + ".scope/container",
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimeCRIO,
+		},
+		{
+			name:            "containerd with systemd cgroup path",
+			cgroup:          "/kubepods-besteffort-pod13118b76_1000_f8fe.slice:cri-containerd:" + testContainerID,
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimeContainerd,
+		},
+		{
+			name:            "podman",
+			cgroup:          "/machine.slice/libpod-" + testContainerID + ".scope",
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimePodman,
+		},
+		{
+			name:            "docker with cgroupfs driver",
+			cgroup:          "/docker/" + testContainerID,
+			expectedID:      testContainerID,
+			expectedRuntime: RuntimeDocker,
+		},
+		{
+			name:       "no container",
+			cgroup:     "/kubepods/besteffort/pod6edbcc6c66e4b5af53005f91bf0bc1fd",
+			expectedID: "pod6edbcc6c66e4b5af53005f91bf0bc1fd",
+		},
+		{
+			name:       "empty",
+			cgroup:     "",
+			expectedID: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, runtime := containerIDFromCgroup(tc.cgroup)
+			assert.Equal(t, tc.expectedID, id)
+			assert.Equal(t, tc.expectedRuntime, runtime)
+		})
+	}
+}
+
+func TestContainerIDFromStatus(t *testing.T) {
+	testCases := []struct {
+		statusID        string
+		expectedID      string
+		expectedRuntime string
+	}{
+		{statusID: "docker://" + testContainerID, expectedID: testContainerID, expectedRuntime: RuntimeDocker},
+		{statusID: "containerd://" + testContainerID, expectedID: testContainerID, expectedRuntime: RuntimeContainerd},
+		{statusID: "cri-o://" + testContainerID, expectedID: testContainerID, expectedRuntime: RuntimeCRIO},
+		{statusID: "unknown://" + testContainerID, expectedID: testContainerID},
+		{statusID: testContainerID, expectedID: testContainerID},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.statusID, func(t *testing.T) {
+			id, runtime := containerIDFromStatus(tc.statusID)
+			assert.Equal(t, tc.expectedID, id)
+			assert.Equal(t, tc.expectedRuntime, runtime)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package metric
+
+import (
+	"fmt"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+// KubeletMetricsPath is the path where kubelet serves its own Prometheus metrics.
+const KubeletMetricsPath = "/metrics"
+
+// kubeletGroupLabel is the group of the metrics about the health of the kubelet, reported as K8sKubeletSample.
+const kubeletGroupLabel = "kubelet"
+
+// KubeletMetricsFetchFunc creates a FetchFunc that fetches the metrics the kubelet of the given node exposes about
+// itself, like the latency of the PLEG relists or the errors of the container runtime operations. Failing to fetch
+// them is a recoverable error, so the rest of the kubelet data is still reported.
+func KubeletMetricsFetchFunc(c client.HTTPClient, nodeName string, queries []prometheus.Query) data.FetchFunc {
+	return func() (definition.RawGroups, error) {
+		families, err := prometheus.Do(c, KubeletMetricsPath, queries)
+		if err != nil {
+			return nil, data.ErrorGroup{
+				Recoverable: true,
+				Errors:      []error{fmt.Errorf("error requesting kubelet metrics endpoint. %s", err)},
+			}
+		}
+
+		specs := definition.SpecGroups{kubeletGroupLabel: {}}
+		g, errs := prometheus.GroupEntityMetricsBySpec(specs, families, nodeName)
+		if len(errs) > 0 {
+			return nil, data.ErrorGroup{Recoverable: true, Errors: errs}
+		}
+		g[kubeletGroupLabel][nodeName]["nodeName"] = nodeName
+
+		return g, nil
+	}
+}
@@ -0,0 +1,68 @@
+package metric
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+var kubeletQueries = []prometheus.Query{
+	{MetricName: "kubelet_pleg_relist_duration_seconds"},
+	{MetricName: "kubelet_runtime_operations_errors_total"},
+	{MetricName: "kubelet_runtime_operations_duration_seconds"},
+	{MetricName: "kubelet_running_pod_count"},
+}
+
+func TestKubeletMetricsFetchFunc(t *testing.T) {
+	f, err := os.Open("./testdata/kubelet_metrics_payload.txt")
+	require.NoError(t, err)
+	defer f.Close() // nolint: errcheck
+
+	c := testClient{handler: readerToHandler(f)}
+	g, err := KubeletMetricsFetchFunc(&c, "minikube", kubeletQueries)()
+	require.NoError(t, err)
+
+	require.Contains(t, g, "kubelet")
+	raw := g["kubelet"]["minikube"]
+	assert.Len(t, raw, 5)
+	assert.Equal(t, "minikube", raw["nodeName"])
+	assert.NotContains(t, raw, "rest_client_requests_total")
+
+	relist, err := prometheus.FromHistogram("kubelet_pleg_relist_duration_seconds")("kubelet", "minikube", g)
+	require.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{
+		"kubelet_pleg_relist_duration_seconds_count":        uint64(8962),
+		"kubelet_pleg_relist_duration_seconds_sum":          150.47351722,
+		"kubelet_pleg_relist_duration_seconds_bucket_0.005": uint64(0),
+		"kubelet_pleg_relist_duration_seconds_bucket_0.01":  uint64(1720),
+		"kubelet_pleg_relist_duration_seconds_bucket_0.1":   uint64(8954),
+		"kubelet_pleg_relist_duration_seconds_bucket_1":     uint64(8961),
+	}, relist)
+
+	durations, err := prometheus.FromHistogramTotals("kubelet_runtime_operations_duration_seconds")("kubelet", "minikube", g)
+	require.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{
+		"kubelet_runtime_operations_duration_seconds_operation_type_list_containers_count": uint64(17802),
+		"kubelet_runtime_operations_duration_seconds_operation_type_list_containers_sum":   35.05,
+		"kubelet_runtime_operations_duration_seconds_operation_type_pull_image_count":      uint64(3),
+		"kubelet_runtime_operations_duration_seconds_operation_type_pull_image_sum":        12.5,
+	}, durations)
+}
+
+func TestKubeletMetricsFetchFunc_Unavailable(t *testing.T) {
+	c := testClient{handler: func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}}
+	_, err := KubeletMetricsFetchFunc(&c, "minikube", kubeletQueries)()
+	require.Error(t, err)
+	errGroup, ok := err.(data.ErrorGroup)
+	require.True(t, ok)
+	assert.True(t, errGroup.Recoverable)
+}
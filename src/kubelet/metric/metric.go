@@ -17,26 +17,64 @@ import (
 // StatsSummaryPath is the path where kubelet serves a summary with several information.
 const StatsSummaryPath = "/stats/summary"
 
+// systemContainerPods is the name of the system container tracking the cgroup all the pods run in.
+const systemContainerPods = "pods"
+
+// Summary is the response of the kubelet /stats/summary endpoint. It extends the vendored stats API with the
+// stats that only newer kubelets report.
+type Summary struct {
+	v1.Summary
+	// Rlimit holds the process limits of the node, reported from kubelet 1.12 on.
+	Rlimit *RlimitStats
+}
+
+// RlimitStats are the stats about the process limits of the node.
+type RlimitStats struct {
+	// MaxPID is the maximum process ID of the node, which bounds the processes that can run on it.
+	MaxPID *int64 `json:"maxpid,omitempty"`
+	// NumOfRunningProcesses is the number of processes running on the node.
+	NumOfRunningProcesses *int64 `json:"curproc,omitempty"`
+}
+
+// UnmarshalJSON decodes the summary along with the node stats missing in the vendored stats API.
+func (s *Summary) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &s.Summary); err != nil {
+		return err
+	}
+
+	var extended struct {
+		Node struct {
+			Rlimit *RlimitStats `json:"rlimit"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(b, &extended); err != nil {
+		return err
+	}
+	s.Rlimit = extended.Node.Rlimit
+
+	return nil
+}
+
 // GetMetricsData calls kubelet /stats/summary endpoint and returns unmarshalled response
-func GetMetricsData(c client.HTTPClient) (v1.Summary, error) {
+func GetMetricsData(c client.HTTPClient) (Summary, error) {
 	resp, err := c.Do(http.MethodGet, StatsSummaryPath)
 	if err != nil {
-		return v1.Summary{}, err
+		return Summary{}, err
 	}
 	defer resp.Body.Close() // nolint: errcheck
 	if resp.StatusCode != http.StatusOK {
-		return v1.Summary{}, fmt.Errorf("error calling kubelet endpoint. Got status code: %d", resp.StatusCode)
+		return Summary{}, fmt.Errorf("error calling kubelet endpoint. Got status code: %d", resp.StatusCode)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return v1.Summary{}, fmt.Errorf("error reading the response body of kubelet endpoint. Got error: %v", err.Error())
+		return Summary{}, fmt.Errorf("error reading the response body of kubelet endpoint. Got error: %v", err.Error())
 	}
 
-	var summary = new(v1.Summary)
+	var summary = new(Summary)
 	err = json.Unmarshal(body, summary)
 	if err != nil {
-		return v1.Summary{}, fmt.Errorf("error unmarshaling the response body. Got error: %v", err.Error())
+		return Summary{}, fmt.Errorf("error unmarshaling the response body. Got error: %v", err.Error())
 	}
 
 	return *summary, nil
@@ -104,6 +142,28 @@ func fetchNodeStats(n v1.NodeStats) (definition.RawMetrics, string, error) {
 		AddUint64RawMetric(r, "runtimeInodesUsed", n.Runtime.ImageFs.InodesUsed)
 	}
 
+	// Usage of the kubelet, the container runtime and the cgroup all the pods run in
+	for _, c := range n.SystemContainers {
+		var prefix string
+		switch c.Name {
+		case v1.SystemContainerKubelet:
+			prefix = "kubelet"
+		case v1.SystemContainerRuntime:
+			prefix = "runtime"
+		case systemContainerPods:
+			prefix = "podsCgroup"
+		default:
+			continue
+		}
+		if c.CPU != nil {
+			AddUint64RawMetric(r, prefix+"UsageNanoCores", c.CPU.UsageNanoCores)
+		}
+		if c.Memory != nil {
+			AddUint64RawMetric(r, prefix+"MemoryUsageBytes", c.Memory.UsageBytes)
+			AddUint64RawMetric(r, prefix+"MemoryWorkingSetBytes", c.Memory.WorkingSetBytes)
+		}
+	}
+
 	return r, nodeName, nil
 }
 
@@ -117,6 +177,20 @@ func fetchPodStats(pod v1.PodStats) (definition.RawMetrics, string, error) {
 	r["podName"] = pod.PodRef.Name
 	r["namespace"] = pod.PodRef.Namespace
 
+	// CPU and memory of the pod cgroup, which includes the usage of all the containers and the pod overhead
+	if pod.CPU != nil {
+		AddUint64RawMetric(r, "usageNanoCores", pod.CPU.UsageNanoCores)
+	}
+
+	if pod.Memory != nil {
+		AddUint64RawMetric(r, "memoryUsageBytes", pod.Memory.UsageBytes)
+		AddUint64RawMetric(r, "memoryWorkingSetBytes", pod.Memory.WorkingSetBytes)
+	}
+
+	if pod.EphemeralStorage != nil {
+		AddUint64RawMetric(r, "ephemeralStorageUsedBytes", pod.EphemeralStorage.UsedBytes)
+	}
+
 	if pod.Network != nil {
 		AddUint64RawMetric(r, "rxBytes", pod.Network.RxBytes)
 		AddUint64RawMetric(r, "txBytes", pod.Network.TxBytes)
@@ -192,7 +266,7 @@ func fetchVolumeStats(v v1.VolumeStats) (definition.RawMetrics, error) {
 }
 
 // GroupStatsSummary groups specific data for pods, containers and node
-func GroupStatsSummary(statsSummary v1.Summary, enableVolumeMetrics bool) (definition.RawGroups, []error) {
+func GroupStatsSummary(statsSummary Summary, enableVolumeMetrics bool) (definition.RawGroups, []error) {
 	var errs []error
 	var rawEntityID string
 	g := definition.RawGroups{
@@ -206,6 +280,10 @@ func GroupStatsSummary(statsSummary v1.Summary, enableVolumeMetrics bool) (defin
 	if err != nil {
 		errs = append(errs, err)
 	} else {
+		if statsSummary.Rlimit != nil {
+			addInt64RawMetric(rawNodeData, "maxPid", statsSummary.Rlimit.MaxPID)
+			addInt64RawMetric(rawNodeData, "runningProcesses", statsSummary.Rlimit.NumOfRunningProcesses)
+		}
 		g["node"][rawEntityID] = rawNodeData
 	}
 
@@ -302,7 +380,7 @@ func FromRawEntityIDGroupEntityIDGenerator(key string) definition.EntityIDGenera
 func FromRawGroupsEntityTypeGenerator(groupLabel string, rawEntityID string, groups definition.RawGroups, clusterName string) (string, error) {
 
 	switch groupLabel {
-	case "namespace", "node":
+	case "namespace", "node", kubeletGroupLabel:
 		return fmt.Sprintf("k8s:%s:%s", clusterName, groupLabel), nil
 
 	case "container":
@@ -371,3 +449,9 @@ func AddUint64RawMetric(r definition.RawMetrics, name string, valuePtr *uint64)
 		r[name] = *valuePtr
 	}
 }
+
+func addInt64RawMetric(r definition.RawMetrics, name string, valuePtr *int64) {
+	if valuePtr != nil {
+		r[name] = *valuePtr
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"io/ioutil"
 	"testing"
 
-	v1 "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
-
 	"github.com/newrelic/nri-kubernetes/src/definition"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,13 +16,15 @@ var responseMissingContainerName = `{ "pods": [ { "podRef": { "name": "newrelic-
 var responseMissingPodName = `{ "pods": [ { "podRef": { "namespace": "kube-system", "uid": "b5a9c98f-d34f-11e7-95fe-62d16fb0cc7f" }, "startTime": "2017-11-30T09:12:37Z", "containers": [ { "name": "kube-state-metrics", "startTime": "2017-11-30T09:12:51Z", "cpu": { "time": "2017-11-30T14:48:10Z", "usageNanoCores": 184087, "usageCoreNanoSeconds": 4284675040 }, "memory": { "time": "2017-11-30T14:48:10Z", "usageBytes": 22552576, "workingSetBytes": 15196160, "rssBytes": 7352320, "pageFaults": 4683, "majorPageFaults": 152 } } ], "network": { "time": "2017-11-30T14:48:12Z", "rxBytes": 15741653, "txBytes": 52463212, "rxErrors": 0,  "txErrors": 0 } } ] }`
 var responseMissingRxBytesForPod = `{ "pods": [ { "podRef": { "name": "newrelic-infra-monitoring-pjp0v", "namespace": "kube-system", "uid": "b5a9c98f-d34f-11e7-95fe-62d16fb0cc7f" }, "startTime": "2017-11-30T09:12:37Z", "containers": [ { "name": "kube-state-metrics", "startTime": "2017-11-30T09:12:51Z", "cpu": { "time": "2017-11-30T14:48:10Z", "usageNanoCores": 184087, "usageCoreNanoSeconds": 4284675040 }, "memory": { "time": "2017-11-30T14:48:10Z", "usageBytes": 22552576, "workingSetBytes": 15196160, "rssBytes": 7352320, "pageFaults": 4683, "majorPageFaults": 152 } } ], "network": { "time": "2017-11-30T14:48:12Z", "txBytes": 52463212, "rxErrors": 0,  "txErrors": 0 } } ] }`
 
+var podSampleWithPodLevelUsage = `{ "pods": [ { "podRef": { "name": "web-1", "namespace": "default" }, "containers": [], "cpu": { "usageNanoCores": 1393100, "usageCoreNanoSeconds": 20541236874 }, "memory": { "usageBytes": 54046720, "workingSetBytes": 53444608 }, "ephemeral-storage": { "availableBytes": 6911750144, "capacityBytes": 17293533184, "usedBytes": 7823360 } } ] }`
+var nodeSampleWithSystemContainers = `{ "node": { "nodeName": "fooNode", "systemContainers": [ { "name": "kubelet", "cpu": { "usageNanoCores": 87352681 }, "memory": { "usageBytes": 457232384, "workingSetBytes": 435048448 } }, { "name": "pods", "cpu": { "usageNanoCores": 95134719 }, "memory": { "usageBytes": 852029440, "workingSetBytes": 714407936 } }, { "name": "misc", "cpu": { "usageNanoCores": 1234 } } ], "rlimit": { "maxpid": 32768, "curproc": 412 } }, "pods": [] }`
 var nodeSampleMissingImageFs = `{ "node": { "nodeName": "fooNode", "startTime": "2018-01-22T06:52:15Z", "cpu": { "time": "2018-01-24T16:40:00Z", "usageNanoCores": 64124211, "usageCoreNanoSeconds": 353998913059080 }, "memory": { "time": "2018-01-24T16:40:00Z", "availableBytes": 502603776, "usageBytes": 687067136, "workingSetBytes": 540618752, "rssBytes": 150396928, "pageFaults": 3067606235, "majorPageFaults": 517653 }, "network": { "time": "2018-01-24T16:40:00Z", "rxBytes": 51419684038, "rxErrors": 0, "txBytes": 25630208577, "txErrors": 0, "interfaces": [ { "name": "ens5", "rxBytes": 51419684038, "rxErrors": 0, "txBytes": 25630208577, "txErrors": 0 }, { "name": "ip6tnl0", "rxBytes": 0, "rxErrors": 0, "txBytes": 0, "txErrors": 0 } ] }, "fs": { "time": "2018-01-24T16:40:00Z", "availableBytes": 92795400192, "capacityBytes": 128701009920, "usedBytes": 30305800192, "inodesFree": 32999604, "inodes": 33554432, "inodesUsed": 554828 }, "runtime": { } } }`
 
-func toSummary(response string) (v1.Summary, error) {
-	var summary = new(v1.Summary)
+func toSummary(response string) (Summary, error) {
+	var summary = new(Summary)
 	err := json.Unmarshal([]byte(response), summary)
 	if err != nil {
-		return v1.Summary{}, fmt.Errorf("Error unmarshaling the response body. Got error: %v", err.Error())
+		return Summary{}, fmt.Errorf("Error unmarshaling the response body. Got error: %v", err.Error())
 	}
 	return *summary, nil
 }
@@ -511,8 +511,27 @@ func TestGroupStatsSummary_IncompleteStatsSummaryMessage_MissingNodeData_NoRxByt
 	assert.Equal(t, expectedRawData, rawData)
 }
 
+func TestGroupStatsSummary_SystemContainersAndRlimit(t *testing.T) {
+	summary, err := toSummary(nodeSampleWithSystemContainers)
+	require.NoError(t, err)
+
+	rawData, errs := GroupStatsSummary(summary, true)
+	assert.Empty(t, errs)
+	assert.Equal(t, definition.RawMetrics{
+		"nodeName":                        "fooNode",
+		"kubeletUsageNanoCores":           uint64(87352681),
+		"kubeletMemoryUsageBytes":         uint64(457232384),
+		"kubeletMemoryWorkingSetBytes":    uint64(435048448),
+		"podsCgroupUsageNanoCores":        uint64(95134719),
+		"podsCgroupMemoryUsageBytes":      uint64(852029440),
+		"podsCgroupMemoryWorkingSetBytes": uint64(714407936),
+		"maxPid":                          int64(32768),
+		"runningProcesses":                int64(412),
+	}, rawData["node"]["fooNode"])
+}
+
 func TestGroupStatsSummary_EmptyStatsSummaryMessage(t *testing.T) {
-	var summary = new(v1.Summary)
+	var summary = new(Summary)
 
 	rawData, errs := GroupStatsSummary(*summary, true)
 
@@ -587,6 +606,26 @@ func TestFetchNodeStats_MissingImageFs(t *testing.T) {
 	assert.Equal(t, expectedRawData, rawData)
 }
 
+func TestFetchPodStats_PodLevelUsage(t *testing.T) {
+	expectedRawData := definition.RawMetrics{
+		"podName":   "web-1",
+		"namespace": "default",
+		// CPU and memory of the pod cgroup
+		"usageNanoCores":        uint64(1393100),
+		"memoryUsageBytes":      uint64(54046720),
+		"memoryWorkingSetBytes": uint64(53444608),
+		// Ephemeral storage
+		"ephemeralStorageUsedBytes": uint64(7823360),
+	}
+	s, err := toSummary(podSampleWithPodLevelUsage)
+	assert.NoError(t, err)
+
+	rawData, ID, err := fetchPodStats(s.Pods[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "default_web-1", ID)
+	assert.Equal(t, expectedRawData, rawData)
+}
+
 // ------------ FromRawGroupsEntityIDGenerator ------------
 func TestFromRawGroupsEntityIDGenerator_CorrectValue(t *testing.T) {
 	raw := definition.RawGroups{
@@ -3,8 +3,10 @@ package metric
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/pattern"
 )
 
 // FromRawWithFallbackToDefaultInterface fetches network metrics from the raw
@@ -91,3 +93,75 @@ func getMetricFromDefaultInterface(defaultInterface, metricKey string, m definit
 	}
 	return nil, errors.New("default interface metrics not found")
 }
+
+// OneMetricPerInterface fetches the given network metric of every interface of
+// the entity that the filter selects, named net.<interface>.<name>. The dots in
+// the interface names, like the ones of VLAN interfaces as eth0.100, are
+// replaced by underscores, so they are not taken as separators of the name.
+func OneMetricPerInterface(metricKey, name string, filter *InterfaceFilter) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		e, ok := groups[groupLabel][entityID]
+		if !ok {
+			return nil, errors.New("entity not found")
+		}
+		interfaces, ok := e["interfaces"].(map[string]definition.RawMetrics)
+		if !ok {
+			return nil, errors.New("interfaces metrics not found")
+		}
+
+		values := make(definition.FetchedValues)
+		for interfaceName, i := range interfaces {
+			if !filter.Selects(interfaceName) {
+				continue
+			}
+			if value, ok := i[metricKey]; ok {
+				values[fmt.Sprintf("net.%s.%s", strings.Replace(interfaceName, ".", "_", -1), name)] = value
+			}
+		}
+		return values, nil
+	}
+}
+
+// InterfaceFilter selects network interfaces by name. Patterns are globs,
+// where '*' matches any sequence of characters and '?' any single character,
+// or regular expressions when enclosed in slashes, like /^eth[0-9]+$/.
+// An interface is selected when it matches any of the include patterns, or
+// there are none, and it does not match any of the exclude patterns.
+type InterfaceFilter struct {
+	include pattern.List
+	exclude pattern.List
+}
+
+// NewInterfaceFilter creates an InterfaceFilter. It fails if any of the
+// patterns is not valid.
+func NewInterfaceFilter(include, exclude []string) (*InterfaceFilter, error) {
+	var f InterfaceFilter
+	var err error
+	if f.include, err = compileInterfacePatterns(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compileInterfacePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func compileInterfacePatterns(patterns []string) (pattern.List, error) {
+	compiled, err := pattern.CompileList(patterns)
+	if err == pattern.ErrEmpty {
+		return nil, errors.New("interface patterns must not be empty")
+	}
+	return compiled, err
+}
+
+// Selects returns whether the interface with the given name is selected. A nil
+// InterfaceFilter selects every interface.
+func (f *InterfaceFilter) Selects(name string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.include) > 0 && !f.include.MatchesAny(name) {
+		return false
+	}
+	return !f.exclude.MatchesAny(name)
+}
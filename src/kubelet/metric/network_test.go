@@ -61,3 +61,109 @@ func TestFromRawWithFallbackToDefaultInterface_UsesFallback(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, uint64(51419684038), value)
 }
+
+func TestOneMetricPerInterface(t *testing.T) {
+	raw := definition.RawGroups{
+		"pod": {
+			"default_multus": definition.RawMetrics{
+				"interfaces": map[string]definition.RawMetrics{
+					"eth0":     {"rxBytes": uint64(100), "txBytes": uint64(200)},
+					"net1":     {"rxBytes": uint64(300), "txBytes": uint64(400)},
+					"lo":       {"rxBytes": uint64(500), "txBytes": uint64(600)},
+					"eth0.100": {"rxBytes": uint64(700), "txBytes": uint64(800)},
+				},
+			},
+		},
+	}
+	filter, err := NewInterfaceFilter(nil, []string{"lo"})
+	require.NoError(t, err)
+
+	value, err := OneMetricPerInterface("rxBytes", "rxBytesPerSecond", filter)("pod", "default_multus", raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, definition.FetchedValues{
+		"net.eth0.rxBytesPerSecond":     uint64(100),
+		"net.net1.rxBytesPerSecond":     uint64(300),
+		"net.eth0_100.rxBytesPerSecond": uint64(700),
+	}, value)
+}
+
+func TestOneMetricPerInterface_NoInterfaces(t *testing.T) {
+	raw := definition.RawGroups{
+		"node": {
+			"fooNode": definition.RawMetrics{"rxBytes": uint64(100)},
+		},
+	}
+
+	_, err := OneMetricPerInterface("rxBytes", "rxBytesPerSecond", nil)("node", "fooNode", raw)
+	assert.EqualError(t, err, "interfaces metrics not found")
+
+	_, err = OneMetricPerInterface("rxBytes", "rxBytesPerSecond", nil)("node", "barNode", raw)
+	assert.EqualError(t, err, "entity not found")
+}
+
+func TestInterfaceFilter_Selects(t *testing.T) {
+	testCases := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		selected []string
+		rejected []string
+	}{
+		{
+			name:     "no patterns",
+			selected: []string{"eth0", "veth1234", "lo"},
+		},
+		{
+			name:     "include globs",
+			include:  []string{"eth*", "bond?"},
+			selected: []string{"eth0", "eth12", "bond0"},
+			rejected: []string{"veth1234", "bond10", "lo"},
+		},
+		{
+			name:     "exclude glob",
+			exclude:  []string{"veth*"},
+			selected: []string{"eth0", "cni0"},
+			rejected: []string{"veth1234"},
+		},
+		{
+			name:     "regular expressions",
+			include:  []string{"/^(eth|net)[0-9]+$/"},
+			exclude:  []string{"/9$/"},
+			selected: []string{"eth0", "net1"},
+			rejected: []string{"eth9", "veth0", "eth0.100"},
+		},
+		{
+			name:     "glob metacharacters are literal",
+			include:  []string{"eth0.100"},
+			selected: []string{"eth0.100"},
+			rejected: []string{"eth0x100"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewInterfaceFilter(tc.include, tc.exclude)
+			require.NoError(t, err)
+			for _, name := range tc.selected {
+				assert.True(t, f.Selects(name), name)
+			}
+			for _, name := range tc.rejected {
+				assert.False(t, f.Selects(name), name)
+			}
+		})
+	}
+}
+
+func TestInterfaceFilter_NilSelectsEverything(t *testing.T) {
+	var f *InterfaceFilter
+	assert.True(t, f.Selects("veth1234"))
+}
+
+func TestNewInterfaceFilter_InvalidPatterns(t *testing.T) {
+	_, err := NewInterfaceFilter([]string{""}, nil)
+	assert.EqualError(t, err, "interface patterns must not be empty")
+
+	_, err = NewInterfaceFilter(nil, []string{"/veth(/"})
+	assert.EqualError(t, err, "invalid regular expression \"/veth(/\": error parsing regexp: missing closing ): `veth(`")
+}
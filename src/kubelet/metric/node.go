@@ -0,0 +1,86 @@
+package metric
+
+import (
+	"fmt"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	v1 "k8s.io/api/core/v1"
+)
+
+// OneMetricPerCondition transforms the conditions of a node to FetchedValues
+// type, which will be converted later to one metric per condition, like
+// `condition.Ready` or `condition.MemoryPressure`.
+//
+// The value is 1 when the condition status is True, 0 when it is False and
+// -1 when it is Unknown.
+func OneMetricPerCondition(rawConditions definition.FetchedValue) (definition.FetchedValue, error) {
+	conditions, ok := rawConditions.([]v1.NodeCondition)
+	if !ok {
+		return rawConditions, fmt.Errorf("error on creating kubelet condition metrics")
+	}
+
+	modified := make(definition.FetchedValues, len(conditions))
+	for _, c := range conditions {
+		var status int
+		switch c.Status {
+		case v1.ConditionTrue:
+			status = 1
+		case v1.ConditionFalse:
+			status = 0
+		default:
+			status = -1
+		}
+		modified[fmt.Sprintf("condition.%s", c.Type)] = status
+	}
+
+	return modified, nil
+}
+
+// OneMetricPerConditionTransition transforms the conditions of a node to
+// FetchedValues type, which will be converted later to one metric per
+// condition with the Unix timestamp of its last status change, like
+// `conditionLastTransitionTime.Ready`.
+func OneMetricPerConditionTransition(rawConditions definition.FetchedValue) (definition.FetchedValue, error) {
+	conditions, ok := rawConditions.([]v1.NodeCondition)
+	if !ok {
+		return rawConditions, fmt.Errorf("error on creating kubelet condition transition metrics")
+	}
+
+	modified := make(definition.FetchedValues, len(conditions))
+	for _, c := range conditions {
+		if c.LastTransitionTime.IsZero() {
+			continue
+		}
+		modified[fmt.Sprintf("conditionLastTransitionTime.%s", c.Type)] = c.LastTransitionTime.Unix()
+	}
+
+	return modified, nil
+}
+
+// OneAttributePerTaint transforms the taints of a node to FetchedValues
+// type, which will be converted later to one attribute per taint key, like
+// `taint.dedicated`. The value of the attribute is the taint value and its
+// effect, separated by a colon, or just the effect if the taint has no value.
+// Several effects of the same key are separated by commas.
+func OneAttributePerTaint(rawTaints definition.FetchedValue) (definition.FetchedValue, error) {
+	taints, ok := rawTaints.([]v1.Taint)
+	if !ok {
+		return rawTaints, fmt.Errorf("error on creating kubelet taint attributes")
+	}
+
+	modified := make(definition.FetchedValues, len(taints))
+	for _, t := range taints {
+		value := string(t.Effect)
+		if t.Value != "" {
+			value = fmt.Sprintf("%s:%s", t.Value, t.Effect)
+		}
+		name := fmt.Sprintf("taint.%s", t.Key)
+		if previous, ok := modified[name]; ok {
+			// The same key can taint the node with several effects.
+			value = fmt.Sprintf("%s,%s", previous, value)
+		}
+		modified[name] = value
+	}
+
+	return modified, nil
+}
@@ -0,0 +1,57 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+)
+
+var nodeConditions = []v1.NodeCondition{
+	{Type: v1.NodeReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+	{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Unix(1602648000, 0))},
+	{Type: v1.NodeDiskPressure, Status: v1.ConditionUnknown},
+}
+
+func TestOneMetricPerCondition(t *testing.T) {
+	v, err := OneMetricPerCondition(nodeConditions)
+	assert.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{
+		"condition.Ready":          1,
+		"condition.MemoryPressure": 0,
+		"condition.DiskPressure":   -1,
+	}, v)
+
+	_, err = OneMetricPerCondition(map[string]string{})
+	assert.Error(t, err)
+}
+
+func TestOneMetricPerConditionTransition(t *testing.T) {
+	v, err := OneMetricPerConditionTransition(nodeConditions)
+	assert.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{
+		"conditionLastTransitionTime.Ready":          int64(1602648300),
+		"conditionLastTransitionTime.MemoryPressure": int64(1602648000),
+	}, v)
+}
+
+func TestOneAttributePerTaint(t *testing.T) {
+	v, err := OneAttributePerTaint([]v1.Taint{
+		{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoExecute},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{
+		"taint.node-role.kubernetes.io/master": "NoSchedule",
+		"taint.dedicated":                      "gpu:NoSchedule,gpu:NoExecute",
+	}, v)
+
+	v, err = OneAttributePerTaint([]v1.Taint(nil))
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+}
@@ -1,7 +1,6 @@
 package metric
 
 import (
-	"errors"
 	"net/http"
 	"strings"
 	"sync"
@@ -20,6 +19,7 @@ import (
 	"github.com/newrelic/nri-kubernetes/src/data"
 	"github.com/newrelic/nri-kubernetes/src/definition"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // KubeletPodsPath is the path where kubelet serves information about pods.
@@ -29,8 +29,10 @@ const KubeletPodsPath = "/pods"
 // running on the node. It contains an in-memory cache to store the
 // results and avoid querying the kubelet multiple times in the same
 // integration execution.
+// When the integration runs as a long-lived process, Reset must be
+// called before each execution so the pods are queried again.
 type PodsFetcher struct {
-	once                   sync.Once
+	lock                   sync.Mutex
 	cached                 bool
 	cachedPods             definition.RawGroups
 	fetchError             error
@@ -69,6 +71,12 @@ func doPodsFetch(logger *logrus.Logger, c client.HTTPClient, enableStaticPodsSta
 		return nil, fmt.Errorf("error decoding response from kubelet %s path. %s", KubeletPodsPath, err)
 	}
 
+	var ephemeral ephemeralPodList
+	err = json.Unmarshal(rawPods, &ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding response from kubelet %s path. %s", KubeletPodsPath, err)
+	}
+
 	raw := definition.RawGroups{
 		"pod":       make(map[string]definition.RawMetrics),
 		"container": make(map[string]definition.RawMetrics),
@@ -80,7 +88,7 @@ func doPodsFetch(logger *logrus.Logger, c client.HTTPClient, enableStaticPodsSta
 	var missingNodeIPPodIDs []string
 	var nodeIP string
 
-	for _, p := range pods.Items {
+	for i, p := range pods.Items {
 		id := podID(&p)
 		raw["pod"][id] = fetchPodData(logger, &p, enableStaticPodsStatus)
 
@@ -94,7 +102,7 @@ func doPodsFetch(logger *logrus.Logger, c client.HTTPClient, enableStaticPodsSta
 			raw["pod"][id]["nodeIP"] = nodeIP
 		}
 
-		containers := fetchContainersData(logger, &p, enableStaticPodsStatus)
+		containers := fetchContainersData(logger, &p, ephemeral.Items[i], enableStaticPodsStatus)
 		for id, c := range containers {
 			raw["container"][id] = c
 
@@ -126,13 +134,26 @@ func doPodsFetch(logger *logrus.Logger, c client.HTTPClient, enableStaticPodsSta
 // the cache is maintain per integration execution.
 func (f *PodsFetcher) FetchFuncWithCache() data.FetchFunc {
 	return func() (definition.RawGroups, error) {
-		f.once.Do(func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if !f.cached {
 			f.cachedPods, f.fetchError = doPodsFetch(f.logger, f.client, f.enableStaticPodsStatus)
-		})
+			f.cached = true
+		}
 		return f.cachedPods, f.fetchError
 	}
 }
 
+// Reset invalidates the in-memory cache, so the next call to the
+// FetchFunc queries the kubelet again.
+func (f *PodsFetcher) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.cached = false
+	f.cachedPods = nil
+	f.fetchError = nil
+}
+
 // NewPodsFetcher returns a new PodsFetcher.
 func NewPodsFetcher(l *logrus.Logger, c client.HTTPClient, enableStaticPodsStatus bool) *PodsFetcher {
 	return &PodsFetcher{
@@ -142,21 +163,71 @@ func NewPodsFetcher(l *logrus.Logger, c client.HTTPClient, enableStaticPodsStatu
 	}
 }
 
-func fetchContainersData(logger *logrus.Logger, pod *v1.Pod, enableStaticPodsStatus bool) map[string]definition.RawMetrics {
+// Types of the containers of a pod, reported as the containerType attribute.
+const (
+	regularContainer   = "regular"
+	initContainer      = "init"
+	ephemeralContainer = "ephemeral"
+)
+
+// ephemeralPodList decodes the ephemeral containers of the pods, which are missing from the v1.Pod type of the
+// Kubernetes API version the integration is built with. Their specs have the same fields as the ones of the
+// regular containers, like the name, the image and the resources.
+type ephemeralPodList struct {
+	Items []podEphemeralContainers `json:"items"`
+}
+
+type podEphemeralContainers struct {
+	Spec struct {
+		EphemeralContainers []v1.Container `json:"ephemeralContainers"`
+	} `json:"spec"`
+	Status struct {
+		EphemeralContainerStatuses []v1.ContainerStatus `json:"ephemeralContainerStatuses"`
+	} `json:"status"`
+}
+
+// typedContainer is a container of a pod along with its type.
+type typedContainer struct {
+	v1.Container
+	containerType string
+}
+
+// podContainers returns the regular, init and ephemeral containers of a pod.
+func podContainers(pod *v1.Pod, ephemeral podEphemeralContainers) []typedContainer {
+	var containers []typedContainer
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, typedContainer{Container: c, containerType: regularContainer})
+	}
+	for _, c := range pod.Spec.InitContainers {
+		containers = append(containers, typedContainer{Container: c, containerType: initContainer})
+	}
+	for _, c := range ephemeral.Spec.EphemeralContainers {
+		containers = append(containers, typedContainer{Container: c, containerType: ephemeralContainer})
+	}
+	return containers
+}
+
+func fetchContainersData(
+	logger *logrus.Logger,
+	pod *v1.Pod,
+	ephemeral podEphemeralContainers,
+	enableStaticPodsStatus bool,
+) map[string]definition.RawMetrics {
 	statuses := make(map[string]definition.RawMetrics)
 	if enableStaticPodsStatus || !isStaticPod(pod) {
-		fillContainerStatuses(pod, statuses)
+		fillContainerStatuses(pod, ephemeral, statuses)
 	} else {
 		logger.Debugf("static pod found. Skip fetching containers status for pod %q", podID(pod))
 	}
 
 	metrics := make(map[string]definition.RawMetrics)
 
-	for _, c := range pod.Spec.Containers {
+	for _, c := range podContainers(pod, ephemeral) {
 		id := containerID(pod, c.Name)
 		metrics[id] = definition.RawMetrics{
 			"containerName":  c.Name,
 			"containerImage": c.Image,
+			"containerType":  c.containerType,
 			"namespace":      pod.GetObjectMeta().GetNamespace(),
 			"podName":        pod.GetObjectMeta().GetName(),
 			"nodeName":       pod.Spec.NodeName,
@@ -188,6 +259,10 @@ func fetchContainersData(logger *logrus.Logger, pod *v1.Pod, enableStaticPodsSta
 			}
 		}
 
+		for k, v := range podOwnerMetrics(pod) {
+			metrics[id][k] = v
+		}
+
 		// merging status data
 		for k, v := range statuses[id] {
 			metrics[id][k] = v
@@ -202,17 +277,33 @@ func fetchContainersData(logger *logrus.Logger, pod *v1.Pod, enableStaticPodsSta
 	return metrics
 }
 
-func fillContainerStatuses(pod *v1.Pod, dest map[string]definition.RawMetrics) {
-	for _, c := range pod.Status.ContainerStatuses {
+// oomKilledReason is the reason of the termination of the containers killed for running out of memory.
+const oomKilledReason = "OOMKilled"
+
+func fillContainerStatuses(pod *v1.Pod, ephemeral podEphemeralContainers, dest map[string]definition.RawMetrics) {
+	var statuses []v1.ContainerStatus
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, ephemeral.Status.EphemeralContainerStatuses...)
+
+	for _, c := range statuses {
 		name := c.Name
 		id := containerID(pod, name)
 
 		dest[id] = make(definition.RawMetrics)
 
+		if c.ContainerID != "" {
+			containerID, runtime := containerIDFromStatus(c.ContainerID)
+			dest[id]["containerID"] = containerID
+			if runtime != "" {
+				dest[id]["containerRuntime"] = runtime
+			}
+		}
+
 		switch {
 		case c.State.Running != nil:
 			dest[id]["status"] = "Running"
-			dest[id]["startedAt"] = c.State.Running.StartedAt.Time.In(time.UTC) // TODO WE DO NOT REPORT THAT METRIC
+			dest[id]["startedAt"] = c.State.Running.StartedAt.Time.In(time.UTC)
 			dest[id]["restartCount"] = c.RestartCount
 			dest[id]["isReady"] = c.Ready
 		case c.State.Waiting != nil:
@@ -223,13 +314,43 @@ func fillContainerStatuses(pod *v1.Pod, dest map[string]definition.RawMetrics) {
 			dest[id]["status"] = "Terminated"
 			dest[id]["reason"] = c.State.Terminated.Reason
 			dest[id]["restartCount"] = c.RestartCount
-			dest[id]["startedAt"] = c.State.Terminated.StartedAt.Time.In(time.UTC) // TODO WE DO NOT REPORT THAT METRIC
+			dest[id]["startedAt"] = c.State.Terminated.StartedAt.Time.In(time.UTC)
+			dest[id]["exitCode"] = c.State.Terminated.ExitCode
+			if c.State.Terminated.Signal != 0 {
+				dest[id]["signal"] = c.State.Terminated.Signal
+			}
+			if !c.State.Terminated.FinishedAt.IsZero() {
+				dest[id]["finishedAt"] = c.State.Terminated.FinishedAt.Time.In(time.UTC)
+			}
 		default:
 			dest[id]["status"] = "Unknown"
 		}
+
+		// The previous termination explains why a container is restarting, like in a CrashLoopBackOff
+		if last := c.LastTerminationState.Terminated; last != nil {
+			dest[id]["lastTerminatedReason"] = last.Reason
+			dest[id]["lastTerminatedExitCode"] = last.ExitCode
+			if last.Signal != 0 {
+				dest[id]["lastTerminatedSignal"] = last.Signal
+			}
+			if !last.FinishedAt.IsZero() {
+				dest[id]["lastTerminatedFinishedAt"] = last.FinishedAt.Time.In(time.UTC)
+			}
+		}
+
+		dest[id]["isOOMKilled"] = isOOMKilled(c)
 	}
 }
 
+// isOOMKilled returns whether the container is terminated because it was killed for running out of memory. The
+// previous instance of a restarted container is not considered, as it would be reported until the next
+// termination: its OOM kills are told by the lastTerminatedReason, along with restartCountDelta and
+// lastTerminatedFinishedAt, which change on every new one.
+func isOOMKilled(c v1.ContainerStatus) bool {
+	t := c.State.Terminated
+	return t != nil && t.Reason == oomKilledReason
+}
+
 // Static pods are created by Kubelet on start time reading from static yaml files.
 // They contain the annotation: `"kubernetes.io/config.source": "file"`
 // Kubelet creates Mirror Pods in the K8s API that represents each static pod. They have a different pod ID than their Kubelet internal ones.
@@ -291,6 +412,10 @@ func fetchPodData(logger *logrus.Logger, pod *v1.Pod, staticPodsStatusSupport bo
 		}
 	}
 
+	for k, v := range podOwnerMetrics(pod) {
+		metrics[k] = v
+	}
+
 	if pod.Status.Reason != "" {
 		metrics["reason"] = pod.Status.Reason
 	}
@@ -304,6 +429,11 @@ func fetchPodData(logger *logrus.Logger, pod *v1.Pod, staticPodsStatusSupport bo
 		metrics["labels"] = labels
 	}
 
+	annotations := podAnnotations(pod)
+	if len(annotations) > 0 {
+		metrics["annotations"] = annotations
+	}
+
 	return metrics
 }
 
@@ -340,6 +470,58 @@ func podLabels(p *v1.Pod) map[string]string {
 	return labels
 }
 
+func podAnnotations(p *v1.Pod) map[string]string {
+	annotations := make(map[string]string, len(p.GetObjectMeta().GetAnnotations()))
+	for k, v := range p.GetObjectMeta().GetAnnotations() {
+		annotations[k] = v
+	}
+
+	return annotations
+}
+
+// WorkloadNameMetric returns the name of the metric with the name of the workload of the given kind a pod belongs
+// to, or an empty string if pods are not attributed to workloads of that kind.
+func WorkloadNameMetric(kind string) string {
+	switch kind {
+	case client.KindDeployment:
+		return "deploymentName"
+	case client.KindStatefulSet:
+		return "statefulsetName"
+	case client.KindDaemonSet:
+		return "daemonsetName"
+	case client.KindJob:
+		return "jobName"
+	case client.KindCronJob:
+		return "cronjobName"
+	}
+	return ""
+}
+
+// podOwnerMetrics returns the controller owning the given pod, which is also its top owner until the rest of the
+// owner chain is resolved through the API server, and the name of the workload it belongs to, if the controller is
+// the workload itself.
+func podOwnerMetrics(pod *v1.Pod) definition.RawMetrics {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		refs := pod.GetOwnerReferences()
+		if len(refs) == 0 {
+			return nil
+		}
+		ref = &refs[0]
+	}
+
+	metrics := definition.RawMetrics{
+		"ownerKind":    ref.Kind,
+		"ownerName":    ref.Name,
+		"topOwnerKind": ref.Kind,
+		"topOwnerName": ref.Name,
+	}
+	if m := WorkloadNameMetric(ref.Kind); m != "" {
+		metrics[m] = ref.Name
+	}
+	return metrics
+}
+
 func deploymentNameBasedOnCreator(creatorKind, creatorName string) string {
 	var deploymentName string
 	if creatorKind == "ReplicaSet" {
@@ -357,14 +539,25 @@ func replicasetNameToDeploymentName(rsName string) string {
 // which will be converted later to one metric per label.
 // It also prefix the labels with 'label.'
 func OneMetricPerLabel(rawLabels definition.FetchedValue) (definition.FetchedValue, error) {
-	labels, ok := rawLabels.(map[string]string)
+	return oneMetricPerKey(rawLabels, "label")
+}
+
+// OneMetricPerAnnotation transforms a map of annotations to FetchedValues type,
+// which will be converted later to one metric per annotation.
+// It also prefix the annotations with 'annotation.'
+func OneMetricPerAnnotation(rawAnnotations definition.FetchedValue) (definition.FetchedValue, error) {
+	return oneMetricPerKey(rawAnnotations, "annotation")
+}
+
+func oneMetricPerKey(raw definition.FetchedValue, prefix string) (definition.FetchedValue, error) {
+	values, ok := raw.(map[string]string)
 	if !ok {
-		return rawLabels, errors.New("error on creating kubelet label metrics")
+		return raw, fmt.Errorf("error on creating kubelet %s metrics", prefix)
 	}
 
-	modified := make(definition.FetchedValues, len(labels))
-	for k, v := range labels {
-		modified[fmt.Sprintf("label.%v", k)] = v
+	modified := make(definition.FetchedValues, len(values))
+	for k, v := range values {
+		modified[fmt.Sprintf("%s.%v", prefix, k)] = v
 	}
 
 	return modified, nil
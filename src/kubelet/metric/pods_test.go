@@ -10,11 +10,14 @@ import (
 	"net/http/httptest"
 
 	"io"
+	"time"
 
 	"github.com/newrelic/nri-kubernetes/src/definition"
 	"github.com/newrelic/nri-kubernetes/src/kubelet/metric/testdata"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type testClient struct {
@@ -84,6 +87,29 @@ func TestFetchFuncCache(t *testing.T) {
 	assert.Equal(t, testdata.ExpectedRawData, g)
 }
 
+func TestFetchFuncCache_Reset(t *testing.T) {
+	// Given an HTTPClient
+	c := testClient{
+		handler: servePayload,
+	}
+
+	// When calling the fetch pods func the results are cached
+	f := NewPodsFetcher(logrus.StandardLogger(), &c, true)
+	fetch := f.FetchFuncWithCache()
+	_, err := fetch()
+	assert.NoError(t, err)
+
+	// After resetting the cache, the kubelet is queried again
+	f.client = &testClient{
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+	}
+	f.Reset()
+	_, err = fetch()
+	assert.EqualError(t, err, "error calling kubelet /pods path. Status code 500")
+}
+
 func TestNewPodsFetchFunc_StatusNoOK(t *testing.T) {
 	assertError(
 		t,
@@ -132,6 +158,131 @@ func TestOneMetricPerLabel(t *testing.T) {
 	assert.Equal(t, expected, v)
 }
 
+func TestFillContainerStatuses_Terminations(t *testing.T) {
+	startedAt := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(time.Hour)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+			{
+				Name:         "crashing",
+				RestartCount: 3,
+				State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					Reason:     "OOMKilled",
+					ExitCode:   137,
+					Signal:     9,
+					FinishedAt: metav1.NewTime(finishedAt),
+				}},
+			},
+			{
+				Name:         "oom",
+				RestartCount: 0,
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					Reason:     "OOMKilled",
+					ExitCode:   137,
+					StartedAt:  metav1.NewTime(startedAt),
+					FinishedAt: metav1.NewTime(finishedAt),
+				}},
+			},
+			{
+				Name:         "completed",
+				RestartCount: 0,
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					Reason:     "Completed",
+					StartedAt:  metav1.NewTime(startedAt),
+					FinishedAt: metav1.NewTime(finishedAt),
+				}},
+			},
+		}},
+	}
+
+	statuses := make(map[string]definition.RawMetrics)
+	fillContainerStatuses(pod, podEphemeralContainers{}, statuses)
+
+	assert.Equal(t, definition.RawMetrics{
+		"status":                   "Waiting",
+		"reason":                   "CrashLoopBackOff",
+		"restartCount":             int32(3),
+		"lastTerminatedReason":     "OOMKilled",
+		"lastTerminatedExitCode":   int32(137),
+		"lastTerminatedSignal":     int32(9),
+		"lastTerminatedFinishedAt": finishedAt,
+		"isOOMKilled":              false,
+	}, statuses["default_web-1_crashing"])
+
+	assert.Equal(t, definition.RawMetrics{
+		"status":       "Terminated",
+		"reason":       "OOMKilled",
+		"restartCount": int32(0),
+		"startedAt":    startedAt,
+		"finishedAt":   finishedAt,
+		"exitCode":     int32(137),
+		"isOOMKilled":  true,
+	}, statuses["default_web-1_oom"])
+
+	assert.Equal(t, definition.RawMetrics{
+		"status":       "Terminated",
+		"reason":       "Completed",
+		"restartCount": int32(0),
+		"startedAt":    startedAt,
+		"finishedAt":   finishedAt,
+		"exitCode":     int32(0),
+		"isOOMKilled":  false,
+	}, statuses["default_web-1_completed"])
+}
+
+const initAndEphemeralContainersPayload = `{"items": [{
+	"metadata": {"name": "web-1", "namespace": "default"},
+	"spec": {
+		"nodeName": "node-1",
+		"initContainers": [{"name": "migrate", "image": "migrate:1.0", "resources": {"requests": {"cpu": "100m"}}}],
+		"containers": [{"name": "web", "image": "web:1.0"}],
+		"ephemeralContainers": [{"name": "debugger", "image": "busybox", "targetContainerName": "web"}]
+	},
+	"status": {
+		"phase": "Pending",
+		"hostIP": "10.0.0.1",
+		"initContainerStatuses": [{
+			"name": "migrate",
+			"restartCount": 4,
+			"state": {"waiting": {"reason": "CrashLoopBackOff"}},
+			"lastState": {"terminated": {"exitCode": 1, "reason": "Error"}}
+		}],
+		"containerStatuses": [{"name": "web", "state": {"waiting": {"reason": "PodInitializing"}}}],
+		"ephemeralContainerStatuses": [{"name": "debugger", "state": {"running": {"startedAt": "2020-05-04T10:00:00Z"}}}]
+	}
+}]}`
+
+func TestFetchFunc_InitAndEphemeralContainers(t *testing.T) {
+	c := testClient{
+		handler: func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, initAndEphemeralContainersPayload)
+		},
+	}
+
+	g, err := NewPodsFetcher(logrus.StandardLogger(), &c, true).FetchFuncWithCache()()
+	assert.NoError(t, err)
+	assert.Len(t, g["container"], 3)
+
+	assert.Equal(t, "regular", g["container"]["default_web-1_web"]["containerType"])
+	assert.Equal(t, "PodInitializing", g["container"]["default_web-1_web"]["reason"])
+
+	migrate := g["container"]["default_web-1_migrate"]
+	assert.Equal(t, "init", migrate["containerType"])
+	assert.Equal(t, "migrate:1.0", migrate["containerImage"])
+	assert.Equal(t, int64(100), migrate["cpuRequestedCores"])
+	assert.Equal(t, "CrashLoopBackOff", migrate["reason"])
+	assert.Equal(t, int32(4), migrate["restartCount"])
+	assert.Equal(t, "Error", migrate["lastTerminatedReason"])
+
+	debugger := g["container"]["default_web-1_debugger"]
+	assert.Equal(t, "ephemeral", debugger["containerType"])
+	assert.Equal(t, "busybox", debugger["containerImage"])
+	assert.Equal(t, "Running", debugger["status"])
+	assert.Equal(t, "10.0.0.1", debugger["nodeIP"])
+}
+
 func assertError(t *testing.T, errorMessage string, handler http.HandlerFunc) {
 	c := testClient{
 		handler: handler,
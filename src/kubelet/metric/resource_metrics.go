@@ -0,0 +1,163 @@
+package metric
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+	"github.com/newrelic/nri-kubernetes/src/storage"
+)
+
+// ResourceMetricsPath is the path where kubelet serves the CPU and memory usage of the node, pods and containers.
+// It is cheaper to produce than the summary served in StatsSummaryPath.
+const ResourceMetricsPath = "/metrics/resource"
+
+// cpuUsageStorageKey is the storage key holding the CPU usage reported in the previous run, which is needed to turn
+// the cumulative CPU usage exposed by ResourceMetricsPath into the cores in use.
+const cpuUsageStorageKey = "kubelet_resource_cpu_usage"
+
+var now = time.Now
+
+var resourceMetricsQueries = []prometheus.Query{
+	{MetricName: "node_cpu_usage_seconds_total"},
+	{MetricName: "node_memory_working_set_bytes"},
+	{MetricName: "pod_cpu_usage_seconds_total"},
+	{MetricName: "pod_memory_working_set_bytes"},
+	{MetricName: "container_cpu_usage_seconds_total"},
+	{MetricName: "container_memory_working_set_bytes"},
+	{MetricName: "container_start_time_seconds"},
+}
+
+// cpuUsage is a sample of the cumulative CPU usage of an entity.
+type cpuUsage struct {
+	Seconds   float64
+	Timestamp int64 // Unix nanoseconds
+}
+
+// ResourceMetricsFetchFunc creates a FetchFunc that builds the node, pod and container groups with the CPU usage,
+// memory working set and container start time that kubelet serves in ResourceMetricsPath, under the same raw
+// metric names GroupStatsSummary uses. The CPU usage is exposed as a counter, so the cores in use are calculated
+// from the usage stored in the previous run, and are not reported on the first one.
+func ResourceMetricsFetchFunc(c client.HTTPClient, nodeName string, s storage.Storage) data.FetchFunc {
+	return func() (definition.RawGroups, error) {
+		families, err := prometheus.Do(c, ResourceMetricsPath, resourceMetricsQueries)
+		if err != nil {
+			return nil, fmt.Errorf("error requesting kubelet resource metrics endpoint. %s", err)
+		}
+
+		g := definition.RawGroups{
+			"pod":       {},
+			"container": {},
+			"node": {
+				nodeName: definition.RawMetrics{"nodeName": nodeName},
+			},
+		}
+		var errs []error
+		cpu := make(map[string]cpuUsage)
+		ts := now().UnixNano()
+
+		for _, f := range families {
+			for _, m := range f.Metrics {
+				groupLabel, entityID, rawMetrics, err := resourceEntity(g, nodeName, m.Labels)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %v", f.Name, err))
+					continue
+				}
+				value, ok := floatValue(m.Value)
+				if !ok {
+					errs = append(errs, fmt.Errorf("%s: unexpected value type %T", f.Name, m.Value))
+					continue
+				}
+
+				switch f.Name {
+				case "node_cpu_usage_seconds_total":
+					rawMetrics["usageCoreNanoSeconds"] = uint64(value * 1e9)
+					cpu[groupLabel+"/"+entityID] = cpuUsage{Seconds: value, Timestamp: ts}
+				case "pod_cpu_usage_seconds_total", "container_cpu_usage_seconds_total":
+					cpu[groupLabel+"/"+entityID] = cpuUsage{Seconds: value, Timestamp: ts}
+				case "node_memory_working_set_bytes", "pod_memory_working_set_bytes":
+					rawMetrics["memoryWorkingSetBytes"] = uint64(value)
+				case "container_memory_working_set_bytes":
+					rawMetrics["workingSetBytes"] = uint64(value)
+				case "container_start_time_seconds":
+					rawMetrics["startedAt"] = time.Unix(int64(value), 0).In(time.UTC)
+				}
+			}
+		}
+
+		var previous map[string]cpuUsage
+		if _, err := s.Read(cpuUsageStorageKey, &previous); err != nil {
+			// There is no usage stored on the first run.
+			previous = nil
+		}
+		for key, current := range cpu {
+			p, ok := previous[key]
+			if !ok || current.Timestamp <= p.Timestamp || current.Seconds < p.Seconds {
+				continue
+			}
+			groupLabel, entityID := splitCPUUsageKey(key)
+			elapsed := float64(current.Timestamp-p.Timestamp) / 1e9
+			g[groupLabel][entityID]["usageNanoCores"] = uint64((current.Seconds - p.Seconds) / elapsed * 1e9)
+		}
+		if err := s.Write(cpuUsageStorageKey, cpu); err != nil {
+			errs = append(errs, fmt.Errorf("error storing the CPU usage: %v", err))
+		}
+
+		if len(errs) > 0 {
+			return g, data.ErrorGroup{Recoverable: true, Errors: errs}
+		}
+		return g, nil
+	}
+}
+
+// resourceEntity returns the raw metrics of the entity the given labels belong to, adding it to the groups if
+// it was not there yet. Metrics without pod labels belong to the node.
+func resourceEntity(g definition.RawGroups, nodeName string, labels prometheus.Labels) (string, string, definition.RawMetrics, error) {
+	namespace, podName, containerName := labels["namespace"], labels["pod"], labels["container"]
+	if podName == "" {
+		return "node", nodeName, g["node"][nodeName], nil
+	}
+	if namespace == "" {
+		return "", "", nil, fmt.Errorf("empty namespace for pod %s", podName)
+	}
+
+	groupLabel := "pod"
+	entityID := fmt.Sprintf("%s_%s", namespace, podName)
+	if containerName != "" {
+		groupLabel = "container"
+		entityID = fmt.Sprintf("%s_%s", entityID, containerName)
+	}
+
+	rawMetrics, ok := g[groupLabel][entityID]
+	if !ok {
+		rawMetrics = definition.RawMetrics{
+			"podName":   podName,
+			"namespace": namespace,
+		}
+		if containerName != "" {
+			rawMetrics["containerName"] = containerName
+		}
+		g[groupLabel][entityID] = rawMetrics
+	}
+	return groupLabel, entityID, rawMetrics, nil
+}
+
+func splitCPUUsageKey(key string) (string, string) {
+	parts := strings.SplitN(key, "/", 2)
+	return parts[0], parts[1]
+}
+
+func floatValue(v prometheus.Value) (float64, bool) {
+	switch value := v.(type) {
+	case prometheus.GaugeValue:
+		return float64(value), true
+	case prometheus.CounterValue:
+		return float64(value), true
+	default:
+		return 0, false
+	}
+}
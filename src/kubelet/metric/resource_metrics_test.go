@@ -0,0 +1,106 @@
+package metric
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/storage"
+)
+
+func resourceMetricsClient(t *testing.T) *testClient {
+	f, err := os.Open("./testdata/kubelet_metrics_resource_payload.txt")
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() }) // nolint: errcheck
+
+	return &testClient{handler: readerToHandler(f)}
+}
+
+func TestResourceMetricsFetchFunc(t *testing.T) {
+	defer func(n func() time.Time) { now = n }(now)
+	now = func() time.Time { return time.Unix(1602664900, 0) }
+
+	s := storage.NewMemoryStorage()
+	previous := now().Add(-10 * time.Second).UnixNano()
+	require.NoError(t, s.Write(cpuUsageStorageKey, map[string]cpuUsage{
+		"node/minikube":                                          {Seconds: 3140.25, Timestamp: previous},
+		"pod/default_web-6d4b75cb6d-9zf6k":                       {Seconds: 12.25, Timestamp: previous},
+		"container/kube-system_coredns-5644d7b6d9-x7lp2_coredns": {Seconds: 85, Timestamp: previous},
+	}))
+
+	g, err := ResourceMetricsFetchFunc(resourceMetricsClient(t), "minikube", s)()
+	require.NoError(t, err)
+
+	assert.Equal(t, definition.RawGroups{
+		"node": {
+			"minikube": {
+				"nodeName":              "minikube",
+				"usageCoreNanoSeconds":  uint64(3150250000000),
+				"usageNanoCores":        uint64(1000000000),
+				"memoryWorkingSetBytes": uint64(1073741824),
+			},
+		},
+		"pod": {
+			"default_web-6d4b75cb6d-9zf6k": {
+				"podName":               "web-6d4b75cb6d-9zf6k",
+				"namespace":             "default",
+				"usageNanoCores":        uint64(100000000),
+				"memoryWorkingSetBytes": uint64(43122688),
+			},
+			"kube-system_coredns-5644d7b6d9-x7lp2": {
+				"podName":               "coredns-5644d7b6d9-x7lp2",
+				"namespace":             "kube-system",
+				"memoryWorkingSetBytes": uint64(13467648),
+			},
+		},
+		"container": {
+			"default_web-6d4b75cb6d-9zf6k_web": {
+				"containerName":   "web",
+				"podName":         "web-6d4b75cb6d-9zf6k",
+				"namespace":       "default",
+				"workingSetBytes": uint64(42573824),
+				"startedAt":       time.Unix(1602660100, 0).In(time.UTC),
+			},
+			"kube-system_coredns-5644d7b6d9-x7lp2_coredns": {
+				"containerName":   "coredns",
+				"podName":         "coredns-5644d7b6d9-x7lp2",
+				"namespace":       "kube-system",
+				"usageNanoCores":  uint64(50000000),
+				"workingSetBytes": uint64(12918784),
+				"startedAt":       time.Unix(1602648300, 0).In(time.UTC),
+			},
+		},
+	}, g)
+
+	// The usage of every entity is stored for the next run.
+	var stored map[string]cpuUsage
+	_, err = s.Read(cpuUsageStorageKey, &stored)
+	require.NoError(t, err)
+	assert.Len(t, stored, 5)
+	assert.Equal(t, cpuUsage{Seconds: 12.5, Timestamp: now().UnixNano()}, stored["container/default_web-6d4b75cb6d-9zf6k_web"])
+}
+
+func TestResourceMetricsFetchFunc_FirstRun(t *testing.T) {
+	g, err := ResourceMetricsFetchFunc(resourceMetricsClient(t), "minikube", storage.NewMemoryStorage())()
+	require.NoError(t, err)
+
+	for _, group := range []string{"node", "pod", "container"} {
+		for id, raw := range g[group] {
+			assert.NotContains(t, raw, "usageNanoCores", "%s %s", group, id)
+		}
+	}
+	assert.Equal(t, uint64(3150250000000), g["node"]["minikube"]["usageCoreNanoSeconds"])
+}
+
+func TestResourceMetricsFetchFunc_Unavailable(t *testing.T) {
+	c := testClient{handler: func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}}
+	_, err := ResourceMetricsFetchFunc(&c, "minikube", storage.NewMemoryStorage())()
+	assert.Error(t, err)
+}
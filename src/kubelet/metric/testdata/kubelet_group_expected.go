@@ -1,9 +1,12 @@
 package testdata
 
 import (
+	"time"
+
 	"github.com/newrelic/nri-kubernetes/src/definition"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ExpectedGroupData is the expectation for main group_test tests.
@@ -31,6 +34,11 @@ var ExpectedGroupData = definition.RawGroups{
 				"name":                     "newrelic-infra",
 				"pod-template-generation":  "1",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":                 "2018-02-27T15:21:31.663551743Z",
+				"kubernetes.io/config.source":               "api",
+				"scheduler.alpha.kubernetes.io/tolerations": "[{\"operator\": \"Exists\", \"effect\": \"NoSchedule\"}]\n",
+			},
 			"errors":  uint64(0),
 			"rxBytes": uint64(106175985),
 			"txBytes": uint64(35714359),
@@ -46,6 +54,17 @@ var ExpectedGroupData = definition.RawGroups{
 					"errors":  uint64(0),
 				},
 			},
+			"usageNanoCores":            uint64(16874347),
+			"memoryUsageBytes":          uint64(52617216),
+			"memoryWorkingSetBytes":     uint64(50044928),
+			"ephemeralStorageUsedBytes": uint64(159744),
+
+			// Owner chain
+			"ownerKind":     "DaemonSet",
+			"ownerName":     "newrelic-infra",
+			"topOwnerKind":  "DaemonSet",
+			"topOwnerName":  "newrelic-infra",
+			"daemonsetName": "newrelic-infra",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq": {
 			"createdKind":    "ReplicaSet",
@@ -63,6 +82,10 @@ var ExpectedGroupData = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":   "2018-02-27T15:21:31.663544832Z",
+				"kubernetes.io/config.source": "api",
+			},
 			"errors":  uint64(0),
 			"rxBytes": uint64(32575098),
 			"txBytes": uint64(27840584),
@@ -78,6 +101,16 @@ var ExpectedGroupData = definition.RawGroups{
 					"errors":  uint64(0),
 				},
 			},
+			"usageNanoCores":            uint64(1393100),
+			"memoryUsageBytes":          uint64(54046720),
+			"memoryWorkingSetBytes":     uint64(53444608),
+			"ephemeralStorageUsedBytes": uint64(7823360),
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "Deployment",
+			"topOwnerName": "kube-state-metrics",
 		},
 		"default_sh-7c95664875-4btqh": {
 			"createdKind":    "ReplicaSet",
@@ -96,6 +129,16 @@ var ExpectedGroupData = definition.RawGroups{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":   "2019-03-13T08:03:01.880958599Z",
+				"kubernetes.io/config.source": "api",
+			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "sh-7c95664875",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "sh-7c95664875",
 		},
 		"kube-system_kube-controller-manager-minikube": {
 			"isReady":   "True",
@@ -107,6 +150,11 @@ var ExpectedGroupData = definition.RawGroups{
 				"k8s-app":   "kube-controller-manager",
 				"component": "kube-controller-manager",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.hash":   "38d78cbd438e068d417c11c848b26f09",
+				"kubernetes.io/config.seen":   "2019-10-23T17:10:43.500021033Z",
+				"kubernetes.io/config.source": "file",
+			},
 			"namespace":   "kube-system",
 			"podName":     "kube-controller-manager-minikube",
 			"nodeName":    "minikube",
@@ -115,9 +163,11 @@ var ExpectedGroupData = definition.RawGroups{
 	},
 	"container": {
 		"kube-system_newrelic-infra-rz225_newrelic-infra": {
+			"containerRuntime":     "docker",
 			"containerName":        "newrelic-infra",
 			"containerID":          "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
 			"containerImage":       "newrelic/ohaik:1.0.0-beta3",
+			"containerType":        "regular",
 			"containerImageID":     "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
 			"namespace":            "kube-system",
 			"podName":              "newrelic-infra-rz225",
@@ -144,11 +194,25 @@ var ExpectedGroupData = definition.RawGroups{
 				"name":                     "newrelic-infra",
 				"pod-template-generation":  "1",
 			},
+
+			// Previous termination of the restarted container
+			"lastTerminatedReason":     "Completed",
+			"lastTerminatedExitCode":   int32(0),
+			"lastTerminatedFinishedAt": parseTime("2018-02-27T15:21:10Z"),
+			"isOOMKilled":              false,
+
+			// Owner chain
+			"ownerKind":     "DaemonSet",
+			"ownerName":     "newrelic-infra",
+			"topOwnerKind":  "DaemonSet",
+			"topOwnerName":  "newrelic-infra",
+			"daemonsetName": "newrelic-infra",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_kube-state-metrics": {
 			"containerName":    "kube-state-metrics",
 			"containerID":      "c452821fcf6c5f594d4f98a1426e7a2c51febb65d5d50d92903f9dfb367bfba7",
 			"containerImage":   "quay.io/coreos/kube-state-metrics:v1.1.0",
+			"containerType":    "regular",
 			"containerImageID": "quay.io/coreos/kube-state-metrics@sha256:52a2c47355c873709bb4e37e990d417e9188c2a778a0c38ed4c09776ddc54efb",
 			"namespace":        "kube-system",
 			"podName":          "kube-state-metrics-57f4659995-6n2qq",
@@ -176,11 +240,18 @@ var ExpectedGroupData = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "Deployment",
+			"topOwnerName": "kube-state-metrics",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_addon-resizer": {
 			"containerName":    "addon-resizer",
 			"containerID":      "3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",
 			"containerImage":   "gcr.io/google_containers/addon-resizer:1.0",
+			"containerType":    "regular",
 			"containerImageID": "gcr.io/google_containers/addon-resizer@sha256:e77acf80697a70386c04ae3ab494a7b13917cb30de2326dcf1a10a5118eddabe",
 			"namespace":        "kube-system",
 			"podName":          "kube-state-metrics-57f4659995-6n2qq",
@@ -208,10 +279,17 @@ var ExpectedGroupData = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "Deployment",
+			"topOwnerName": "kube-state-metrics",
 		},
 		"default_sh-7c95664875-4btqh_sh": {
 			"containerName":  "sh",
 			"containerImage": "python",
+			"containerType":  "regular",
 			"namespace":      "default",
 			"podName":        "sh-7c95664875-4btqh",
 			"nodeName":       "minikube",
@@ -221,10 +299,19 @@ var ExpectedGroupData = definition.RawGroups{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "sh-7c95664875",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "sh-7c95664875",
 		},
 		"kube-system_kube-controller-manager-minikube_kube-controller-manager": {
+			"containerID":       "4c3ce07424670ccd46561eb1498a2759de4da4a7c2cd460fef147afefe4db150",
+			"containerRuntime":  "docker",
 			"containerName":     "kube-controller-manager",
 			"containerImage":    "k8s.gcr.io/kube-controller-manager:v1.16.0",
+			"containerType":     "regular",
 			"nodeIP":            "192.168.99.100",
 			"cpuRequestedCores": int64(200),
 			"status":            "Running",
@@ -239,6 +326,12 @@ var ExpectedGroupData = definition.RawGroups{
 			"namespace": "kube-system",
 			"podName":   "kube-controller-manager-minikube",
 			"nodeName":  "minikube",
+
+			// Previous termination of the restarted container
+			"lastTerminatedReason":     "Error",
+			"lastTerminatedExitCode":   int32(255),
+			"lastTerminatedFinishedAt": parseTime("2019-10-23T17:10:25Z"),
+			"isOOMKilled":              false,
 		},
 	},
 	"node": {
@@ -285,6 +378,26 @@ var ExpectedGroupData = definition.RawGroups{
 				v1.ResourceEphemeralStorage: *resource.NewQuantity(18211586048, resource.BinarySI),
 				v1.ResourceMemory:           *resource.NewQuantity(2033283072, resource.BinarySI),
 			},
+			"conditions": []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+			},
+			"taints": []v1.Taint{
+				{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule},
+			},
+			"unschedulable": false,
+			// system containers and process limits
+			"kubeletUsageNanoCores":           uint64(87352681),
+			"kubeletMemoryUsageBytes":         uint64(457232384),
+			"kubeletMemoryWorkingSetBytes":    uint64(435048448),
+			"runtimeUsageNanoCores":           uint64(17115302),
+			"runtimeMemoryUsageBytes":         uint64(110526464),
+			"runtimeMemoryWorkingSetBytes":    uint64(83607552),
+			"podsCgroupUsageNanoCores":        uint64(95134719),
+			"podsCgroupMemoryUsageBytes":      uint64(852029440),
+			"podsCgroupMemoryWorkingSetBytes": uint64(714407936),
+			"maxPid":                          int64(32768),
+			"runningProcesses":                int64(412),
 			"interfaces": map[string]definition.RawMetrics{
 				"eth0": {
 					"rxBytes": uint64(1507694406),
@@ -434,6 +547,11 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"name":                     "newrelic-infra",
 				"pod-template-generation":  "1",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":                 "2018-02-27T15:21:31.663551743Z",
+				"kubernetes.io/config.source":               "api",
+				"scheduler.alpha.kubernetes.io/tolerations": "[{\"operator\": \"Exists\", \"effect\": \"NoSchedule\"}]\n",
+			},
 			"errors":  uint64(0),
 			"rxBytes": uint64(106175985),
 			"txBytes": uint64(35714359),
@@ -449,6 +567,17 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 					"errors":  uint64(0),
 				},
 			},
+			"usageNanoCores":            uint64(16874347),
+			"memoryUsageBytes":          uint64(52617216),
+			"memoryWorkingSetBytes":     uint64(50044928),
+			"ephemeralStorageUsedBytes": uint64(159744),
+
+			// Owner chain
+			"ownerKind":     "DaemonSet",
+			"ownerName":     "newrelic-infra",
+			"topOwnerKind":  "DaemonSet",
+			"topOwnerName":  "newrelic-infra",
+			"daemonsetName": "newrelic-infra",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq": {
 			"createdKind":    "ReplicaSet",
@@ -466,6 +595,10 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":   "2018-02-27T15:21:31.663544832Z",
+				"kubernetes.io/config.source": "api",
+			},
 			"errors":  uint64(0),
 			"rxBytes": uint64(32575098),
 			"txBytes": uint64(27840584),
@@ -481,6 +614,16 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 					"errors":  uint64(0),
 				},
 			},
+			"usageNanoCores":            uint64(1393100),
+			"memoryUsageBytes":          uint64(54046720),
+			"memoryWorkingSetBytes":     uint64(53444608),
+			"ephemeralStorageUsedBytes": uint64(7823360),
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "Deployment",
+			"topOwnerName": "kube-state-metrics",
 		},
 		"default_sh-7c95664875-4btqh": {
 			"createdKind":    "ReplicaSet",
@@ -499,6 +642,16 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":   "2019-03-13T08:03:01.880958599Z",
+				"kubernetes.io/config.source": "api",
+			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "sh-7c95664875",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "sh-7c95664875",
 		},
 		"kube-system_kube-controller-manager-minikube": {
 			"startTime": parseTime("2019-10-23T17:10:48Z"),
@@ -508,6 +661,11 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"k8s-app":   "kube-controller-manager",
 				"component": "kube-controller-manager",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.hash":   "38d78cbd438e068d417c11c848b26f09",
+				"kubernetes.io/config.seen":   "2019-10-23T17:10:43.500021033Z",
+				"kubernetes.io/config.source": "file",
+			},
 			"namespace": "kube-system",
 			"podName":   "kube-controller-manager-minikube",
 			"nodeName":  "minikube",
@@ -515,9 +673,11 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 	},
 	"container": {
 		"kube-system_newrelic-infra-rz225_newrelic-infra": {
+			"containerRuntime":     "docker",
 			"containerName":        "newrelic-infra",
 			"containerID":          "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
 			"containerImage":       "newrelic/ohaik:1.0.0-beta3",
+			"containerType":        "regular",
 			"containerImageID":     "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
 			"namespace":            "kube-system",
 			"podName":              "newrelic-infra-rz225",
@@ -544,11 +704,25 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"name":                     "newrelic-infra",
 				"pod-template-generation":  "1",
 			},
+
+			// Previous termination of the restarted container
+			"lastTerminatedReason":     "Completed",
+			"lastTerminatedExitCode":   int32(0),
+			"lastTerminatedFinishedAt": parseTime("2018-02-27T15:21:10Z"),
+			"isOOMKilled":              false,
+
+			// Owner chain
+			"ownerKind":     "DaemonSet",
+			"ownerName":     "newrelic-infra",
+			"topOwnerKind":  "DaemonSet",
+			"topOwnerName":  "newrelic-infra",
+			"daemonsetName": "newrelic-infra",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_kube-state-metrics": {
 			"containerName":    "kube-state-metrics",
 			"containerID":      "c452821fcf6c5f594d4f98a1426e7a2c51febb65d5d50d92903f9dfb367bfba7",
 			"containerImage":   "quay.io/coreos/kube-state-metrics:v1.1.0",
+			"containerType":    "regular",
 			"containerImageID": "quay.io/coreos/kube-state-metrics@sha256:52a2c47355c873709bb4e37e990d417e9188c2a778a0c38ed4c09776ddc54efb",
 			"namespace":        "kube-system",
 			"podName":          "kube-state-metrics-57f4659995-6n2qq",
@@ -576,11 +750,18 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "Deployment",
+			"topOwnerName": "kube-state-metrics",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_addon-resizer": {
 			"containerName":    "addon-resizer",
 			"containerID":      "3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",
 			"containerImage":   "gcr.io/google_containers/addon-resizer:1.0",
+			"containerType":    "regular",
 			"containerImageID": "gcr.io/google_containers/addon-resizer@sha256:e77acf80697a70386c04ae3ab494a7b13917cb30de2326dcf1a10a5118eddabe",
 			"namespace":        "kube-system",
 			"podName":          "kube-state-metrics-57f4659995-6n2qq",
@@ -608,10 +789,17 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "Deployment",
+			"topOwnerName": "kube-state-metrics",
 		},
 		"default_sh-7c95664875-4btqh_sh": {
 			"containerName":  "sh",
 			"containerImage": "python",
+			"containerType":  "regular",
 			"namespace":      "default",
 			"podName":        "sh-7c95664875-4btqh",
 			"nodeName":       "minikube",
@@ -621,10 +809,17 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "sh-7c95664875",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "sh-7c95664875",
 		},
 		"kube-system_kube-controller-manager-minikube_kube-controller-manager": {
 			"containerName":     "kube-controller-manager",
 			"containerImage":    "k8s.gcr.io/kube-controller-manager:v1.16.0",
+			"containerType":     "regular",
 			"nodeIP":            "192.168.99.100",
 			"cpuRequestedCores": int64(200),
 			"labels": map[string]string{
@@ -681,6 +876,26 @@ var ExpectedGroupDataWithoutStaticPodsStatus = definition.RawGroups{
 				v1.ResourceEphemeralStorage: *resource.NewQuantity(18211586048, resource.BinarySI),
 				v1.ResourceMemory:           *resource.NewQuantity(2033283072, resource.BinarySI),
 			},
+			"conditions": []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Unix(1602648300, 0))},
+			},
+			"taints": []v1.Taint{
+				{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule},
+			},
+			"unschedulable": false,
+			// system containers and process limits
+			"kubeletUsageNanoCores":           uint64(87352681),
+			"kubeletMemoryUsageBytes":         uint64(457232384),
+			"kubeletMemoryWorkingSetBytes":    uint64(435048448),
+			"runtimeUsageNanoCores":           uint64(17115302),
+			"runtimeMemoryUsageBytes":         uint64(110526464),
+			"runtimeMemoryWorkingSetBytes":    uint64(83607552),
+			"podsCgroupUsageNanoCores":        uint64(95134719),
+			"podsCgroupMemoryUsageBytes":      uint64(852029440),
+			"podsCgroupMemoryWorkingSetBytes": uint64(714407936),
+			"maxPid":                          int64(32768),
+			"runningProcesses":                int64(412),
 			"interfaces": map[string]definition.RawMetrics{
 				"eth0": {
 					"rxBytes": uint64(1507694406),
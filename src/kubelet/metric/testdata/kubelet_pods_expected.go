@@ -19,6 +19,11 @@ var ExpectedRawData = definition.RawGroups{
 			"podName":     "kube-controller-manager-minikube",
 			"status":      "Running",
 			"startTime":   parseTime("2019-10-23T17:10:48Z"),
+			"annotations": map[string]string{
+				"kubernetes.io/config.hash":   "38d78cbd438e068d417c11c848b26f09",
+				"kubernetes.io/config.seen":   "2019-10-23T17:10:43.500021033Z",
+				"kubernetes.io/config.source": "file",
+			},
 		},
 		"kube-system_newrelic-infra-rz225": {
 			"createdKind": "DaemonSet",
@@ -37,6 +42,18 @@ var ExpectedRawData = definition.RawGroups{
 				"name":                     "newrelic-infra",
 				"pod-template-generation":  "1",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":                 "2018-02-27T15:21:31.663551743Z",
+				"kubernetes.io/config.source":               "api",
+				"scheduler.alpha.kubernetes.io/tolerations": "[{\"operator\": \"Exists\", \"effect\": \"NoSchedule\"}]\n",
+			},
+
+			// Owner chain
+			"ownerKind":     "DaemonSet",
+			"ownerName":     "newrelic-infra",
+			"topOwnerKind":  "DaemonSet",
+			"topOwnerName":  "newrelic-infra",
+			"daemonsetName": "newrelic-infra",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq": {
 			"createdKind":    "ReplicaSet",
@@ -54,6 +71,16 @@ var ExpectedRawData = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":   "2018-02-27T15:21:31.663544832Z",
+				"kubernetes.io/config.source": "api",
+			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "kube-state-metrics-57f4659995",
 		},
 		"default_sh-7c95664875-4btqh": {
 			"createdKind":    "ReplicaSet",
@@ -72,19 +99,32 @@ var ExpectedRawData = definition.RawGroups{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
 			},
+			"annotations": map[string]string{
+				"kubernetes.io/config.seen":   "2019-03-13T08:03:01.880958599Z",
+				"kubernetes.io/config.source": "api",
+			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "sh-7c95664875",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "sh-7c95664875",
 		},
 	},
 	"container": {
 		"kube-system_newrelic-infra-rz225_newrelic-infra": {
-			"containerName":  "newrelic-infra",
-			"containerImage": "newrelic/ohaik:1.0.0-beta3",
-			"namespace":      "kube-system",
-			"podName":        "newrelic-infra-rz225",
-			"nodeName":       "minikube",
-			"nodeIP":         "192.168.99.100",
-			"restartCount":   int32(6),
-			"isReady":        true,
-			"status":         "Running",
+			"containerID":      "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
+			"containerRuntime": "docker",
+			"containerName":    "newrelic-infra",
+			"containerImage":   "newrelic/ohaik:1.0.0-beta3",
+			"containerType":    "regular",
+			"namespace":        "kube-system",
+			"podName":          "newrelic-infra-rz225",
+			"nodeName":         "minikube",
+			"nodeIP":           "192.168.99.100",
+			"restartCount":     int32(6),
+			"isReady":          true,
+			"status":           "Running",
 			//"reason": "", // TODO
 			"startedAt":            parseTime("2018-02-27T15:21:16Z"),
 			"cpuRequestedCores":    int64(100),
@@ -95,10 +135,24 @@ var ExpectedRawData = definition.RawGroups{
 				"name":                     "newrelic-infra",
 				"pod-template-generation":  "1",
 			},
+
+			// Previous termination of the restarted container
+			"lastTerminatedReason":     "Completed",
+			"lastTerminatedExitCode":   int32(0),
+			"lastTerminatedFinishedAt": parseTime("2018-02-27T15:21:10Z"),
+			"isOOMKilled":              false,
+
+			// Owner chain
+			"ownerKind":     "DaemonSet",
+			"ownerName":     "newrelic-infra",
+			"topOwnerKind":  "DaemonSet",
+			"topOwnerName":  "newrelic-infra",
+			"daemonsetName": "newrelic-infra",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_kube-state-metrics": {
 			"containerName":  "kube-state-metrics",
 			"containerImage": "quay.io/coreos/kube-state-metrics:v1.1.0",
+			"containerType":  "regular",
 			"namespace":      "kube-system",
 			"podName":        "kube-state-metrics-57f4659995-6n2qq",
 			"nodeName":       "minikube",
@@ -116,10 +170,17 @@ var ExpectedRawData = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "kube-state-metrics-57f4659995",
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_addon-resizer": {
 			"containerName":  "addon-resizer",
 			"containerImage": "gcr.io/google_containers/addon-resizer:1.0",
+			"containerType":  "regular",
 			"namespace":      "kube-system",
 			"podName":        "kube-state-metrics-57f4659995-6n2qq",
 			"nodeName":       "minikube",
@@ -138,10 +199,17 @@ var ExpectedRawData = definition.RawGroups{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "kube-state-metrics-57f4659995",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "kube-state-metrics-57f4659995",
 		},
 		"default_sh-7c95664875-4btqh_sh": {
 			"containerName":  "sh",
 			"containerImage": "python",
+			"containerType":  "regular",
 			"namespace":      "default",
 			"podName":        "sh-7c95664875-4btqh",
 			"nodeName":       "minikube",
@@ -151,11 +219,19 @@ var ExpectedRawData = definition.RawGroups{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
 			},
+
+			// Owner chain
+			"ownerKind":    "ReplicaSet",
+			"ownerName":    "sh-7c95664875",
+			"topOwnerKind": "ReplicaSet",
+			"topOwnerName": "sh-7c95664875",
 		},
 
 		"kube-system_kube-controller-manager-minikube_kube-controller-manager": {
-			"nodeName": "minikube",
-			"isReady":  bool(true),
+			"containerID":      "4c3ce07424670ccd46561eb1498a2759de4da4a7c2cd460fef147afefe4db150",
+			"containerRuntime": "docker",
+			"nodeName":         "minikube",
+			"isReady":          bool(true),
 			"labels": map[string]string{
 				"tier":      "control-plane",
 				"k8s-app":   "kube-controller-manager",
@@ -163,6 +239,7 @@ var ExpectedRawData = definition.RawGroups{
 			},
 			"podName":           "kube-controller-manager-minikube",
 			"containerImage":    "k8s.gcr.io/kube-controller-manager:v1.16.0",
+			"containerType":     "regular",
 			"namespace":         "kube-system",
 			"nodeIP":            "192.168.99.100",
 			"cpuRequestedCores": int64(200),
@@ -170,6 +247,12 @@ var ExpectedRawData = definition.RawGroups{
 			"startedAt":         parseTime("2019-10-23T17:10:49Z"),
 			"restartCount":      int32(1),
 			"containerName":     "kube-controller-manager",
+
+			// Previous termination of the restarted container
+			"lastTerminatedReason":     "Error",
+			"lastTerminatedExitCode":   int32(255),
+			"lastTerminatedFinishedAt": parseTime("2019-10-23T17:10:25Z"),
+			"isOOMKilled":              false,
 		},
 	},
 }
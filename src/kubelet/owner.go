@@ -0,0 +1,160 @@
+package kubelet
+
+import (
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/kubelet/metric"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// maxOwnerChainLength bounds the owners looked up for every pod, so a cycle in the owner references does not keep
+// the lookups going forever.
+const maxOwnerChainLength = 10
+
+// ownerCacheTTL is how long the owners, or the failure to look them up, are kept across collections. Owner
+// references rarely change, so they are not looked up again on every collection.
+const ownerCacheTTL = 10 * time.Minute
+
+type ownerKey struct {
+	kind      string
+	name      string
+	namespace string
+}
+
+type cachedOwner struct {
+	owner     *apiserver.OwnerInfo
+	expiresAt time.Time
+}
+
+// ownerResolver walks the chain of owners of the pods through the API server, like Pod→ReplicaSet→Deployment or
+// Pod→Job→CronJob. The owners are cached for ownerCacheTTL, so the ones shared by several pods or collections are
+// only looked up once.
+type ownerResolver struct {
+	apiServer apiserver.Client
+	logger    *logrus.Logger
+	owners    map[ownerKey]cachedOwner
+	// rejected holds the kinds whose lookups were rejected by the API server, which are warned about once.
+	rejected map[string]bool
+	now      func() time.Time
+}
+
+func newOwnerResolver(apiServer apiserver.Client, logger *logrus.Logger) *ownerResolver {
+	return &ownerResolver{
+		apiServer: apiServer,
+		logger:    logger,
+		owners:    make(map[ownerKey]cachedOwner),
+		rejected:  make(map[string]bool),
+		now:       time.Now,
+	}
+}
+
+// owner looks up the controller of the given kind, name and namespace. It returns nil if it cannot be found.
+func (r *ownerResolver) owner(key ownerKey) *apiserver.OwnerInfo {
+	now := r.now()
+	if o, ok := r.owners[key]; ok && now.Before(o.expiresAt) {
+		return o.owner
+	}
+	o, err := r.apiServer.GetOwnerInfo(key.kind, key.name, key.namespace)
+	switch cause := errors.Cause(err); {
+	case err == nil:
+		delete(r.rejected, key.kind)
+	case apierrors.IsForbidden(cause) || apierrors.IsUnauthorized(cause):
+		if !r.rejected[key.kind] {
+			r.logger.Warnf("The API server rejected looking up the owner of %s %s/%s, the owners of the %s kind "+
+				"will not be resolved. Check the integration is granted get access to them: %v",
+				key.kind, key.namespace, key.name, key.kind, err)
+		}
+		r.rejected[key.kind] = true
+	default:
+		r.logger.Debugf("Cannot resolve the owner of %s %s/%s: %v", key.kind, key.namespace, key.name, err)
+	}
+	r.owners[key] = cachedOwner{owner: o, expiresAt: now.Add(ownerCacheTTL)}
+	return o
+}
+
+// expire forgets the owners cached for longer than ownerCacheTTL, like the ones of deleted pods.
+func (r *ownerResolver) expire() {
+	now := r.now()
+	for key, o := range r.owners {
+		if !now.Before(o.expiresAt) {
+			delete(r.owners, key)
+		}
+	}
+}
+
+// resolve walks the owner chain of the pods, starting from the controller owning them, and sets the top owner of
+// the pods and their containers, and the names of the workloads they belong to. Controllers of kinds that are not
+// built in, like the ones of custom operators, end the chain, as their owners cannot be looked up.
+func (r *ownerResolver) resolve(groups definition.RawGroups) {
+	r.expire()
+
+	containers := make(map[string][]definition.RawMetrics)
+	for _, c := range groups["container"] {
+		podID := podIDOf(c)
+		containers[podID] = append(containers[podID], c)
+	}
+
+	for podID, pod := range groups["pod"] {
+		resolved := r.chainMetrics(pod)
+		if resolved == nil {
+			continue
+		}
+		for _, entity := range append([]definition.RawMetrics{pod}, containers[podIDOf(pod)]...) {
+			for k, v := range resolved {
+				if v == nil {
+					delete(entity, k)
+					continue
+				}
+				entity[k] = v
+			}
+		}
+		r.logger.Debugf("Resolved the owner chain of pod %s", podID)
+	}
+}
+
+// chainMetrics returns the metrics of the pod changed by its owner chain. Nil values are metrics to be removed.
+func (r *ownerResolver) chainMetrics(pod definition.RawMetrics) definition.RawMetrics {
+	kind, _ := pod["ownerKind"].(string)
+	name, _ := pod["ownerName"].(string)
+	namespace, _ := pod["namespace"].(string)
+	if !client.IsOwnerKindSupported(kind) {
+		return nil
+	}
+
+	metrics := make(definition.RawMetrics)
+	for i := 0; i < maxOwnerChainLength && client.IsOwnerKindSupported(kind); i++ {
+		o := r.owner(ownerKey{kind: kind, name: name, namespace: namespace})
+		if o == nil {
+			break
+		}
+		if kind == client.KindReplicaSet && i == 0 {
+			// The deployment guessed from the name of the ReplicaSet is replaced by the actual one, if any.
+			metrics["deploymentName"] = nil
+		}
+		if o.OwnerKind == "" {
+			break
+		}
+		kind, name = o.OwnerKind, o.OwnerName
+		metrics["topOwnerKind"] = kind
+		metrics["topOwnerName"] = name
+		if m := metric.WorkloadNameMetric(kind); m != "" {
+			metrics[m] = name
+		}
+	}
+
+	if len(metrics) == 0 {
+		return nil
+	}
+	return metrics
+}
+
+func podIDOf(r definition.RawMetrics) string {
+	namespace, _ := r["namespace"].(string)
+	podName, _ := r["podName"].(string)
+	return namespace + "_" + podName
+}
@@ -0,0 +1,159 @@
+package kubelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+)
+
+// countingAPIServer counts the owner lookups, failing the ones of the kinds in forbidden like the API server does
+// without the RBAC rules granting access to them.
+type countingAPIServer struct {
+	apiserver.TestAPIServer
+	forbidden map[string]bool
+	lookups   int
+}
+
+func (c *countingAPIServer) GetOwnerInfo(kind, name, namespace string) (*apiserver.OwnerInfo, error) {
+	c.lookups++
+	if c.forbidden[kind] {
+		err := apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "replicasets"}, name, nil)
+		return nil, errors.Wrapf(err, "could not find owner information for %s='%s/%s'", kind, namespace, name)
+	}
+	return c.TestAPIServer.GetOwnerInfo(kind, name, namespace)
+}
+
+func replicaSetPods(names ...string) definition.RawGroups {
+	pods := make(map[string]definition.RawMetrics)
+	for _, name := range names {
+		pods["default_"+name] = definition.RawMetrics{
+			"namespace": "default", "podName": name, "ownerKind": "ReplicaSet", "ownerName": "web-5d8f",
+		}
+	}
+	return definition.RawGroups{"pod": pods}
+}
+
+func TestOwnerResolver(t *testing.T) {
+	a := apiserver.TestAPIServer{Owners: map[string]*apiserver.OwnerInfo{
+		"Job/default/backup-1589": {Kind: "Job", Name: "backup-1589", Namespace: "default", OwnerKind: "CronJob", OwnerName: "backup"},
+		"CronJob/default/backup":  {Kind: "CronJob", Name: "backup", Namespace: "default"},
+		"ReplicaSet/default/web":  {Kind: "ReplicaSet", Name: "web", Namespace: "default"},
+		"StatefulSet/default/db":  {Kind: "StatefulSet", Name: "db", Namespace: "default", OwnerKind: "Cluster", OwnerName: "main"},
+	}}
+
+	groups := definition.RawGroups{
+		"pod": {
+			"default_backup-1589-x7k2p": {
+				"namespace": "default", "podName": "backup-1589-x7k2p",
+				"ownerKind": "Job", "ownerName": "backup-1589", "jobName": "backup-1589",
+				"topOwnerKind": "Job", "topOwnerName": "backup-1589",
+			},
+			// Standalone ReplicaSet, the deployment guessed from its name does not exist
+			"default_web-f9v4s": {
+				"namespace": "default", "podName": "web-f9v4s", "deploymentName": "we",
+				"ownerKind": "ReplicaSet", "ownerName": "web",
+				"topOwnerKind": "ReplicaSet", "topOwnerName": "web",
+			},
+			// StatefulSet managed by a custom operator
+			"default_db-0": {
+				"namespace": "default", "podName": "db-0",
+				"ownerKind": "StatefulSet", "ownerName": "db", "statefulsetName": "db",
+				"topOwnerKind": "StatefulSet", "topOwnerName": "db",
+			},
+		},
+		"container": {
+			"default_backup-1589-x7k2p_backup": {
+				"namespace": "default", "podName": "backup-1589-x7k2p", "containerName": "backup",
+				"ownerKind": "Job", "ownerName": "backup-1589", "jobName": "backup-1589",
+				"topOwnerKind": "Job", "topOwnerName": "backup-1589",
+			},
+		},
+	}
+
+	newOwnerResolver(a, logrus.StandardLogger()).resolve(groups)
+
+	assert.Equal(t, definition.RawMetrics{
+		"namespace": "default", "podName": "backup-1589-x7k2p",
+		"ownerKind": "Job", "ownerName": "backup-1589", "jobName": "backup-1589", "cronjobName": "backup",
+		"topOwnerKind": "CronJob", "topOwnerName": "backup",
+	}, groups["pod"]["default_backup-1589-x7k2p"])
+	assert.Equal(t, definition.RawMetrics{
+		"namespace": "default", "podName": "backup-1589-x7k2p", "containerName": "backup",
+		"ownerKind": "Job", "ownerName": "backup-1589", "jobName": "backup-1589", "cronjobName": "backup",
+		"topOwnerKind": "CronJob", "topOwnerName": "backup",
+	}, groups["container"]["default_backup-1589-x7k2p_backup"])
+	assert.Equal(t, definition.RawMetrics{
+		"namespace": "default", "podName": "web-f9v4s",
+		"ownerKind": "ReplicaSet", "ownerName": "web",
+		"topOwnerKind": "ReplicaSet", "topOwnerName": "web",
+	}, groups["pod"]["default_web-f9v4s"])
+	assert.Equal(t, definition.RawMetrics{
+		"namespace": "default", "podName": "db-0",
+		"ownerKind": "StatefulSet", "ownerName": "db", "statefulsetName": "db",
+		"topOwnerKind": "Cluster", "topOwnerName": "main",
+	}, groups["pod"]["default_db-0"])
+}
+
+func TestOwnerResolver_CachesAcrossCollections(t *testing.T) {
+	a := &countingAPIServer{TestAPIServer: apiserver.TestAPIServer{Owners: map[string]*apiserver.OwnerInfo{
+		"ReplicaSet/default/web-5d8f": {Kind: "ReplicaSet", Name: "web-5d8f", Namespace: "default", OwnerKind: "Deployment", OwnerName: "web"},
+		"Deployment/default/web":      {Kind: "Deployment", Name: "web", Namespace: "default"},
+	}}}
+	now := time.Unix(1000, 0)
+	r := newOwnerResolver(a, logrus.StandardLogger())
+	r.now = func() time.Time { return now }
+
+	groups := replicaSetPods("web-5d8f-a", "web-5d8f-b")
+	r.resolve(groups)
+	assert.Equal(t, "web", groups["pod"]["default_web-5d8f-a"]["deploymentName"])
+	assert.Equal(t, 2, a.lookups)
+
+	// The owners are not looked up again by the next collections
+	now = now.Add(ownerCacheTTL / 2)
+	groups = replicaSetPods("web-5d8f-a", "web-5d8f-c")
+	r.resolve(groups)
+	assert.Equal(t, "web", groups["pod"]["default_web-5d8f-c"]["deploymentName"])
+	assert.Equal(t, 2, a.lookups)
+
+	// Until they expire
+	now = now.Add(ownerCacheTTL)
+	r.resolve(replicaSetPods("web-5d8f-a"))
+	assert.Equal(t, 4, a.lookups)
+}
+
+func TestOwnerResolver_WarnsOnceWhenTheLookupsAreRejected(t *testing.T) {
+	a := &countingAPIServer{forbidden: map[string]bool{"ReplicaSet": true}}
+	logger, hook := logTest.NewNullLogger()
+	now := time.Unix(1000, 0)
+	r := newOwnerResolver(a, logger)
+	r.now = func() time.Time { return now }
+
+	groups := replicaSetPods("web-5d8f-a")
+	r.resolve(groups)
+	assert.Equal(t, definition.RawMetrics{
+		"namespace": "default", "podName": "web-5d8f-a", "ownerKind": "ReplicaSet", "ownerName": "web-5d8f",
+	}, groups["pod"]["default_web-5d8f-a"])
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, "ReplicaSet default/web-5d8f")
+
+	// The rejected lookups are cached too, and looked up again without warning once they expire
+	r.resolve(replicaSetPods("web-5d8f-a"))
+	assert.Equal(t, 1, a.lookups)
+	now = now.Add(ownerCacheTTL)
+	r.resolve(replicaSetPods("web-5d8f-a"))
+	assert.Equal(t, 2, a.lookups)
+	for _, e := range hook.Entries[1:] {
+		assert.NotEqual(t, logrus.WarnLevel, e.Level)
+	}
+}
@@ -12,26 +12,28 @@ import (
 	"github.com/newrelic/infra-integrations-sdk/log"
 	"github.com/newrelic/infra-integrations-sdk/sdk"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/version"
 
 	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	clientCadvisor "github.com/newrelic/nri-kubernetes/src/cadvisor/client"
 	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/config"
 	"github.com/newrelic/nri-kubernetes/src/controlplane"
 	clientControlPlane "github.com/newrelic/nri-kubernetes/src/controlplane/client"
 	"github.com/newrelic/nri-kubernetes/src/data"
 	"github.com/newrelic/nri-kubernetes/src/featureflag"
-	"github.com/newrelic/nri-kubernetes/src/ksm"
+	"github.com/newrelic/nri-kubernetes/src/health"
 	clientKsm "github.com/newrelic/nri-kubernetes/src/ksm/client"
-	"github.com/newrelic/nri-kubernetes/src/kubelet"
-	clientKubelet "github.com/newrelic/nri-kubernetes/src/kubelet/client"
 	metric2 "github.com/newrelic/nri-kubernetes/src/kubelet/metric"
-	"github.com/newrelic/nri-kubernetes/src/metric"
-	"github.com/newrelic/nri-kubernetes/src/network"
+	"github.com/newrelic/nri-kubernetes/src/leader"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
 	"github.com/newrelic/nri-kubernetes/src/scrape"
 	"github.com/newrelic/nri-kubernetes/src/storage"
 )
 
 type argumentList struct {
 	sdkArgs.DefaultArgumentList
+	ConfigFile                   string `help:"Path to a YAML configuration file. Arguments explicitly set from the command line or the environment take precedence over it"`
 	Timeout                      int    `default:"5000" help:"timeout in milliseconds for calling metrics sources"`
 	ClusterName                  string `help:"Identifier of your cluster. You could use it later to filter data in your New Relic account"`
 	DiscoveryCacheDir            string `default:"/var/cache/nr-kubernetes" help:"The location of the cached values for discovered endpoints. Obsolete, use CacheDir instead."`
@@ -54,6 +56,35 @@ type argumentList struct {
 	APIServerEndpointURL         string `help:"Set a custom endpoint URL for the API server endpoint."`
 	NetworkRouteFile             string `help:"Route file to get the default interface from. If left empty on Linux /proc/net/route will be used by default"`
 	EnableVolumeMetrics          bool   `default:"true" help:"Used to disable Volume metrics. Enabled by default"`
+	EnableNetworkInterfaces      bool   `default:"false" help:"Set to report the network usage of every interface of the nodes and pods as net.<interface>.* metrics, selecting the interfaces with the kubelet.network_interfaces patterns of the configuration file. Disabled by default"`
+	KubeletUsageSource           string `default:"summary" help:"Kubelet endpoint the CPU and memory usage of the node, pods and containers is fetched from: 'summary' for /stats/summary, 'resource' for the lighter /metrics/resource, which lacks the network, filesystem and volume metrics, or 'auto' to use /metrics/resource on kubelets serving it"`
+	CadvisorSource               string `help:"Where the cAdvisor metrics of the containers are fetched from: 'kubelet' for the /metrics/cadvisor kubelet endpoint, 'standalone' for a cAdvisor running on each node, falling back to the kubelet when it fails, or 'auto' to use the kubelet and fall back to the standalone cAdvisor. Defaults to 'standalone' when a standalone cAdvisor is configured, and to 'kubelet' otherwise"`
+	CadvisorURL                  string `help:"URL of the standalone cAdvisor. If it is not provided, it is discovered with CadvisorPodLabel, or reached on CadvisorPort of the node"`
+	CadvisorPodLabel             string `help:"discover the standalone cAdvisor pod running on the node using Kubernetes Labels."`
+	CadvisorPort                 int    `default:"0" help:"port to query the standalone cAdvisor on. Defaults to 8080 with the pod label discovery"`
+	CadvisorScheme               string `default:"http" help:"scheme to query the standalone cAdvisor ('http' or 'https')"`
+	CadvisorAuthentication       string `default:"none" help:"authentication against the standalone cAdvisor: 'none' or 'service_account' to send the service account token"`
+	CadvisorInsecureSkipVerify   bool   `default:"false" help:"Set to skip verifying the certificate of the standalone cAdvisor. Disabled by default"`
+	CadvisorCAFile               string `help:"Certificate authority file the certificate of the standalone cAdvisor is verified with"`
+	JobTimeout                   int    `default:"0" help:"deadline in milliseconds for each scrape job to fetch its data. Jobs exceeding it are discarded, and skipped until they finish fetching. Set to 0 to disable"`
+	MaxConcurrentJobs            int    `default:"4" help:"maximum number of scrape jobs running at the same time. Set to 0 to run all of them at once"`
+	EnableSelfMetrics            bool   `default:"true" help:"Used to disable the K8sIntegrationSelfSample describing how each scrape job behaved. Enabled by default"`
+	Daemon                       bool   `default:"false" help:"Keep running and collect metrics periodically, writing one payload per interval to the standard output. Disabled by default"`
+	DaemonInterval               string `default:"15s" help:"Interval between collections when running as a daemon. Valid time units: 'ns', 'us', 'ms', 's', 'm', 'h'"`
+	Output                       string `default:"stdout" help:"Where the metrics are sent, separated by commas: 'stdout' for the New Relic infrastructure agent, 'prometheus' to serve them in the Prometheus exposition format and 'otlp' to export them to an OpenTelemetry collector. 'both' stands for 'stdout,prometheus'. The prometheus output requires running as a daemon"`
+	PrometheusListenAddress      string `default:":9296" help:"Address to serve the metrics in the Prometheus exposition format on, under the /metrics path"`
+	LeaderElection               bool   `default:"false" help:"Elect a single instance of the integration in the cluster to run the cluster scoped jobs, like the kube-state-metrics one, instead of the instance running on the kube-state-metrics node. Disabled by default"`
+	LeaderElectionNamespace      string `default:"default" help:"Namespace of the coordination.k8s.io Lease the leader is elected with"`
+	LeaderElectionLeaseName      string `default:"nri-kubernetes-leader" help:"Name of the coordination.k8s.io Lease the leader is elected with"`
+	LeaderElectionLeaseDuration  string `default:"1m" help:"Time the leader keeps the lease without renewing it, which it does on every collection. Valid time units: 'ns', 'us', 'ms', 's', 'm', 'h'"`
+	HealthListenAddress          string `help:"Address to serve the /healthz and /readyz endpoints on, which can be the same one the Prometheus metrics are served on. Requires running as a daemon. Disabled when empty"`
+	HealthMaxCollectionAge       string `default:"0s" help:"Time without a successful collection after which /healthz reports the integration as unhealthy. Valid time units: 'ns', 'us', 'ms', 's', 'm', 'h'. Set to 0s to use 3 times the daemon interval"`
+	OtlpEndpoint                 string `help:"URL of the OpenTelemetry collector receiving the metrics of the otlp output, e.g. http://otel-collector:4317"`
+	OtlpProtocol                 string `default:"grpc" help:"Protocol used to export the metrics to the OpenTelemetry collector: 'grpc' or 'http/protobuf'"`
+	OtlpTimeout                  int    `default:"5000" help:"timeout in milliseconds for exporting the metrics to the OpenTelemetry collector"`
+	OtlpHeaders                  string `help:"Headers added to the requests to the OpenTelemetry collector, in the key=value,key2=value2 format"`
+	Record                       string `help:"Directory to save the responses of the kubelet, kube-state-metrics, control plane components and Kubernetes API lookups to, so they can be replayed. Disabled when empty"`
+	Replay                       string `help:"Directory with responses saved by the record argument, which are used instead of calling the data sources. Disabled when empty"`
 }
 
 const (
@@ -69,6 +100,7 @@ const (
 	defaultAPIServerCacheTTL           = time.Minute * 5
 	defaultAPIServerCacheK8SVersionTTL = time.Hour * 3
 	defaultDiscoveryCacheTTL           = time.Hour
+	defaultDaemonInterval              = time.Second * 15
 
 	integrationName    = "com.newrelic.kubernetes"
 	integrationVersion = "1.26.8"
@@ -88,6 +120,16 @@ func getCacheDir(subDirectory string) string {
 	return path.Join(cacheDir, subDirectory)
 }
 
+// newAPIServerCache wraps the given client with a cache for the given ttl. When running as a daemon the
+// cache is kept in memory, otherwise it is stored on disk so it can be shared between executions. It is also kept
+// in memory when recording or replaying, so the lookups are not answered from the ones of other executions.
+func newAPIServerCache(c apiserver.Client, subDirectory string, ttl time.Duration) apiserver.Client {
+	if args.Daemon || args.Record != "" || args.Replay != "" {
+		return apiserver.NewCacheClientWrapper(c, storage.NewMemoryStorage(), ttl)
+	}
+	return apiserver.NewFileCacheClientWrapper(c, getCacheDir(subDirectory), ttl)
+}
+
 func controlPlaneJobs(
 	logger *logrus.Logger,
 	apiServerClient apiserver.Client,
@@ -103,6 +145,8 @@ func controlPlaneJobs(
 	etcdEndpointURL string,
 	controllerManagerEndpointURL string,
 	apiServerEndpointURL string,
+	rec *recording,
+	extraOpts ...controlplane.ComponentOption,
 ) ([]*scrape.Job, error) {
 
 	nodeInfo, err := apiServerClient.GetNodeInfo(nodeName)
@@ -140,6 +184,8 @@ func controlPlaneJobs(
 		opts = append(opts, controlplane.WithEndpointURL(controlplane.ControllerManager, controllerManagerEndpointURL))
 	}
 
+	opts = append(opts, extraOpts...)
+
 	var jobs []*scrape.Job
 	for _, component := range controlplane.BuildComponentList(opts...) {
 
@@ -165,30 +211,53 @@ func controlPlaneJobs(
 			continue
 		}
 
+		componentClient = rec.httpClient(path.Join(controlPlaneSource, string(component.Name)), componentClient)
 		componentGrouper := controlplane.NewComponentGrouper(
 			componentClient,
 			component.Queries,
 			logger,
 			c.PodName,
 		)
-		jobs = append(
-			jobs,
-			scrape.NewScrapeJob(string(component.Name), componentGrouper, component.Specs),
-		)
+		job := scrape.NewScrapeJob(string(component.Name), componentGrouper, component.Specs)
+		job.Client = componentClient
+		jobs = append(jobs, job)
 	}
 
 	return jobs, nil
 }
 
 func main() {
+	// The explain subcommand is removed from the arguments, so the rest of them are parsed as usual.
+	explainMode := len(os.Args) > 1 && os.Args[1] == explainCommand
+	if explainMode {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	integration, err := sdk.NewIntegrationProtocol2(integrationName, integrationVersion, &args)
-	var jobs []*scrape.Job
 	exitLog := fmt.Sprintf("Integration %q exited", integrationName)
 	if err != nil {
 		defer log.Debug(exitLog)
 		log.Fatal(err) // Global logs used as args processed inside NewIntegrationProtocol2
 	}
 
+	cfg := &config.Config{}
+	if args.ConfigFile != "" {
+		cfg, err = config.Load(args.ConfigFile)
+		if err != nil {
+			defer log.Debug(exitLog)
+			log.Fatal(err)
+		}
+		applyConfig(&args, cfg, explicitArgs())
+	}
+
+	networkInterfaces := cfg.Kubelet.NetworkInterfaces
+	networkInterfaces.Enabled = &args.EnableNetworkInterfaces
+	definitions, err := newMetricDefinitions(cfg.CustomMetrics, networkInterfaces)
+	if err != nil {
+		defer log.Debug(exitLog)
+		log.Fatal(err)
+	}
+
 	logger := log.New(args.Verbose)
 	defer func() {
 		if r := recover(); r != nil {
@@ -207,12 +276,51 @@ func main() {
 		logger.Panic(errors.New("cluster_name argument is mandatory"))
 	}
 
+	// Nothing is published when explaining, so no output is created.
+	outputs := &outputs{}
+	if !explainMode {
+		outputs, err = newOutputs(args)
+		if err != nil {
+			logger.Panic(err)
+		}
+	}
+
+	interval := defaultDaemonInterval
+	if args.Daemon {
+		interval, err = time.ParseDuration(args.DaemonInterval)
+		if err != nil || interval <= 0 {
+			logger.WithError(err).Errorf("while parsing the daemon interval value. Defaulting to %s", defaultDaemonInterval)
+			interval = defaultDaemonInterval
+		}
+	}
+
+	checker, err := newHealthChecker(interval)
+	if err != nil {
+		logger.Panic(err)
+	}
+
+	// Servers are started before the discovery, so the health endpoints can be probed while it runs.
+	if !explainMode {
+		for _, server := range startServers(logger, outputs, checker) {
+			defer server.Close() // nolint: errcheck
+		}
+	}
+
+	rec, err := newRecording(logger)
+	if err != nil {
+		logger.Panic(err)
+	}
+
+	// When replaying, the node is the one the responses were recorded on.
 	nodeName := os.Getenv(nodeNameEnvVar)
+	if rec.replaying() {
+		nodeName = rec.replayer.NodeName()
+	}
 	if nodeName == "" {
 		logger.Panicf("%s env var should be provided by Kubernetes and is mandatory", nodeNameEnvVar)
 	}
 
-	if !args.All && !args.Metrics {
+	if !args.All && !args.Metrics && !explainMode {
 		return
 	}
 
@@ -224,69 +332,23 @@ func main() {
 
 	timeout := time.Millisecond * time.Duration(args.Timeout)
 
-	innerKubeletDiscoverer, err := clientKubelet.NewDiscoverer(nodeName, logger)
-	if err != nil {
-		logger.Panicf("error during Kubelet auto discovering process. %s", err)
-	}
 	cacheStorage := storage.NewJSONDiskStorage(getCacheDir(discoveryCacheDir))
 
-	defaultNetworkInterface, err := network.CachedDefaultInterface(
-		logger, args.NetworkRouteFile, cacheStorage, ttl)
-	if err != nil {
-		logger.Warn(err)
-	}
-	kubeletDiscoverer := clientKubelet.NewDiscoveryCacher(innerKubeletDiscoverer, cacheStorage, ttl, logger)
-
-	kubeletClient, err := kubeletDiscoverer.Discover(timeout)
-	if err != nil {
-		logger.Panic(err)
-	}
-	kubeletNodeIP := kubeletClient.NodeIP()
-	logger.Debugf("Kubelet node IP = %s", kubeletNodeIP)
-
-	k8s, err := client.NewKubernetes(false)
-	if err != nil {
-		logger.Panic(err)
+	var k8s client.Kubernetes
+	if !rec.replaying() {
+		k8s, err = client.NewKubernetes(false)
+		if err != nil {
+			logger.Panic(err)
+		}
 	}
+	k8s = rec.kubernetes(k8s)
 
-	if !args.DisableKubeStateMetrics {
-		var ksmClients []client.HTTPClient
-		var ksmNodeIP string
-		if args.DistributedKubeStateMetrics {
-			ksmDiscoverer, err := getMultiKSMDiscoverer(kubeletNodeIP, logger)
-			if err != nil {
-				logger.Panic(err)
-			}
-			ksmDiscoveryCache := clientKsm.NewDistributedDiscoveryCacher(ksmDiscoverer, cacheStorage, ttl, logger)
-			ksmClients, err = ksmDiscoveryCache.Discover(timeout)
-			logger.Debugf("found %d KSM clients:", len(ksmClients))
-			for _, c := range ksmClients {
-				logger.Debugf("- node IP: %s", c.NodeIP())
-			}
-			if err != nil {
-				logger.Panic(err)
-			}
-			ksmNodeIP = kubeletNodeIP
-		} else {
-			innerKSMDiscoverer, err := getKSMDiscoverer(logger)
-			if err != nil {
-				logger.Panic(err)
-			}
-			ksmDiscoverer := clientKsm.NewDiscoveryCacher(innerKSMDiscoverer, cacheStorage, ttl, logger)
-			ksmClient, err := ksmDiscoverer.Discover(timeout)
-			if err != nil {
-				logger.Panic(err)
-			}
-			ksmNodeIP = ksmClient.NodeIP()
-			// we only scrape KSM when we are on the same Node as KSM
-			if kubeletNodeIP == ksmNodeIP {
-				ksmClients = append(ksmClients, ksmClient)
-			}
-		}
-		logger.Debugf("KSM Node = %s", ksmNodeIP)
-		for _, ksmClient := range ksmClients {
-			ksmGrouper := ksm.NewGrouper(ksmClient, metric.KSMQueries, logger, k8s)
-			jobs = append(jobs, scrape.NewScrapeJob("kube-state-metrics", ksmGrouper, metric.KSMSpecs))
+	// Electing a leader creates or updates the lease, which explaining must not do.
+	var elector *leader.Elector
+	if !explainMode {
+		elector, err = newElector(logger, k8s, nodeName, interval)
+		if err != nil {
+			logger.Panic(err)
 		}
 	}
 
@@ -304,9 +366,9 @@ func main() {
 
 	var apiServerClientK8sVersion apiserver.Client
 	if ttlAPIServerCacheK8SVersion != time.Duration(0) {
-		apiServerClientK8sVersion = apiserver.NewFileCacheClientWrapper(
+		apiServerClientK8sVersion = newAPIServerCache(
 			apiServerClient,
-			getCacheDir(apiserverCacheDirK8sVersion),
+			apiserverCacheDirK8sVersion,
 			ttlAPIServerCacheK8SVersion,
 		)
 	} else {
@@ -325,80 +387,247 @@ func main() {
 	}
 
 	if ttlAPIServerCache != time.Duration(0) {
-		apiServerClient = apiserver.NewFileCacheClientWrapper(apiServerClient,
-			getCacheDir(apiserverCacheDir),
-			ttlAPIServerCache)
+		apiServerClient = newAPIServerCache(apiServerClient, apiserverCacheDir, ttlAPIServerCache)
 	}
 
-	podsFetcher := metric2.NewPodsFetcher(logger, kubeletClient, enableStaticPodsStatus).FetchFuncWithCache()
-	cpJobs, err := controlPlaneJobs(
-		logger,
-		apiServerClient,
-		nodeName,
-		timeout,
-		kubeletNodeIP,
-		podsFetcher,
-		k8s,
-		args.EtcdTLSSecretName,
-		args.EtcdTLSSecretNamespace,
-		args.APIServerSecurePort,
-		args.SchedulerEndpointURL,
-		args.EtcdEndpointURL,
-		args.ControllerManagerEndpointURL,
-		args.APIServerEndpointURL,
-	)
+	chain, err := newPopulatorChain(cfg, apiServerClient)
+	if err != nil {
+		logger.Panic(err)
+	}
 
+	setup := &jobSetup{
+		logger:                 logger,
+		rec:                    rec,
+		nodeName:               nodeName,
+		cacheStorage:           cacheStorage,
+		ttl:                    ttl,
+		timeout:                timeout,
+		k8s:                    k8s,
+		elector:                elector,
+		apiServerClient:        apiServerClient,
+		enableStaticPodsStatus: enableStaticPodsStatus,
+		definitions:            definitions,
+		chain:                  chain,
+		outputs:                outputs,
+		discoveryCaches:        map[string]client.CacheStatsReporter{},
+	}
+	scrapeJobs, err := setup.run()
 	if err != nil {
-		logger.Errorf("couldn't configure control plane components jobs: %v", err)
-	} else {
-		jobs = append(jobs, cpJobs...)
+		logger.Panic(err)
+	}
+	if checker != nil {
+		checker.Discovered()
+	}
+
+	if explainMode {
+		if err := explainJobs(scrapeJobs.jobs, args.ClusterName, chain); err != nil {
+			logger.Panic(err)
+		}
+		return
 	}
 
-	// Kubelet is always scraped, on each node
-	kubeletGrouper := kubelet.NewGrouper(
-		kubeletClient,
+	scheduler := scrape.NewScheduler(
+		args.MaxConcurrentJobs,
+		time.Millisecond*time.Duration(args.JobTimeout),
 		logger,
-		apiServerClient,
-		defaultNetworkInterface,
-		args.EnableVolumeMetrics,
-		podsFetcher,
-		metric2.CadvisorFetchFunc(kubeletClient, metric.CadvisorQueries),
 	)
-	jobs = append(jobs, scrape.NewScrapeJob("kubelet", kubeletGrouper, metric.KubeletSpecs))
 
-	successfulJobs := 0
-	for _, job := range jobs {
-		logger.Debugf("Running job: %s", job.Name)
-		start := time.Now()
-		result := job.Populate(integration, args.ClusterName, logger, k8sVersion)
-		measured := time.Now().Sub(start)
-		logger.Debugf("Job %s took %s", job.Name, measured.Round(time.Millisecond))
+	if !args.Daemon {
+		if err := collectAndPublish(logger, scheduler, scheduledJobs(logger, scrapeJobs.jobs, elector), integration, k8sVersion, nodeName, setup.discoveryCaches, outputs, checker); err != nil {
+			logger.Panic(err)
+		}
+		return
+	}
 
+	runDaemon(logger, interval, func() error {
+		// The data sources are discovered again once per discovery TTL, so the ones that moved are found.
+		scrapeJobs = setup.refresh(scrapeJobs)
+
+		// Pods are cached for a single collection, so they need to be queried again.
+		scrapeJobs.pods.Reset()
+
+		// The version is cached in memory by the api server client, so this only
+		// queries the API server once the cache has expired.
+		if v, err := apiServerClientK8sVersion.GetServerVersion(); err != nil {
+			logger.WithError(err).Errorf("getting the kubernetes server version")
+		} else {
+			k8sVersion = v
+		}
+
+		return collectAndPublish(logger, scheduler, scheduledJobs(logger, scrapeJobs.jobs, elector), integration, k8sVersion, nodeName, setup.discoveryCaches, outputs, checker)
+	})
+
+	if elector != nil {
+		if err := elector.Release(); err != nil {
+			logger.WithError(err).Warn("releasing the leader election lease")
+		}
+	}
+}
+
+// collectAndPublish runs all the scrape jobs once and publishes the data they populated to the given outputs. The
+// results of the jobs are recorded by the health checker, if any.
+func collectAndPublish(
+	logger *logrus.Logger,
+	scheduler *scrape.Scheduler,
+	jobs []*scrape.Job,
+	integration *sdk.IntegrationProtocol2,
+	k8sVersion *version.Info,
+	nodeName string,
+	discoveryCaches map[string]client.CacheStatsReporter,
+	outputs *outputs,
+	checker *health.Checker,
+) error {
+	results := scheduler.Run(jobs, integration, args.ClusterName, k8sVersion)
+	outputs.flush(logger)
+	if checker != nil {
+		checker.Collected(results)
+	}
+	successfulJobs := 0
+	for _, result := range results {
 		if result.Populated {
 			successfulJobs++
 		}
 
 		if len(result.Errors) > 0 {
-			logger.WithFields(logrus.Fields{"phase": "populate", "datasource": job.Name}).Debug(result.Error())
+			logger.WithFields(logrus.Fields{"phase": "populate", "datasource": result.Name}).Debug(result.Error())
 		}
 	}
 
+	var errNoData error
 	if successfulJobs == 0 {
-		logger.Panic("No data was populated")
+		// Discard any partial data, so it is not published together with the next collection. The self metrics
+		// are still published, as they tell why every job failed.
+		integration.Clear()
+		errNoData = errors.New("no data was populated")
+		if !args.EnableSelfMetrics {
+			return errNoData
+		}
+	}
+
+	if args.EnableSelfMetrics {
+		err := scrape.PopulateSelfMetrics(integration, args.ClusterName, nodeName, integrationVersion, results, discoveryCaches)
+		if err != nil {
+			logger.WithError(err).Warn("populating the integration self metrics")
+		}
+	}
+
+	if !outputs.stdout {
+		integration.Clear()
+		return errNoData
 	}
 
 	if err := integration.Publish(); err != nil {
-		logger.Panic(err)
+		return err
+	}
+
+	if args.Daemon {
+		// The integration does not terminate its payload, so each collection must be
+		// finished by a new line to be told apart by the agent.
+		fmt.Fprintln(os.Stdout)
+	}
+	return errNoData
+}
+
+// kubeletUsageFetcher returns the FetchFunc the usage of the node, pods and containers is fetched with, or nil to
+// fetch it from /stats/summary.
+func kubeletUsageFetcher(
+	source string,
+	kubeletClient client.HTTPClient,
+	apiServerClient apiserver.Client,
+	nodeName string,
+	s storage.Storage,
+	logger *logrus.Logger,
+) data.FetchFunc {
+	switch source {
+	case "resource":
+	case "auto":
+		nodeInfo, err := apiServerClient.GetNodeInfo(nodeName)
+		if err != nil {
+			logger.WithError(err).Warnf("getting the kubelet version, fetching the usage from %s", metric2.StatsSummaryPath)
+			return nil
+		}
+		if !featureflag.KubeletResourceMetrics(nodeInfo.KubeletVersion) {
+			return nil
+		}
+	case "summary":
+		return nil
+	default:
+		logger.Errorf("unknown kubelet usage source %q, fetching the usage from %s", source, metric2.StatsSummaryPath)
+		return nil
+	}
+
+	logger.Debugf("Fetching the kubelet usage from %s", metric2.ResourceMetricsPath)
+	return metric2.ResourceMetricsFetchFunc(kubeletClient, nodeName, s)
+}
+
+// resolveCadvisorSource returns the source the cadvisor metrics are fetched from, which defaults to the standalone
+// cadvisor when it is configured.
+func resolveCadvisorSource(source, url, podLabel string, port int) string {
+	if source != "" {
+		return source
+	}
+	if url != "" || podLabel != "" || port != 0 {
+		return "standalone"
 	}
+	return "kubelet"
 }
 
-func getKSMDiscoverer(logger *logrus.Logger) (client.Discoverer, error) {
+// cadvisorFetcher returns the FetchFunc the cadvisor metrics of the containers are fetched with from the given
+// source, falling back between the kubelet and the standalone cadvisor. Only the kubelet is used when the
+// standalone cadvisor client is nil.
+func cadvisorFetcher(
+	source string,
+	kubeletClient client.HTTPClient,
+	cadvisorClient client.HTTPClient,
+	queries []prometheus.Query,
+	logger *logrus.Logger,
+) data.FetchFunc {
+	kubeletFetcher := metric2.CadvisorFetchFunc(kubeletClient, queries)
+	if cadvisorClient == nil {
+		return kubeletFetcher
+	}
+	standaloneFetcher := metric2.StandaloneCadvisorFetchFunc(cadvisorClient, queries)
+
+	switch source {
+	case "kubelet":
+		return kubeletFetcher
+	case "standalone":
+		logger.Debugf("Fetching the cadvisor metrics from the standalone cadvisor, falling back to %s", metric2.KubeletCAdvisorMetricsPath)
+		return metric2.CadvisorFallbackFetchFunc(standaloneFetcher, kubeletFetcher)
+	case "auto":
+		logger.Debugf("Fetching the cadvisor metrics from %s, falling back to the standalone cadvisor", metric2.KubeletCAdvisorMetricsPath)
+		return metric2.CadvisorFallbackFetchFunc(kubeletFetcher, standaloneFetcher)
+	default:
+		logger.Errorf("unknown cadvisor source %q, fetching the cadvisor metrics from %s", source, metric2.KubeletCAdvisorMetricsPath)
+		return kubeletFetcher
+	}
+}
 
-	k8sClient, err := client.NewKubernetes( /* tryLocalKubeconfig */ false)
+// getCadvisorClient discovers the standalone cadvisor running on the node with the given IP.
+func getCadvisorClient(
+	nodeIP string,
+	k8s client.Kubernetes,
+	s storage.Storage,
+	ttl time.Duration,
+	timeout time.Duration,
+	logger *logrus.Logger,
+) (client.HTTPClient, error) {
+	innerDiscoverer, err := clientCadvisor.NewDiscoverer(clientCadvisor.Options{
+		URL:                args.CadvisorURL,
+		PodLabel:           args.CadvisorPodLabel,
+		Port:               args.CadvisorPort,
+		Scheme:             args.CadvisorScheme,
+		Authentication:     args.CadvisorAuthentication,
+		InsecureSkipVerify: args.CadvisorInsecureSkipVerify,
+		CAFile:             args.CadvisorCAFile,
+	}, nodeIP, logger, k8s)
 	if err != nil {
 		return nil, err
 	}
+	return clientCadvisor.NewDiscoveryCacher(innerDiscoverer, s, ttl, logger).Discover(timeout)
+}
 
+func getKSMDiscoverer(logger *logrus.Logger, k8sClient client.Kubernetes) (client.Discoverer, error) {
 	// It's important this one is before the NodeLabel selector, for backwards compatibility.
 	if args.KubeStateMetricsURL != "" {
 		// checking to see if KubeStateMetricsURL contains the /metrics path already.
@@ -419,12 +648,7 @@ func getKSMDiscoverer(logger *logrus.Logger) (client.Discoverer, error) {
 	return clientKsm.NewDiscoverer(logger, k8sClient), nil
 }
 
-func getMultiKSMDiscoverer(nodeIP string, logger *logrus.Logger) (client.MultiDiscoverer, error) {
-	k8sClient, err := client.NewKubernetes( /* tryLocalKubeconfig */ false)
-	if err != nil {
-		return nil, err
-	}
-
+func getMultiKSMDiscoverer(nodeIP string, logger *logrus.Logger, k8sClient client.Kubernetes) (client.MultiDiscoverer, error) {
 	if args.KubeStateMetricsPodLabel == "" {
 		return nil, errors.New("multi KSM discovery set without a KUBE_STATE_METRICS_POD_LABEL")
 	}
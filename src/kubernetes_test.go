@@ -1,19 +1,52 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
+	sdkArgs "github.com/newrelic/infra-integrations-sdk/args"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/stretchr/testify/require"
+
 	"github.com/newrelic/nri-kubernetes/src/apiserver"
+	"github.com/newrelic/nri-kubernetes/src/client"
 	"github.com/newrelic/nri-kubernetes/src/controlplane"
+	"github.com/newrelic/nri-kubernetes/src/data"
 	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+	"github.com/newrelic/nri-kubernetes/src/storage"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 var logger = logrus.StandardLogger()
 
+// TestArgumentListSetup sets up the arguments like the integration does at startup, which fails when an argument
+// has no usable default.
+func TestArgumentListSetup(t *testing.T) {
+	commandLine, osArgs := flag.CommandLine, os.Args
+	defer func() { flag.CommandLine, os.Args = commandLine, osArgs }()
+	flag.CommandLine = flag.NewFlagSet("nri-kubernetes", flag.ContinueOnError)
+	os.Args = []string{"nri-kubernetes"}
+
+	var a argumentList
+	require.NoError(t, sdkArgs.SetupArgs(&a))
+
+	assert.Equal(t, 5000, a.Timeout)
+	assert.Equal(t, 0, a.CadvisorPort)
+	assert.Equal(t, "http", a.CadvisorScheme)
+}
+
 func TestControlPlaneJobs(t *testing.T) {
 	nodeName := "ip-10.0.2.15"
 	nodeIP := "10.0.2.15"
@@ -77,6 +110,7 @@ func TestControlPlaneJobs(t *testing.T) {
 		"",
 		"",
 		"",
+		&recording{},
 	)
 	assert.Equal(t, len(components), len(cpJobs))
 
@@ -95,3 +129,157 @@ func TestControlPlaneJobs(t *testing.T) {
 		}
 	}
 }
+
+func TestKubeletUsageFetcher(t *testing.T) {
+	apiServerClient := apiserver.TestAPIServer{Mem: map[string]*apiserver.NodeInfo{
+		"old": {NodeName: "old", KubeletVersion: "v1.17.9"},
+		"new": {NodeName: "new", KubeletVersion: "v1.18.3"},
+	}}
+	s := storage.NewMemoryStorage()
+
+	assert.Nil(t, kubeletUsageFetcher("summary", nil, apiServerClient, "new", s, logger))
+	assert.Nil(t, kubeletUsageFetcher("auto", nil, apiServerClient, "old", s, logger))
+	assert.Nil(t, kubeletUsageFetcher("auto", nil, apiServerClient, "unknown", s, logger))
+	assert.NotNil(t, kubeletUsageFetcher("auto", nil, apiServerClient, "new", s, logger))
+	assert.NotNil(t, kubeletUsageFetcher("resource", nil, apiServerClient, "old", s, logger))
+}
+
+func TestResolveCadvisorSource(t *testing.T) {
+	assert.Equal(t, "kubelet", resolveCadvisorSource("", "", "", 0))
+	assert.Equal(t, "standalone", resolveCadvisorSource("", "http://cadvisor:8080", "", 0))
+	assert.Equal(t, "standalone", resolveCadvisorSource("", "", "app", 0))
+	assert.Equal(t, "standalone", resolveCadvisorSource("", "", "", 4194))
+	assert.Equal(t, "auto", resolveCadvisorSource("auto", "", "app", 0))
+	assert.Equal(t, "kubelet", resolveCadvisorSource("kubelet", "", "", 4194))
+}
+
+// cadvisorClient serves the memory cache of a container, or fails when it is not available.
+type cadvisorClient struct {
+	available bool
+	calls     []string
+}
+
+func (c *cadvisorClient) Do(method, path string) (*http.Response, error) {
+	c.calls = append(c.calls, path)
+	if !c.available {
+		return nil, fmt.Errorf("connection refused")
+	}
+	w := httptest.NewRecorder()
+	io.WriteString(w, `# TYPE container_memory_cache gauge
+container_memory_cache{container="app",id="/docker/3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",image="app:1",namespace="default",pod="app-1"} 4096
+`) // nolint: errcheck
+	return w.Result(), nil
+}
+
+func (c *cadvisorClient) NodeIP() string {
+	return "10.0.2.15"
+}
+
+func TestCadvisorFetcher(t *testing.T) {
+	queries := []prometheus.Query{{MetricName: "container_memory_cache"}}
+
+	testCases := []struct {
+		name                string
+		source              string
+		noStandalone        bool
+		kubeletAvailable    bool
+		standaloneAvailable bool
+		kubeletCalls        []string
+		standaloneCalls     []string
+	}{
+		{
+			name:                "kubelet",
+			source:              "kubelet",
+			kubeletAvailable:    true,
+			standaloneAvailable: true,
+			kubeletCalls:        []string{"/metrics/cadvisor"},
+		},
+		{
+			name:                "standalone",
+			source:              "standalone",
+			kubeletAvailable:    true,
+			standaloneAvailable: true,
+			standaloneCalls:     []string{"/metrics"},
+		},
+		{
+			name:             "standalone falls back to kubelet",
+			source:           "standalone",
+			kubeletAvailable: true,
+			kubeletCalls:     []string{"/metrics/cadvisor"},
+			standaloneCalls:  []string{"/metrics"},
+		},
+		{
+			name:                "auto falls back to standalone",
+			source:              "auto",
+			standaloneAvailable: true,
+			kubeletCalls:        []string{"/metrics/cadvisor"},
+			standaloneCalls:     []string{"/metrics"},
+		},
+		{
+			name:             "standalone not discovered",
+			source:           "standalone",
+			noStandalone:     true,
+			kubeletAvailable: true,
+			kubeletCalls:     []string{"/metrics/cadvisor"},
+		},
+		{
+			name:                "unknown source",
+			source:              "foo",
+			kubeletAvailable:    true,
+			standaloneAvailable: true,
+			kubeletCalls:        []string{"/metrics/cadvisor"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeletClient := &cadvisorClient{available: tc.kubeletAvailable}
+			standaloneClient := &cadvisorClient{available: tc.standaloneAvailable}
+			var c client.HTTPClient = standaloneClient
+			if tc.noStandalone {
+				c = nil
+			}
+
+			g, err := cadvisorFetcher(tc.source, kubeletClient, c, queries, logger)()
+			assert.NoError(t, err)
+
+			assert.Equal(t, prometheus.GaugeValue(4096), g["container"]["default_app-1_app"]["container_memory_cache"])
+			assert.Equal(t, tc.kubeletCalls, kubeletClient.calls)
+			assert.Equal(t, tc.standaloneCalls, standaloneClient.calls)
+		})
+	}
+}
+
+type failingGrouper struct{}
+
+func (failingGrouper) Group(definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
+	return nil, &data.ErrorGroup{Errors: []error{errors.New("connection refused")}}
+}
+
+func TestCollectAndPublish_NoJobSucceeded(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+	args = argumentList{ClusterName: "cluster", EnableSelfMetrics: true}
+
+	var out bytes.Buffer
+	integration, err := sdk.NewIntegrationProtocol2WithWriter(integrationName, integrationVersion, new(struct{}), &out)
+	require.NoError(t, err)
+	jobs := []*scrape.Job{scrape.NewScrapeJob("kubelet", failingGrouper{}, definition.SpecGroups{})}
+
+	err = collectAndPublish(logger, scrape.NewScheduler(0, 0, logger), jobs, integration, nil, "node", nil, &outputs{stdout: true}, nil)
+	assert.EqualError(t, err, "no data was populated")
+
+	var payload struct {
+		Data []struct {
+			Metrics []map[string]interface{} `json:"metrics"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &payload))
+	require.Len(t, payload.Data, 1)
+	require.Len(t, payload.Data[0].Metrics, 1)
+	sample := payload.Data[0].Metrics[0]
+	assert.Equal(t, scrape.SelfSampleEventType, sample["event_type"])
+	assert.Equal(t, "kubelet", sample["jobName"])
+	assert.Equal(t, float64(0), sample["successfulJobs"])
+	assert.Equal(t, float64(1), sample["nonRecoverableErrors"])
+	assert.Equal(t, "connection refused", sample["errors"])
+}
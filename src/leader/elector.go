@@ -0,0 +1,152 @@
+// Package leader elects a single instance of the integration, among the ones running on every node of the
+// cluster, to collect the data that is not specific to any node.
+package leader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Elector holds a coordination.k8s.io Lease, the object Kubernetes components elect their leader with.
+//
+// The lease is not renewed in the background: it is acquired or renewed on every call to IsLeader, so the election
+// works both when the integration runs once per interval and when it runs as a daemon. The renew time of the
+// lease is compared against the local clock, so the clocks of the nodes are expected to be synchronized.
+type Elector struct {
+	client        LeaseClient
+	namespace     string
+	name          string
+	identity      string
+	leaseDuration time.Duration
+	logger        *logrus.Logger
+	now           func() time.Time
+}
+
+// NewElector creates an Elector competing, with the given identity, for the given Lease. The leader keeps the
+// lease as long as it renews it before the lease duration elapses.
+func NewElector(
+	client LeaseClient,
+	namespace, name, identity string,
+	leaseDuration time.Duration,
+	logger *logrus.Logger,
+) *Elector {
+	return &Elector{
+		client:        client,
+		namespace:     namespace,
+		name:          name,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// IsLeader acquires the lease if it is free or has expired, or renews it if this instance already holds it. It
+// returns whether this instance is the leader.
+func (e *Elector) IsLeader() (bool, error) {
+	now := metav1.NewMicroTime(e.now())
+	spec := LeaseSpec{
+		HolderIdentity:       e.identity,
+		LeaseDurationSeconds: int32(e.leaseDuration / time.Second),
+		AcquireTime:          &now,
+		RenewTime:            &now,
+	}
+
+	lease, err := e.get()
+	if errors.IsNotFound(err) {
+		lease = &Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: e.namespace, Name: e.name},
+			Spec:       spec,
+		}
+		_, err = e.client.Create(lease)
+		if errors.IsAlreadyExists(err) || errors.IsConflict(err) {
+			// Another instance created it first.
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("creating the lease %s: %v", e, err)
+		}
+		e.logger.Debugf("Acquired the lease %s", e)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current := lease.Spec
+	if current.HolderIdentity != e.identity && current.HolderIdentity != "" && !e.expired(current) {
+		e.logger.Debugf("The lease %s is held by %s", e, current.HolderIdentity)
+		return false, nil
+	}
+
+	if current.HolderIdentity == e.identity {
+		spec.AcquireTime = current.AcquireTime
+		spec.LeaseTransitions = current.LeaseTransitions
+	} else {
+		spec.LeaseTransitions = current.LeaseTransitions + 1
+	}
+
+	// The update fails if another instance has updated the lease since it was read, so only one of them wins.
+	lease.Spec = spec
+	if err := e.update(lease); err != nil {
+		if errors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if current.HolderIdentity != e.identity {
+		e.logger.Debugf("Acquired the lease %s", e)
+	}
+	return true, nil
+}
+
+// Release frees the lease, if this instance holds it, so another instance can take over without waiting for it to
+// expire.
+func (e *Elector) Release() error {
+	lease, err := e.get()
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity != e.identity {
+		return nil
+	}
+
+	lease.Spec.HolderIdentity = ""
+	return e.update(lease)
+}
+
+// String returns the namespace and name of the Lease.
+func (e *Elector) String() string {
+	return e.namespace + "/" + e.name
+}
+
+func (e *Elector) get() (*Lease, error) {
+	lease, err := e.client.Get(e.namespace, e.name)
+	if errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting the lease %s: %v", e, err)
+	}
+	return lease, nil
+}
+
+func (e *Elector) update(lease *Lease) error {
+	_, err := e.client.Update(lease)
+	if err != nil && !errors.IsConflict(err) {
+		return fmt.Errorf("updating the lease %s: %v", e, err)
+	}
+	return err
+}
+
+func (e *Elector) expired(spec LeaseSpec) bool {
+	if spec.RenewTime == nil {
+		return true
+	}
+	leaseDuration := time.Duration(spec.LeaseDurationSeconds) * time.Second
+	return spec.RenewTime.Add(leaseDuration).Before(e.now())
+}
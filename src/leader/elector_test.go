@@ -0,0 +1,189 @@
+package leader
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	namespace = "newrelic"
+	leaseName = "nri-kubernetes-leader"
+)
+
+var (
+	logger = logrus.StandardLogger()
+	leases = schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}
+)
+
+// clock is shared by the electors of a test, as if the clocks of their nodes were synchronized.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+// fakeLeases keeps the Leases in memory. Like the API server, it rejects the updates of Leases modified since they
+// were read.
+type fakeLeases struct {
+	leases  map[string]Lease
+	version int
+	// err is returned by every call when set.
+	err error
+	// conflict makes the next update fail as if another instance updated the lease first.
+	conflict bool
+}
+
+func newFakeLeases() *fakeLeases {
+	return &fakeLeases{leases: map[string]Lease{}}
+}
+
+func (f *fakeLeases) Get(namespace, name string) (*Lease, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	l, ok := f.leases[namespace+"/"+name]
+	if !ok {
+		return nil, errors.NewNotFound(leases, name)
+	}
+	return &l, nil
+}
+
+func (f *fakeLeases) Create(lease *Lease) (*Lease, error) {
+	key := lease.Namespace + "/" + lease.Name
+	if _, ok := f.leases[key]; ok {
+		return nil, errors.NewAlreadyExists(leases, lease.Name)
+	}
+	return f.store(key, *lease), nil
+}
+
+func (f *fakeLeases) Update(lease *Lease) (*Lease, error) {
+	key := lease.Namespace + "/" + lease.Name
+	if f.conflict || f.leases[key].ResourceVersion != lease.ResourceVersion {
+		f.conflict = false
+		return nil, errors.NewConflict(leases, lease.Name, nil)
+	}
+	return f.store(key, *lease), nil
+}
+
+func (f *fakeLeases) store(key string, l Lease) *Lease {
+	f.version++
+	l.ResourceVersion = strconv.Itoa(f.version)
+	f.leases[key] = l
+	return &l
+}
+
+func (f *fakeLeases) current(t *testing.T) LeaseSpec {
+	l, ok := f.leases[namespace+"/"+leaseName]
+	require.True(t, ok)
+	return l.Spec
+}
+
+func newElector(client *fakeLeases, identity string, c *clock) *Elector {
+	e := NewElector(client, namespace, leaseName, identity, time.Minute, logger)
+	e.now = c.Now
+	return e
+}
+
+func TestElector(t *testing.T) {
+	client := newFakeLeases()
+	c := &clock{now: time.Unix(1000, 0)}
+	node1 := newElector(client, "node-1", c)
+	node2 := newElector(client, "node-2", c)
+
+	// The first instance creates the lease
+	leader, err := node1.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+	assert.Equal(t, "node-1", client.current(t).HolderIdentity)
+	assert.Equal(t, int32(60), client.current(t).LeaseDurationSeconds)
+
+	// The rest of instances are not leaders while the lease is valid
+	c.now = c.now.Add(30 * time.Second)
+	leader, err = node2.IsLeader()
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	// The leader renews the lease
+	leader, err = node1.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+	r := client.current(t)
+	assert.Equal(t, int64(1000), r.AcquireTime.Unix())
+	assert.Equal(t, int64(1030), r.RenewTime.Unix())
+	assert.Equal(t, int32(0), r.LeaseTransitions)
+
+	c.now = c.now.Add(45 * time.Second)
+	leader, err = node2.IsLeader()
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	// Another instance takes over once the lease expires
+	c.now = c.now.Add(30 * time.Second)
+	leader, err = node2.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+	r = client.current(t)
+	assert.Equal(t, "node-2", r.HolderIdentity)
+	assert.Equal(t, int32(1), r.LeaseTransitions)
+
+	leader, err = node1.IsLeader()
+	require.NoError(t, err)
+	assert.False(t, leader)
+}
+
+func TestElector_Release(t *testing.T) {
+	client := newFakeLeases()
+	c := &clock{now: time.Unix(1000, 0)}
+	node1 := newElector(client, "node-1", c)
+	node2 := newElector(client, "node-2", c)
+
+	leader, err := node1.IsLeader()
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	// Releasing a lease held by another instance does nothing
+	require.NoError(t, node2.Release())
+	assert.Equal(t, "node-1", client.current(t).HolderIdentity)
+
+	require.NoError(t, node1.Release())
+	assert.Empty(t, client.current(t).HolderIdentity)
+
+	// A released lease can be acquired without waiting for it to expire
+	leader, err = node2.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+}
+
+func TestElector_ConflictingUpdate(t *testing.T) {
+	client := newFakeLeases()
+	c := &clock{now: time.Unix(1000, 0)}
+	node1 := newElector(client, "node-1", c)
+	leader, err := node1.IsLeader()
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	// Another instance updates the lease between the read and the update
+	client.conflict = true
+	leader, err = node1.IsLeader()
+	require.NoError(t, err)
+	assert.False(t, leader)
+}
+
+func TestElector_Error(t *testing.T) {
+	client := newFakeLeases()
+	client.err = errors.NewForbidden(leases, leaseName, nil)
+	c := &clock{now: time.Unix(1000, 0)}
+
+	leader, err := newElector(client, "node-1", c).IsLeader()
+	assert.Error(t, err)
+	assert.False(t, leader)
+}
@@ -0,0 +1,96 @@
+package leader
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// leasesPath is the path of the coordination.k8s.io/v1 API, served by Kubernetes 1.14 and later.
+const leasesPath = "/apis/coordination.k8s.io/v1"
+
+// Lease is a coordination.k8s.io/v1 Lease, with the fields of its spec used by the election. The Kubernetes client
+// vendored by the integration predates the coordination.k8s.io API group, so the Lease is defined here and read and
+// written as JSON.
+type Lease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              LeaseSpec `json:"spec,omitempty"`
+}
+
+// LeaseSpec is the specification of a Lease.
+type LeaseSpec struct {
+	HolderIdentity       string            `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int32             `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *metav1.MicroTime `json:"acquireTime,omitempty"`
+	RenewTime            *metav1.MicroTime `json:"renewTime,omitempty"`
+	LeaseTransitions     int32             `json:"leaseTransitions,omitempty"`
+}
+
+// LeaseClient gets, creates and updates Leases. The errors returned are the ones of the Kubernetes API, so they
+// can be checked with the k8s.io/apimachinery/pkg/api/errors functions.
+type LeaseClient interface {
+	Get(namespace, name string) (*Lease, error)
+	Create(lease *Lease) (*Lease, error)
+	Update(lease *Lease) (*Lease, error)
+}
+
+type restLeaseClient struct {
+	client rest.Interface
+}
+
+// NewLeaseClient creates a LeaseClient calling the Kubernetes API with the given REST client, like the one of
+// the core/v1 client of a Clientset.
+func NewLeaseClient(client rest.Interface) LeaseClient {
+	return &restLeaseClient{client: client}
+}
+
+func (c *restLeaseClient) Get(namespace, name string) (*Lease, error) {
+	return c.do(c.client.Get().AbsPath(leasesPath, "namespaces", namespace, "leases", name))
+}
+
+func (c *restLeaseClient) Create(lease *Lease) (*Lease, error) {
+	req, err := c.withBody(c.client.Post().AbsPath(leasesPath, "namespaces", lease.Namespace, "leases"), lease)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+func (c *restLeaseClient) Update(lease *Lease) (*Lease, error) {
+	req, err := c.withBody(c.client.Put().AbsPath(leasesPath, "namespaces", lease.Namespace, "leases", lease.Name), lease)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+func (c *restLeaseClient) withBody(req *rest.Request, lease *Lease) (*rest.Request, error) {
+	l := *lease
+	l.APIVersion = "coordination.k8s.io/v1"
+	l.Kind = "Lease"
+	body, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return req.SetHeader("Content-Type", "application/json").Body(body), nil
+}
+
+func (c *restLeaseClient) do(req *rest.Request) (*Lease, error) {
+	result := req.SetHeader("Accept", "application/json").Do()
+	if err := result.Error(); err != nil {
+		return nil, err
+	}
+	raw, err := result.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	var lease Lease
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		return nil, fmt.Errorf("decoding the lease: %v", err)
+	}
+	return &lease, nil
+}
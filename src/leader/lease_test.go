@@ -0,0 +1,184 @@
+package leader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// apiServer serves a single Lease under the coordination.k8s.io/v1 API, replying with a Status on errors. Like the
+// API server, it sets the resourceVersion of the Lease on every write and rejects the updates of a Lease modified
+// since it was read.
+type apiServer struct {
+	lease    *Lease
+	version  int
+	requests []string
+	// sent is the body of the last write.
+	sent []byte
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		if s.lease == nil {
+			s.status(w, http.StatusNotFound, "NotFound")
+			return
+		}
+		s.write(w, s.lease)
+	case http.MethodPost, http.MethodPut:
+		s.sent, _ = ioutil.ReadAll(r.Body)
+		var lease Lease
+		if err := json.Unmarshal(s.sent, &lease); err != nil {
+			s.status(w, http.StatusBadRequest, "BadRequest")
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && s.lease != nil:
+			s.status(w, http.StatusConflict, "AlreadyExists")
+			return
+		case r.Method == http.MethodPut && s.lease == nil:
+			s.status(w, http.StatusNotFound, "NotFound")
+			return
+		case r.Method == http.MethodPut && lease.ResourceVersion != s.lease.ResourceVersion:
+			s.status(w, http.StatusConflict, "Conflict")
+			return
+		}
+		s.version++
+		lease.ResourceVersion = strconv.Itoa(s.version)
+		s.lease = &lease
+		s.write(w, s.lease)
+	default:
+		s.status(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *apiServer) status(w http.ResponseWriter, code int, reason string) {
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":%q,"code":%d,`+ // nolint: errcheck
+		`"details":{"name":%q,"group":"coordination.k8s.io","kind":"leases"}}`, reason, code, leaseName)
+}
+
+func (s *apiServer) write(w http.ResponseWriter, lease *Lease) {
+	json.NewEncoder(w).Encode(lease) // nolint: errcheck
+}
+
+func newRESTLeaseClient(t *testing.T, server *httptest.Server) LeaseClient {
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return NewLeaseClient(clientset.CoreV1().RESTClient())
+}
+
+func TestLeaseClient(t *testing.T) {
+	s := &apiServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	client := newRESTLeaseClient(t, server)
+
+	_, err := client.Get(namespace, leaseName)
+	assert.True(t, errors.IsNotFound(err))
+
+	renewTime := metav1.NewMicroTime(time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC))
+	created, err := client.Create(&Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: leaseName},
+		Spec:       LeaseSpec{HolderIdentity: "node-1", LeaseDurationSeconds: 60, RenewTime: &renewTime},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", created.Spec.HolderIdentity)
+	assert.Equal(t, "1", created.ResourceVersion)
+
+	var sent map[string]interface{}
+	require.NoError(t, json.Unmarshal(s.sent, &sent))
+	assert.Equal(t, "coordination.k8s.io/v1", sent["apiVersion"])
+	assert.Equal(t, "Lease", sent["kind"])
+	assert.Equal(t, map[string]interface{}{
+		"holderIdentity":       "node-1",
+		"leaseDurationSeconds": float64(60),
+		"renewTime":            "2020-01-02T03:04:05.000006Z",
+	}, sent["spec"])
+
+	// Another instance creating the lease at the same time fails
+	_, err = client.Create(&Lease{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: leaseName}})
+	assert.True(t, errors.IsAlreadyExists(err))
+
+	lease, err := client.Get(namespace, leaseName)
+	require.NoError(t, err)
+	assert.Equal(t, "1", lease.ResourceVersion)
+	assert.Equal(t, int32(60), lease.Spec.LeaseDurationSeconds)
+	assert.True(t, renewTime.Equal(lease.Spec.RenewTime))
+
+	// The update sends the resourceVersion of the lease that was read
+	lease.Spec.HolderIdentity = "node-2"
+	updated, err := client.Update(lease)
+	require.NoError(t, err)
+	assert.Equal(t, "node-2", updated.Spec.HolderIdentity)
+	assert.Equal(t, "2", updated.ResourceVersion)
+	sent = nil
+	require.NoError(t, json.Unmarshal(s.sent, &sent))
+	assert.Equal(t, "1", sent["metadata"].(map[string]interface{})["resourceVersion"])
+
+	// Updating the lease as it was read before the last update conflicts, and keeps the stored lease
+	lease.Spec.HolderIdentity = "node-3"
+	_, err = client.Update(lease)
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, "node-2", s.lease.Spec.HolderIdentity)
+
+	assert.Equal(t, []string{
+		"GET /apis/coordination.k8s.io/v1/namespaces/newrelic/leases/nri-kubernetes-leader",
+		"POST /apis/coordination.k8s.io/v1/namespaces/newrelic/leases",
+		"POST /apis/coordination.k8s.io/v1/namespaces/newrelic/leases",
+		"GET /apis/coordination.k8s.io/v1/namespaces/newrelic/leases/nri-kubernetes-leader",
+		"PUT /apis/coordination.k8s.io/v1/namespaces/newrelic/leases/nri-kubernetes-leader",
+		"PUT /apis/coordination.k8s.io/v1/namespaces/newrelic/leases/nri-kubernetes-leader",
+	}, s.requests)
+}
+
+func TestElector_APIServer(t *testing.T) {
+	s := &apiServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	c := &clock{now: time.Unix(1000, 0)}
+	elector := func(identity string) *Elector {
+		e := NewElector(newRESTLeaseClient(t, server), namespace, leaseName, identity, time.Minute, logger)
+		e.now = c.Now
+		return e
+	}
+	node1 := elector("node-1")
+	node2 := elector("node-2")
+
+	leader, err := node1.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, err = node2.IsLeader()
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	// The leader renews the lease it read, and the other instance takes over once it expires
+	leader, err = node1.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+	assert.Equal(t, "2", s.lease.ResourceVersion)
+
+	c.now = c.now.Add(2 * time.Minute)
+	leader, err = node2.IsLeader()
+	require.NoError(t, err)
+	assert.True(t, leader)
+	assert.Equal(t, "node-2", s.lease.Spec.HolderIdentity)
+	assert.Equal(t, int32(1), s.lease.Spec.LeaseTransitions)
+}
@@ -0,0 +1,131 @@
+package metric
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/nri-kubernetes/src/config"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+var sourceTypes = map[string]sdkMetric.SourceType{
+	"GAUGE":     sdkMetric.GAUGE,
+	"RATE":      sdkMetric.RATE,
+	"DELTA":     sdkMetric.DELTA,
+	"ATTRIBUTE": sdkMetric.ATTRIBUTE,
+}
+
+// NewCustomSpec creates the metric specification declared by the given MetricSpec. Values are fetched from
+// Prometheus metrics when prometheusSource is true, and from the raw data otherwise.
+func NewCustomSpec(s config.MetricSpec, prometheusSource bool) (definition.Spec, error) {
+	sourceType, ok := sourceTypes[strings.ToUpper(s.Type)]
+	if !ok {
+		return definition.Spec{}, fmt.Errorf("unknown type %q for metric %s", s.Type, s.Name)
+	}
+
+	spec := definition.Spec{
+		Name:     s.Name,
+		Type:     sourceType,
+		Optional: s.Optional,
+	}
+
+	switch {
+	case !prometheusSource:
+		spec.ValueFunc = definition.FromRaw(s.Source)
+	case s.Label != "":
+		spec.ValueFunc = prometheus.FromLabelValue(s.Source, s.Label)
+	default:
+		var filters []prometheus.LabelsFilter
+		if len(s.IncludeLabels) > 0 {
+			filters = append(filters, prometheus.IncludeOnlyLabelsFilter(s.IncludeLabels...))
+		}
+		if len(s.IgnoreLabels) > 0 {
+			filters = append(filters, prometheus.IgnoreLabelsFilter(s.IgnoreLabels...))
+		}
+		spec.ValueFunc = prometheus.FromValueWithOverriddenName(s.Source, s.Name, filters...)
+	}
+
+	return spec, nil
+}
+
+// WithCustomSpecs returns a copy of the given spec groups with the custom specs appended to the group they are
+// indexed by. The given spec groups are not modified. Custom specs can only be added to existing groups, and
+// they can not redefine a metric already present in the group.
+func WithCustomSpecs(
+	specGroups definition.SpecGroups,
+	custom map[string][]config.MetricSpec,
+	prometheusSource bool,
+) (definition.SpecGroups, error) {
+	merged := make(definition.SpecGroups, len(specGroups))
+	for name, group := range specGroups {
+		merged[name] = group
+	}
+
+	for groupName, customSpecs := range custom {
+		group, ok := merged[groupName]
+		if !ok {
+			return nil, fmt.Errorf("unknown spec group %q for custom metrics", groupName)
+		}
+
+		defined := make(map[string]bool, len(group.Specs))
+		for _, s := range group.Specs {
+			defined[s.Name] = true
+		}
+
+		specs := make([]definition.Spec, len(group.Specs), len(group.Specs)+len(customSpecs))
+		copy(specs, group.Specs)
+		for _, cs := range customSpecs {
+			if defined[cs.Name] {
+				return nil, fmt.Errorf("metric %s is already defined in spec group %s", cs.Name, groupName)
+			}
+			defined[cs.Name] = true
+
+			spec, err := NewCustomSpec(cs, prometheusSource)
+			if err != nil {
+				return nil, fmt.Errorf("spec group %s: %v", groupName, err)
+			}
+			specs = append(specs, spec)
+		}
+
+		group.Specs = specs
+		merged[groupName] = group
+	}
+
+	return merged, nil
+}
+
+// WithCustomQueries returns a copy of the given queries including a query for the source of each one of the
+// custom specs that is not queried yet.
+func WithCustomQueries(queries []prometheus.Query, custom map[string][]config.MetricSpec) []prometheus.Query {
+	queried := make(map[string]bool, len(queries))
+	for _, q := range queries {
+		queried[q.MetricName] = true
+	}
+
+	merged := make([]prometheus.Query, len(queries))
+	copy(merged, queries)
+	for _, groupName := range sortedGroupNames(custom) {
+		for _, cs := range custom[groupName] {
+			if queried[cs.Source] {
+				continue
+			}
+			queried[cs.Source] = true
+			merged = append(merged, prometheus.Query{MetricName: cs.Source})
+		}
+	}
+
+	return merged
+}
+
+// sortedGroupNames returns the group names in a stable order, so queries are always added in the same order.
+func sortedGroupNames(custom map[string][]config.MetricSpec) []string {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,183 @@
+package metric
+
+import (
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/nri-kubernetes/src/config"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var customRawGroups = definition.RawGroups{
+	"deployment": {
+		"kube-system_coredns": definition.RawMetrics{
+			"kube_deployment_spec_paused": prometheus.Metric{
+				Value:  prometheus.GaugeValue(0),
+				Labels: prometheus.Labels{"deployment": "coredns", "namespace": "kube-system"},
+			},
+			"kube_deployment_status_condition": []prometheus.Metric{
+				{
+					Value:  prometheus.GaugeValue(1),
+					Labels: prometheus.Labels{"condition": "Available", "status": "true", "deployment": "coredns"},
+				},
+				{
+					Value:  prometheus.GaugeValue(1),
+					Labels: prometheus.Labels{"condition": "Progressing", "status": "true", "deployment": "coredns"},
+				},
+			},
+			"uptime": 42,
+		},
+	},
+}
+
+func TestNewCustomSpec(t *testing.T) {
+	testCases := []struct {
+		name             string
+		spec             config.MetricSpec
+		prometheusSource bool
+		expectedType     sdkMetric.SourceType
+		expectedValue    definition.FetchedValue
+	}{
+		{
+			name:             "prometheus value",
+			spec:             config.MetricSpec{Name: "paused", Source: "kube_deployment_spec_paused", Type: "gauge"},
+			prometheusSource: true,
+			expectedType:     sdkMetric.GAUGE,
+			expectedValue:    prometheus.GaugeValue(0),
+		},
+		{
+			name: "prometheus value with label filters",
+			spec: config.MetricSpec{
+				Name:          "condition",
+				Source:        "kube_deployment_status_condition",
+				Type:          "GAUGE",
+				IncludeLabels: []string{"condition"},
+			},
+			prometheusSource: true,
+			expectedType:     sdkMetric.GAUGE,
+			expectedValue: definition.FetchedValues{
+				"condition_condition_Available":   prometheus.GaugeValue(1),
+				"condition_condition_Progressing": prometheus.GaugeValue(1),
+			},
+		},
+		{
+			name: "prometheus label value",
+			spec: config.MetricSpec{
+				Name:   "deploymentLabel",
+				Source: "kube_deployment_spec_paused",
+				Type:   "ATTRIBUTE",
+				Label:  "deployment",
+			},
+			prometheusSource: true,
+			expectedType:     sdkMetric.ATTRIBUTE,
+			expectedValue:    "coredns",
+		},
+		{
+			name:          "raw value",
+			spec:          config.MetricSpec{Name: "uptimeDelta", Source: "uptime", Type: "delta", Optional: true},
+			expectedType:  sdkMetric.DELTA,
+			expectedValue: 42,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := NewCustomSpec(tc.spec, tc.prometheusSource)
+			require.NoError(t, err)
+			assert.Equal(t, tc.spec.Name, spec.Name)
+			assert.Equal(t, tc.expectedType, spec.Type)
+			assert.Equal(t, tc.spec.Optional, spec.Optional)
+
+			value, err := spec.ValueFunc("deployment", "kube-system_coredns", customRawGroups)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+func TestNewCustomSpec_UnknownType(t *testing.T) {
+	_, err := NewCustomSpec(config.MetricSpec{Name: "paused", Source: "kube_deployment_spec_paused", Type: "COUNTER"}, true)
+	assert.EqualError(t, err, `unknown type "COUNTER" for metric paused`)
+}
+
+func TestWithCustomSpecs(t *testing.T) {
+	custom := map[string][]config.MetricSpec{
+		"deployment": {
+			{Name: "paused", Source: "kube_deployment_spec_paused", Type: "GAUGE"},
+		},
+	}
+
+	specs, err := WithCustomSpecs(KSMSpecs, custom, true)
+	require.NoError(t, err)
+
+	builtIn := len(KSMSpecs["deployment"].Specs)
+	require.Len(t, specs["deployment"].Specs, builtIn+1)
+	assert.Equal(t, "paused", specs["deployment"].Specs[builtIn].Name)
+	// the rest of the groups and the built-in specs are kept untouched
+	assert.Len(t, specs, len(KSMSpecs))
+	assert.Equal(t, len(KSMSpecs["pod"].Specs), len(specs["pod"].Specs))
+	assert.Len(t, KSMSpecs["deployment"].Specs, builtIn)
+}
+
+func TestWithCustomSpecs_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		custom map[string][]config.MetricSpec
+		err    string
+	}{
+		{
+			name: "unknown group",
+			custom: map[string][]config.MetricSpec{
+				"cronjob": {{Name: "createdAt", Source: "kube_cronjob_created", Type: "GAUGE"}},
+			},
+			err: `unknown spec group "cronjob" for custom metrics`,
+		},
+		{
+			name: "metric already defined",
+			custom: map[string][]config.MetricSpec{
+				"deployment": {{Name: "createdAt", Source: "kube_deployment_created", Type: "GAUGE"}},
+			},
+			err: "metric createdAt is already defined in spec group deployment",
+		},
+		{
+			name: "unknown type",
+			custom: map[string][]config.MetricSpec{
+				"deployment": {{Name: "paused", Source: "kube_deployment_spec_paused", Type: "COUNTER"}},
+			},
+			err: `spec group deployment: unknown type "COUNTER" for metric paused`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := WithCustomSpecs(KSMSpecs, tc.custom, true)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestWithCustomQueries(t *testing.T) {
+	queries := []prometheus.Query{{MetricName: "kube_deployment_created"}}
+	custom := map[string][]config.MetricSpec{
+		"replicaset": {
+			{Name: "replicasetCreated", Source: "kube_replicaset_created", Type: "GAUGE"},
+		},
+		"deployment": {
+			{Name: "paused", Source: "kube_deployment_spec_paused", Type: "GAUGE"},
+			{Name: "pausedLabel", Source: "kube_deployment_spec_paused", Type: "ATTRIBUTE", Label: "deployment"},
+			{Name: "created", Source: "kube_deployment_created", Type: "GAUGE"},
+		},
+	}
+
+	merged := WithCustomQueries(queries, custom)
+
+	assert.Equal(t, []prometheus.Query{
+		{MetricName: "kube_deployment_created"},
+		{MetricName: "kube_deployment_spec_paused"},
+		{MetricName: "kube_replicaset_created"},
+	}, merged)
+	assert.Len(t, queries, 1)
+}
@@ -523,6 +523,7 @@ var KSMSpecs = definition.SpecGroups{
 			{Name: "namespaceName", ValueFunc: prometheus.FromLabelValue("kube_namespace_created", "namespace"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "status", ValueFunc: prometheus.FromLabelValue("kube_namespace_status_phase", "phase"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "label.*", ValueFunc: prometheus.InheritAllLabelsFrom("namespace", "kube_namespace_labels"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "annotation.*", ValueFunc: prometheus.InheritAllAnnotationsFrom("namespace", "kube_namespace_annotations"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 		},
 	},
 	"deployment": {
@@ -542,6 +543,7 @@ var KSMSpecs = definition.SpecGroups{
 			// Important: The order of these lines is important: we could have the same label in different entities, and we would like to keep the value closer to deployment
 			{Name: "label.*", ValueFunc: prometheus.InheritAllLabelsFrom("namespace", "kube_namespace_labels"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "label.*", ValueFunc: prometheus.InheritAllLabelsFrom("deployment", "kube_deployment_labels"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "annotation.*", ValueFunc: prometheus.InheritAllAnnotationsFrom("deployment", "kube_deployment_annotations"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 		},
 	},
 	"service": {
@@ -657,6 +659,7 @@ var KSMSpecs = definition.SpecGroups{
 			{Name: "isScheduled", ValueFunc: definition.Transform(prometheus.FromLabelValue("kube_pod_status_scheduled", "condition"), toNumericBoolean), Type: sdkMetric.GAUGE},
 			{Name: "deploymentName", ValueFunc: ksmMetric.GetDeploymentNameForPod(), Type: sdkMetric.ATTRIBUTE},
 			{Name: "label.*", ValueFunc: prometheus.InheritAllLabelsFrom("pod", "kube_pod_labels"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "annotation.*", ValueFunc: prometheus.InheritAllAnnotationsFrom("pod", "kube_pod_annotations"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 		},
 	},
 }
@@ -697,6 +700,9 @@ var KSMQueries = []prometheus.Query{
 	{MetricName: "kube_namespace_labels", Value: prometheus.QueryValue{
 		Value: prometheus.GaugeValue(1),
 	}},
+	{MetricName: "kube_namespace_annotations", Value: prometheus.QueryValue{
+		Value: prometheus.GaugeValue(1),
+	}},
 	{MetricName: "kube_namespace_created"},
 	{MetricName: "kube_namespace_status_phase", Value: prometheus.QueryValue{
 		Value: prometheus.GaugeValue(1),
@@ -704,6 +710,9 @@ var KSMQueries = []prometheus.Query{
 	{MetricName: "kube_deployment_labels", Value: prometheus.QueryValue{
 		Value: prometheus.GaugeValue(1),
 	}},
+	{MetricName: "kube_deployment_annotations", Value: prometheus.QueryValue{
+		Value: prometheus.GaugeValue(1),
+	}},
 	{MetricName: "kube_deployment_created"},
 	{MetricName: "kube_deployment_spec_replicas"},
 	{MetricName: "kube_deployment_status_replicas"},
@@ -719,6 +728,7 @@ var KSMQueries = []prometheus.Query{
 	{MetricName: "kube_pod_info"},
 	{MetricName: "kube_pod_created"},
 	{MetricName: "kube_pod_labels"},
+	{MetricName: "kube_pod_annotations"},
 	{MetricName: "kube_pod_status_scheduled", Value: prometheus.QueryValue{
 		Value: prometheus.GaugeValue(1),
 	}},
@@ -753,6 +763,44 @@ var CadvisorQueries = []prometheus.Query{
 	{MetricName: "container_cpu_cfs_throttled_periods_total"},
 	{MetricName: "container_cpu_cfs_throttled_seconds_total"},
 	{MetricName: "container_memory_mapped_file"},
+	{MetricName: "container_memory_cache"},
+	{MetricName: "container_memory_rss"},
+	{MetricName: "container_memory_swap"},
+	{MetricName: "container_memory_failcnt"},
+	{MetricName: "container_oom_events_total"},
+	{MetricName: "container_fs_reads_bytes_total"},
+	{MetricName: "container_fs_writes_bytes_total"},
+	{MetricName: "container_fs_reads_total"},
+	{MetricName: "container_fs_writes_total"},
+	{MetricName: "container_network_receive_bytes_total"},
+	{MetricName: "container_network_transmit_bytes_total"},
+	{MetricName: "container_network_receive_errors_total"},
+	{MetricName: "container_network_transmit_errors_total"},
+	{MetricName: "container_network_receive_packets_dropped_total"},
+	{MetricName: "container_network_transmit_packets_dropped_total"},
+	{MetricName: "container_spec_cpu_period"},
+	{MetricName: "container_spec_cpu_quota"},
+	{MetricName: "container_spec_cpu_shares"},
+	{MetricName: "container_spec_memory_limit_bytes"},
+	{MetricName: "container_spec_memory_reservation_limit_bytes"},
+	{MetricName: "container_spec_memory_swap_limit_bytes"},
+}
+
+// KubeletQueries are the queries we will do to the kubelet metrics endpoint in order to fetch the metrics about
+// the health of the kubelet itself.
+var KubeletQueries = []prometheus.Query{
+	{MetricName: "kubelet_pleg_relist_duration_seconds"},
+	{MetricName: "kubelet_pleg_relist_interval_seconds"},
+	{MetricName: "kubelet_pod_start_duration_seconds"},
+	{MetricName: "kubelet_runtime_operations_total"},
+	{MetricName: "kubelet_runtime_operations_errors_total"},
+	{MetricName: "kubelet_runtime_operations_duration_seconds"},
+	{MetricName: "kubelet_evictions"},
+	{MetricName: "kubelet_running_pod_count"},
+	{MetricName: "kubelet_running_container_count"},
+	{MetricName: "process_resident_memory_bytes"},
+	{MetricName: "process_cpu_seconds_total"},
+	{MetricName: "go_goroutines"},
 }
 
 // KubeletSpecs are the metric specifications we want to collect from Kubelet.
@@ -765,6 +813,10 @@ var KubeletSpecs = definition.SpecGroups{
 			{Name: "net.rxBytesPerSecond", ValueFunc: kubeletMetric.FromRawWithFallbackToDefaultInterface("rxBytes"), Type: sdkMetric.RATE},
 			{Name: "net.txBytesPerSecond", ValueFunc: kubeletMetric.FromRawWithFallbackToDefaultInterface("txBytes"), Type: sdkMetric.RATE},
 			{Name: "net.errorsPerSecond", ValueFunc: kubeletMetric.FromRawWithFallbackToDefaultInterface("errors"), Type: sdkMetric.RATE},
+			{Name: "cpuUsedCores", ValueFunc: definition.Transform(definition.FromRaw("usageNanoCores"), fromNano), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "memoryUsedBytes", ValueFunc: definition.FromRaw("memoryUsageBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "memoryWorkingSetBytes", ValueFunc: definition.FromRaw("memoryWorkingSetBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "ephemeralStorageUsedBytes", ValueFunc: definition.FromRaw("ephemeralStorageUsedBytes"), Type: sdkMetric.GAUGE, Optional: true},
 
 			// /pods endpoint
 			{Name: "createdAt", ValueFunc: definition.Transform(definition.FromRaw("createdAt"), toTimestamp), Type: sdkMetric.GAUGE},
@@ -780,7 +832,16 @@ var KubeletSpecs = definition.SpecGroups{
 			{Name: "status", ValueFunc: definition.FromRaw("status"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "isScheduled", ValueFunc: definition.Transform(definition.FromRaw("isScheduled"), toNumericBoolean), Type: sdkMetric.GAUGE},
 			{Name: "deploymentName", ValueFunc: definition.FromRaw("deploymentName"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "ownerKind", ValueFunc: definition.FromRaw("ownerKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "ownerName", ValueFunc: definition.FromRaw("ownerName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "topOwnerKind", ValueFunc: definition.FromRaw("topOwnerKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "topOwnerName", ValueFunc: definition.FromRaw("topOwnerName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "statefulsetName", ValueFunc: definition.FromRaw("statefulsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "daemonsetName", ValueFunc: definition.FromRaw("daemonsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "jobName", ValueFunc: definition.FromRaw("jobName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "cronjobName", ValueFunc: definition.FromRaw("cronjobName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 			{Name: "label.*", ValueFunc: definition.Transform(definition.FromRaw("labels"), kubeletMetric.OneMetricPerLabel), Type: sdkMetric.ATTRIBUTE},
+			{Name: "annotation.*", ValueFunc: definition.Transform(definition.FromRaw("annotations"), kubeletMetric.OneMetricPerAnnotation), Type: sdkMetric.ATTRIBUTE, Optional: true},
 			{Name: "reason", ValueFunc: definition.FromRaw("reason"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "message", ValueFunc: definition.FromRaw("message"), Type: sdkMetric.ATTRIBUTE},
 		},
@@ -804,6 +865,7 @@ var KubeletSpecs = definition.SpecGroups{
 			// /metrics/cadvisor endpoint
 			{Name: "containerID", ValueFunc: definition.FromRaw("containerID"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "containerImageID", ValueFunc: definition.FromRaw("containerImageID"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "containerRuntime", ValueFunc: definition.FromRaw("containerRuntime"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 			{Name: "containerCpuCfsPeriodsDelta", ValueFunc: definition.FromRaw("container_cpu_cfs_periods_total"), Type: sdkMetric.DELTA, Optional: true},
 			{Name: "containerCpuCfsThrottledPeriodsDelta", ValueFunc: definition.FromRaw("container_cpu_cfs_throttled_periods_total"), Type: sdkMetric.DELTA, Optional: true},
 			{Name: "containerCpuCfsThrottledSecondsDelta", ValueFunc: definition.FromRaw("container_cpu_cfs_throttled_seconds_total"), Type: sdkMetric.DELTA, Optional: true},
@@ -811,11 +873,40 @@ var KubeletSpecs = definition.SpecGroups{
 			{Name: "containerCpuCfsThrottledPeriodsTotal", ValueFunc: definition.FromRaw("container_cpu_cfs_throttled_periods_total"), Type: sdkMetric.GAUGE, Optional: true},
 			{Name: "containerCpuCfsThrottledSecondsTotal", ValueFunc: definition.FromRaw("container_cpu_cfs_throttled_seconds_total"), Type: sdkMetric.GAUGE, Optional: true},
 			{Name: "containerMemoryMappedFileBytes", ValueFunc: definition.FromRaw("container_memory_mapped_file"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerMemoryCacheBytes", ValueFunc: definition.FromRaw("container_memory_cache"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerMemoryRssBytes", ValueFunc: definition.FromRaw("container_memory_rss"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerMemorySwapBytes", ValueFunc: definition.FromRaw("container_memory_swap"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerMemoryFailCountDelta", ValueFunc: definition.FromRaw("container_memory_failcnt"), Type: sdkMetric.DELTA, Optional: true},
+			{Name: "containerOOMEventsDelta", ValueFunc: definition.FromRaw("container_oom_events_total"), Type: sdkMetric.DELTA, Optional: true},
+			{Name: "containerFsReadBytesPerSecond", ValueFunc: definition.FromRaw("container_fs_reads_bytes_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerFsWriteBytesPerSecond", ValueFunc: definition.FromRaw("container_fs_writes_bytes_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerFsReadsPerSecond", ValueFunc: definition.FromRaw("container_fs_reads_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerFsWritesPerSecond", ValueFunc: definition.FromRaw("container_fs_writes_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerNetRxBytesPerSecond", ValueFunc: definition.FromRaw("container_network_receive_bytes_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerNetTxBytesPerSecond", ValueFunc: definition.FromRaw("container_network_transmit_bytes_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerNetRxErrorsPerSecond", ValueFunc: definition.FromRaw("container_network_receive_errors_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerNetTxErrorsPerSecond", ValueFunc: definition.FromRaw("container_network_transmit_errors_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerNetRxDroppedPerSecond", ValueFunc: definition.FromRaw("container_network_receive_packets_dropped_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerNetTxDroppedPerSecond", ValueFunc: definition.FromRaw("container_network_transmit_packets_dropped_total"), Type: sdkMetric.RATE, Optional: true},
+			{Name: "containerSpecCpuLimitCores", ValueFunc: cpuQuotaToCores("container_spec_cpu_quota", "container_spec_cpu_period"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerSpecCpuShares", ValueFunc: definition.FromRaw("container_spec_cpu_shares"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerSpecMemoryLimitBytes", ValueFunc: definition.FromRaw("container_spec_memory_limit_bytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerSpecMemoryReservationLimitBytes", ValueFunc: definition.FromRaw("container_spec_memory_reservation_limit_bytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "containerSpecMemorySwapLimitBytes", ValueFunc: definition.FromRaw("container_spec_memory_swap_limit_bytes"), Type: sdkMetric.GAUGE, Optional: true},
 
 			// /pods endpoint
 			{Name: "containerName", ValueFunc: definition.FromRaw("containerName"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "containerImage", ValueFunc: definition.FromRaw("containerImage"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "containerType", ValueFunc: definition.FromRaw("containerType"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "deploymentName", ValueFunc: definition.FromRaw("deploymentName"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "ownerKind", ValueFunc: definition.FromRaw("ownerKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "ownerName", ValueFunc: definition.FromRaw("ownerName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "topOwnerKind", ValueFunc: definition.FromRaw("topOwnerKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "topOwnerName", ValueFunc: definition.FromRaw("topOwnerName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "statefulsetName", ValueFunc: definition.FromRaw("statefulsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "daemonsetName", ValueFunc: definition.FromRaw("daemonsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "jobName", ValueFunc: definition.FromRaw("jobName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "cronjobName", ValueFunc: definition.FromRaw("cronjobName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 			{Name: "namespace", ValueFunc: definition.FromRaw("namespace"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "namespaceName", ValueFunc: definition.FromRaw("namespace"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "podName", ValueFunc: definition.FromRaw("podName"), Type: sdkMetric.ATTRIBUTE},
@@ -829,6 +920,16 @@ var KubeletSpecs = definition.SpecGroups{
 			{Name: "status", ValueFunc: definition.FromRaw("status"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "isReady", ValueFunc: definition.Transform(definition.FromRaw("isReady"), toNumericBoolean), Type: sdkMetric.GAUGE},
 			{Name: "reason", ValueFunc: definition.FromRaw("reason"), Type: sdkMetric.ATTRIBUTE}, // Previously called statusWaitingReason
+			{Name: "restartCountDelta", ValueFunc: definition.FromRaw("restartCount"), Type: sdkMetric.DELTA, Optional: true},
+			{Name: "startedAt", ValueFunc: definition.Transform(definition.FromRaw("startedAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "exitCode", ValueFunc: definition.FromRaw("exitCode"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "signal", ValueFunc: definition.FromRaw("signal"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "finishedAt", ValueFunc: definition.Transform(definition.FromRaw("finishedAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "lastTerminatedReason", ValueFunc: definition.FromRaw("lastTerminatedReason"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "lastTerminatedExitCode", ValueFunc: definition.FromRaw("lastTerminatedExitCode"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "lastTerminatedSignal", ValueFunc: definition.FromRaw("lastTerminatedSignal"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "lastTerminatedFinishedAt", ValueFunc: definition.Transform(definition.FromRaw("lastTerminatedFinishedAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "isOOMKilled", ValueFunc: definition.Transform(definition.FromRaw("isOOMKilled"), toNumericBoolean), Type: sdkMetric.GAUGE, Optional: true},
 
 			// Inherit from pod
 			{Name: "label.*", ValueFunc: definition.Transform(definition.FromRaw("labels"), kubeletMetric.OneMetricPerLabel), Type: sdkMetric.ATTRIBUTE},
@@ -861,9 +962,44 @@ var KubeletSpecs = definition.SpecGroups{
 			{Name: "runtimeInodesFree", ValueFunc: definition.FromRaw("runtimeInodesFree"), Type: sdkMetric.GAUGE},
 			{Name: "runtimeInodes", ValueFunc: definition.FromRaw("runtimeInodes"), Type: sdkMetric.GAUGE},
 			{Name: "runtimeInodesUsed", ValueFunc: definition.FromRaw("runtimeInodesUsed"), Type: sdkMetric.GAUGE},
+			{Name: "kubeletCpuUsedCores", ValueFunc: definition.Transform(definition.FromRaw("kubeletUsageNanoCores"), fromNano), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "kubeletMemoryUsedBytes", ValueFunc: definition.FromRaw("kubeletMemoryUsageBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "kubeletMemoryWorkingSetBytes", ValueFunc: definition.FromRaw("kubeletMemoryWorkingSetBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "runtimeCpuUsedCores", ValueFunc: definition.Transform(definition.FromRaw("runtimeUsageNanoCores"), fromNano), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "runtimeMemoryUsedBytes", ValueFunc: definition.FromRaw("runtimeMemoryUsageBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "runtimeMemoryWorkingSetBytes", ValueFunc: definition.FromRaw("runtimeMemoryWorkingSetBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "podsCgroupCpuUsedCores", ValueFunc: definition.Transform(definition.FromRaw("podsCgroupUsageNanoCores"), fromNano), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "podsCgroupMemoryUsedBytes", ValueFunc: definition.FromRaw("podsCgroupMemoryUsageBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "podsCgroupMemoryWorkingSetBytes", ValueFunc: definition.FromRaw("podsCgroupMemoryWorkingSetBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "processesRunning", ValueFunc: definition.FromRaw("runningProcesses"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "maxPid", ValueFunc: definition.FromRaw("maxPid"), Type: sdkMetric.GAUGE, Optional: true},
 			{Name: "label.*", ValueFunc: definition.Transform(definition.FromRaw("labels"), kubeletMetric.OneMetricPerLabel), Type: sdkMetric.ATTRIBUTE},
 			{Name: "allocatable.*", ValueFunc: definition.Transform(definition.FromRaw("allocatable"), kubeletMetric.OneAttributePerAllocatable), Type: sdkMetric.GAUGE},
 			{Name: "capacity.*", ValueFunc: definition.Transform(definition.FromRaw("capacity"), kubeletMetric.OneAttributePerCapacity), Type: sdkMetric.GAUGE},
+			{Name: "condition.*", ValueFunc: definition.Transform(definition.FromRaw("conditions"), kubeletMetric.OneMetricPerCondition), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "conditionLastTransitionTime.*", ValueFunc: definition.Transform(definition.FromRaw("conditions"), kubeletMetric.OneMetricPerConditionTransition), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "taint.*", ValueFunc: definition.Transform(definition.FromRaw("taints"), kubeletMetric.OneAttributePerTaint), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "unschedulable", ValueFunc: definition.Transform(definition.FromRaw("unschedulable"), toNumericBoolean), Type: sdkMetric.GAUGE, Optional: true},
+		},
+	},
+	// /metrics endpoint
+	"kubelet": {
+		TypeGenerator: kubeletMetric.FromRawGroupsEntityTypeGenerator,
+		Specs: []definition.Spec{
+			{Name: "nodeName", ValueFunc: definition.FromRaw("nodeName"), Type: sdkMetric.ATTRIBUTE},
+			{Name: "plegRelistDurationSeconds", ValueFunc: prometheus.FromHistogram("kubelet_pleg_relist_duration_seconds"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "plegRelistIntervalSeconds", ValueFunc: prometheus.FromHistogram("kubelet_pleg_relist_interval_seconds"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "podStartDurationSeconds", ValueFunc: prometheus.FromHistogram("kubelet_pod_start_duration_seconds"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "runtimeOperationsDelta", ValueFunc: prometheus.FromValueWithOverriddenName("kubelet_runtime_operations_total", "runtimeOperationsDelta"), Type: sdkMetric.DELTA, Optional: true},
+			{Name: "runtimeOperationsErrorsDelta", ValueFunc: prometheus.FromValueWithOverriddenName("kubelet_runtime_operations_errors_total", "runtimeOperationsErrorsDelta"), Type: sdkMetric.DELTA, Optional: true},
+			// Only the totals, as there are buckets for every operation type
+			{Name: "runtimeOperationsDurationSeconds", ValueFunc: prometheus.FromHistogramTotals("kubelet_runtime_operations_duration_seconds"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "evictionsDelta", ValueFunc: prometheus.FromValueWithOverriddenName("kubelet_evictions", "evictionsDelta"), Type: sdkMetric.DELTA, Optional: true},
+			{Name: "runningPods", ValueFunc: prometheus.FromValueWithOverriddenName("kubelet_running_pod_count", "runningPods"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "runningContainers", ValueFunc: prometheus.FromValueWithOverriddenName("kubelet_running_container_count", "runningContainers"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "processResidentMemoryBytes", ValueFunc: prometheus.FromValueWithOverriddenName("process_resident_memory_bytes", "processResidentMemoryBytes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "processCpuSecondsDelta", ValueFunc: prometheus.FromValueWithOverriddenName("process_cpu_seconds_total", "processCpuSecondsDelta"), Type: sdkMetric.DELTA, Optional: true},
+			{Name: "goGoroutines", ValueFunc: prometheus.FromValueWithOverriddenName("go_goroutines", "goGoroutines"), Type: sdkMetric.GAUGE, Optional: true},
 		},
 	},
 	"volume": {
@@ -923,6 +1059,33 @@ func toComplementPercentage(desiredMetric, complementMetric string) definition.F
 	}
 }
 
+// cpuQuotaToCores returns the CPU limit in cores of a container from its CFS quota and period, which cAdvisor
+// only reports for containers with a CPU limit.
+func cpuQuotaToCores(quotaMetric, periodMetric string) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		quota, err := definition.FromRaw(quotaMetric)(groupLabel, entityID, groups)
+		if err != nil {
+			return nil, err
+		}
+		period, err := definition.FromRaw(periodMetric)(groupLabel, entityID, groups)
+		if err != nil {
+			return nil, err
+		}
+		q, ok := quota.(prometheus.GaugeValue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %T for %s", quota, quotaMetric)
+		}
+		p, ok := period.(prometheus.GaugeValue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %T for %s", period, periodMetric)
+		}
+		if p <= 0 {
+			return nil, fmt.Errorf("error computing cpu limit from %s: division by zero", periodMetric)
+		}
+		return float64(q) / float64(p), nil
+	}
+}
+
 // Used to transform from usageNanoCores to cpuUsedCores
 func fromNano(value definition.FetchedValue) (definition.FetchedValue, error) {
 	v, ok := value.(uint64)
@@ -5,6 +5,8 @@ import (
 
 	"time"
 
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,3 +106,32 @@ func TestComputePercentage(t *testing.T) {
 	v, err = computePercentage(3, 0)
 	assert.EqualError(t, err, "division by zero")
 }
+
+func TestCPUQuotaToCores(t *testing.T) {
+	raw := definition.RawGroups{
+		"container": {
+			"limited": definition.RawMetrics{
+				"container_spec_cpu_quota":  prometheus.GaugeValue(50000),
+				"container_spec_cpu_period": prometheus.GaugeValue(100000),
+			},
+			"unlimited": definition.RawMetrics{
+				"container_spec_cpu_period": prometheus.GaugeValue(100000),
+			},
+			"zeroPeriod": definition.RawMetrics{
+				"container_spec_cpu_quota":  prometheus.GaugeValue(50000),
+				"container_spec_cpu_period": prometheus.GaugeValue(0),
+			},
+		},
+	}
+	f := cpuQuotaToCores("container_spec_cpu_quota", "container_spec_cpu_period")
+
+	v, err := f("container", "limited", raw)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, v)
+
+	_, err = f("container", "unlimited", raw)
+	assert.EqualError(t, err, "metric not found")
+
+	_, err = f("container", "zeroPeriod", raw)
+	assert.EqualError(t, err, "error computing cpu limit from container_spec_cpu_period: division by zero")
+}
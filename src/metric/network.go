@@ -0,0 +1,39 @@
+package metric
+
+import (
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	kubeletMetric "github.com/newrelic/nri-kubernetes/src/kubelet/metric"
+)
+
+// networkInterfaceGroups are the kubelet spec groups reporting the network usage of every interface.
+var networkInterfaceGroups = []string{"node", "pod"}
+
+// WithNetworkInterfaceSpecs returns a copy of the given kubelet spec groups where nodes and pods report the rate
+// of received and transmitted bytes and errors of every interface the filter selects, as
+// net.<interface>.rxBytesPerSecond, net.<interface>.txBytesPerSecond and net.<interface>.errorsPerSecond, besides
+// the ones of the default interface. The given spec groups are not modified.
+func WithNetworkInterfaceSpecs(specGroups definition.SpecGroups, filter *kubeletMetric.InterfaceFilter) definition.SpecGroups {
+	merged := make(definition.SpecGroups, len(specGroups))
+	for name, group := range specGroups {
+		merged[name] = group
+	}
+
+	for _, groupName := range networkInterfaceGroups {
+		group, ok := merged[groupName]
+		if !ok {
+			continue
+		}
+		specs := make([]definition.Spec, len(group.Specs), len(group.Specs)+3)
+		copy(specs, group.Specs)
+		group.Specs = append(specs,
+			definition.Spec{Name: "net.*.rxBytesPerSecond", ValueFunc: kubeletMetric.OneMetricPerInterface("rxBytes", "rxBytesPerSecond", filter), Type: sdkMetric.RATE, Optional: true},
+			definition.Spec{Name: "net.*.txBytesPerSecond", ValueFunc: kubeletMetric.OneMetricPerInterface("txBytes", "txBytesPerSecond", filter), Type: sdkMetric.RATE, Optional: true},
+			definition.Spec{Name: "net.*.errorsPerSecond", ValueFunc: kubeletMetric.OneMetricPerInterface("errors", "errorsPerSecond", filter), Type: sdkMetric.RATE, Optional: true},
+		)
+		merged[groupName] = group
+	}
+
+	return merged
+}
@@ -0,0 +1,48 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	kubeletMetric "github.com/newrelic/nri-kubernetes/src/kubelet/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithNetworkInterfaceSpecs(t *testing.T) {
+	filter, err := kubeletMetric.NewInterfaceFilter(nil, []string{"veth*"})
+	require.NoError(t, err)
+
+	specs := WithNetworkInterfaceSpecs(KubeletSpecs, filter)
+
+	for _, group := range []string{"node", "pod"} {
+		require.Len(t, specs[group].Specs, len(KubeletSpecs[group].Specs)+3)
+		added := specs[group].Specs[len(KubeletSpecs[group].Specs):]
+		assert.Equal(t, "net.*.rxBytesPerSecond", added[0].Name)
+		assert.Equal(t, "net.*.txBytesPerSecond", added[1].Name)
+		assert.Equal(t, "net.*.errorsPerSecond", added[2].Name)
+	}
+	assert.Len(t, specs["container"].Specs, len(KubeletSpecs["container"].Specs))
+
+	raw := definition.RawGroups{
+		"node": {
+			"fooNode": definition.RawMetrics{
+				"interfaces": map[string]definition.RawMetrics{
+					"eth0":     {"rxBytes": uint64(100), "txBytes": uint64(200), "errors": uint64(1)},
+					"veth1234": {"rxBytes": uint64(300), "txBytes": uint64(400), "errors": uint64(0)},
+				},
+			},
+		},
+	}
+	value, err := specs["node"].Specs[len(KubeletSpecs["node"].Specs)+2].ValueFunc("node", "fooNode", raw)
+	require.NoError(t, err)
+	assert.Equal(t, definition.FetchedValues{"net.eth0.errorsPerSecond": uint64(1)}, value)
+}
+
+func TestWithNetworkInterfaceSpecs_DoesNotModifyGivenSpecs(t *testing.T) {
+	nodeSpecs := len(KubeletSpecs["node"].Specs)
+
+	WithNetworkInterfaceSpecs(KubeletSpecs, nil)
+
+	assert.Len(t, KubeletSpecs["node"].Specs, nodeSpecs)
+}
@@ -67,6 +67,24 @@ var expectedMetrics = []*sdk.EntityData{
 				"label.pod-template-generation":  "1",
 				"displayName":                    "newrelic-infra-rz225", // From manipulator
 				"clusterName":                    "test-cluster",         // From manipulator
+
+				// Usage of the pod cgroup
+				"cpuUsedCores":              0.016874347,
+				"memoryUsedBytes":           uint64(52617216),
+				"memoryWorkingSetBytes":     uint64(50044928),
+				"ephemeralStorageUsedBytes": uint64(159744),
+
+				// Annotations are only dropped when populating through an attribute.Limiter
+				"annotation.kubernetes.io/config.seen":                 "2018-02-27T15:21:31.663551743Z",
+				"annotation.kubernetes.io/config.source":               "api",
+				"annotation.scheduler.alpha.kubernetes.io/tolerations": "[{\"operator\": \"Exists\", \"effect\": \"NoSchedule\"}]\n",
+
+				// Owner chain
+				"ownerKind":     "DaemonSet",
+				"ownerName":     "newrelic-infra",
+				"topOwnerKind":  "DaemonSet",
+				"topOwnerName":  "newrelic-infra",
+				"daemonsetName": "newrelic-infra",
 			},
 		},
 		Inventory: sdk.Inventory{},
@@ -94,7 +112,9 @@ var expectedMetrics = []*sdk.EntityData{
 				"containerName":         "newrelic-infra",
 				"containerID":           "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
 				"containerImage":        "newrelic/ohaik:1.0.0-beta3",
+				"containerType":         "regular",
 				"containerImageID":      "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
+				"containerRuntime":      "docker",
 				"namespace":             "kube-system",
 				"namespaceName":         "kube-system",
 				"podName":               "newrelic-infra-rz225",
@@ -112,6 +132,21 @@ var expectedMetrics = []*sdk.EntityData{
 				"label.controller-revision-hash": "3887482659",
 				"label.name":                     "newrelic-infra",
 				"label.pod-template-generation":  "1",
+
+				// Termination history
+				"restartCountDelta":        0.,
+				"startedAt":                parseTime("2018-02-27T15:21:16Z").Unix(),
+				"lastTerminatedReason":     "Completed",
+				"lastTerminatedExitCode":   int32(0),
+				"lastTerminatedFinishedAt": parseTime("2018-02-27T15:21:10Z").Unix(),
+				"isOOMKilled":              0,
+
+				// Owner chain
+				"ownerKind":     "DaemonSet",
+				"ownerName":     "newrelic-infra",
+				"topOwnerKind":  "DaemonSet",
+				"topOwnerName":  "newrelic-infra",
+				"daemonsetName": "newrelic-infra",
 			},
 		},
 		Inventory: sdk.Inventory{},
@@ -0,0 +1,264 @@
+// Package otlp exports the metrics populated by the integration to an OpenTelemetry collector, using the
+// OpenTelemetry protocol (OTLP) over gRPC or HTTP.
+package otlp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const scopeName = "github.com/newrelic/nri-kubernetes"
+
+// resourceAttributes maps the metric set attributes identifying a Kubernetes object to the resource attributes
+// of the OpenTelemetry semantic conventions.
+var resourceAttributes = map[string]string{
+	"namespaceName":   "k8s.namespace.name",
+	"podName":         "k8s.pod.name",
+	"nodeName":        "k8s.node.name",
+	"containerName":   "k8s.container.name",
+	"deploymentName":  "k8s.deployment.name",
+	"daemonsetName":   "k8s.daemonset.name",
+	"statefulsetName": "k8s.statefulset.name",
+	"replicasetName":  "k8s.replicaset.name",
+	"jobName":         "k8s.job.name",
+	"cronjobName":     "k8s.cronjob.name",
+}
+
+// ignoredAttributes are not exported, as their value is already exported as a resource attribute or as part of
+// the metric name, or is specific to New Relic, like the entity name.
+var ignoredAttributes = map[string]bool{
+	"event_type":  true,
+	"entityName":  true,
+	"clusterName": true,
+	"namespace":   true,
+	"displayName": true,
+}
+
+// Exporter converts the metrics populated by the integration into OTLP metrics and sends them to a receiver.
+// Each entity becomes an OTLP resource, and each one of its metrics a gauge. RATE and DELTA metrics, which
+// the SDK derives from ever-growing counters, are both exported as the increase of the counter: a monotonic
+// sum with delta temporality.
+type Exporter struct {
+	lock      sync.Mutex
+	sender    Sender
+	version   string
+	resources map[string]*metricspb.ResourceMetrics
+	// recorded indexes the recorded metrics by resource, name and attributes.
+	recorded map[string]int
+	// sampled holds the time of the last sample of each sum series, indexed like recorded, which is the start
+	// time of its next sample.
+	sampled map[string]time.Time
+	// lastFlush is the start time of the sum series sampled for the first time.
+	lastFlush time.Time
+	now       func() time.Time
+}
+
+// staleSeriesAge is the time after which the sum series that have not been sampled again are forgotten, so
+// the series of deleted objects do not make the exporter grow without bounds.
+const staleSeriesAge = time.Hour
+
+// NewExporter creates an Exporter sending the metrics with the given Sender. The version is reported as the
+// version of the instrumentation scope.
+func NewExporter(sender Sender, version string) *Exporter {
+	return &Exporter{
+		sender:    sender,
+		version:   version,
+		resources: make(map[string]*metricspb.ResourceMetrics),
+		recorded:  make(map[string]int),
+		sampled:   make(map[string]time.Time),
+		lastFlush: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Record converts the given metric sets of the given cluster into OTLP metrics, which are sent on the next
+// Flush. The sourceType function returns the type of each metric, which determines whether it is exported as
+// a gauge or as a sum.
+func (e *Exporter) Record(
+	clusterName string,
+	sourceType func(metricName string) sdkMetric.SourceType,
+	metricSets ...sdkMetric.MetricSet,
+) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	sampledAt := e.now()
+	now := uint64(sampledAt.UnixNano())
+	for _, ms := range metricSets {
+		eventType, _ := ms["event_type"].(string)
+		resource, attributes := splitAttributes(clusterName, ms)
+
+		key := attributesSignature(resource)
+		rm, ok := e.resources[key]
+		if !ok {
+			rm = &metricspb.ResourceMetrics{
+				Resource: &resourcepb.Resource{Attributes: resource},
+				ScopeMetrics: []*metricspb.ScopeMetrics{{
+					Scope: &commonpb.InstrumentationScope{Name: scopeName, Version: e.version},
+				}},
+			}
+			e.resources[key] = rm
+		}
+		scope := rm.ScopeMetrics[0]
+
+		for _, name := range sortedNames(ms) {
+			if _, ok := ms[name].(string); ok {
+				continue
+			}
+			value, err := strconv.ParseFloat(fmt.Sprintf("%v", ms[name]), 64)
+			if err != nil {
+				continue
+			}
+
+			m := &metricspb.Metric{
+				Name:        MetricName(eventType, name),
+				Description: fmt.Sprintf("%s of the %s samples.", name, eventType),
+			}
+			dp := &metricspb.NumberDataPoint{Attributes: attributes, TimeUnixNano: now}
+			metricKey := key + "\x00" + m.Name + "\x00" + attributesSignature(attributes)
+			i, recorded := e.recorded[metricKey]
+			switch sourceType(name) {
+			case sdkMetric.RATE, sdkMetric.DELTA:
+				// The increase is the one since the previous sample of the series, which is not necessarily the
+				// previous flush, e.g. when the job sampling it was skipped.
+				if recorded {
+					dp.StartTimeUnixNano = scope.Metrics[i].GetSum().DataPoints[0].StartTimeUnixNano
+				} else if previous, ok := e.sampled[metricKey]; ok {
+					dp.StartTimeUnixNano = uint64(previous.UnixNano())
+				} else {
+					dp.StartTimeUnixNano = uint64(e.lastFlush.UnixNano())
+				}
+				e.sampled[metricKey] = sampledAt
+				if sourceType(name) == sdkMetric.RATE {
+					// The SDK divides the increase by the time between the samples of the series, which is the
+					// interval of the data point.
+					value *= float64(dp.TimeUnixNano-dp.StartTimeUnixNano) / float64(time.Second)
+				}
+				dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: value}
+				m.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+					DataPoints:             []*metricspb.NumberDataPoint{dp},
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+					// Counters that are reset are discarded by the SDK, so the increase is never negative.
+					IsMonotonic: true,
+				}}
+			default:
+				dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: value}
+				m.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{dp}}}
+			}
+
+			// The same entity can be reported by several jobs, e.g. the cluster, in which case the last value wins.
+			if recorded {
+				scope.Metrics[i] = m
+				continue
+			}
+			e.recorded[metricKey] = len(scope.Metrics)
+			scope.Metrics = append(scope.Metrics, m)
+		}
+	}
+}
+
+// Flush sends the metrics recorded since the last flush. Recorded metrics are discarded even if they could not
+// be sent, so a receiver that is down does not make the exporter grow without bounds.
+func (e *Exporter) Flush() error {
+	e.lock.Lock()
+	resources := e.resources
+	e.resources = make(map[string]*metricspb.ResourceMetrics)
+	e.recorded = make(map[string]int)
+	e.lastFlush = e.now()
+	for key, sampledAt := range e.sampled {
+		if e.lastFlush.Sub(sampledAt) > staleSeriesAge {
+			delete(e.sampled, key)
+		}
+	}
+	e.lock.Unlock()
+
+	if len(resources) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(resources))
+	for k := range resources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	request := &colmetricspb.ExportMetricsServiceRequest{}
+	for _, k := range keys {
+		request.ResourceMetrics = append(request.ResourceMetrics, resources[k])
+	}
+	return e.sender.Send(request)
+}
+
+// MetricName returns the name of the OTLP metric for the given metric of the given event type, e.g.
+// "k8s.pod.cpuUsedCores" for the cpuUsedCores metric of the K8sPodSample event type.
+func MetricName(eventType, metricName string) string {
+	object := strings.ToLower(strings.TrimPrefix(strings.TrimSuffix(eventType, "Sample"), "K8s"))
+	if object == "" {
+		return "k8s." + metricName
+	}
+	return "k8s." + object + "." + metricName
+}
+
+// splitAttributes returns the resource attributes identifying the entity of the metric set, and the rest of
+// its string attributes.
+func splitAttributes(clusterName string, ms sdkMetric.MetricSet) (resource, attributes []*commonpb.KeyValue) {
+	if clusterName != "" {
+		resource = append(resource, stringAttribute("k8s.cluster.name", clusterName))
+	}
+
+	for _, name := range sortedNames(ms) {
+		value, ok := ms[name].(string)
+		if !ok || value == "" || ignoredAttributes[name] {
+			continue
+		}
+		if key, ok := resourceAttributes[name]; ok {
+			resource = append(resource, stringAttribute(key, value))
+			continue
+		}
+		attributes = append(attributes, stringAttribute(name, value))
+	}
+
+	// namespace is only used by some entities, which do not have namespaceName.
+	if ns, ok := ms["namespace"].(string); ok && ns != "" && ms["namespaceName"] == nil {
+		resource = append(resource, stringAttribute("k8s.namespace.name", ns))
+	}
+
+	sort.Slice(resource, func(i, j int) bool {
+		return resource[i].Key < resource[j].Key
+	})
+	return resource, attributes
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func attributesSignature(attributes []*commonpb.KeyValue) string {
+	var b strings.Builder
+	for _, kv := range attributes {
+		b.WriteString(kv.Key)
+		b.WriteByte('=')
+		b.WriteString(kv.Value.GetStringValue())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func sortedNames(ms sdkMetric.MetricSet) []string {
+	names := make([]string, 0, len(ms))
+	for name := range ms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
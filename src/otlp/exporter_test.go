@@ -0,0 +1,210 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+type recordingSender struct {
+	requests []*colmetricspb.ExportMetricsServiceRequest
+}
+
+func (s *recordingSender) Send(request *colmetricspb.ExportMetricsServiceRequest) error {
+	s.requests = append(s.requests, request)
+	return nil
+}
+
+// attributes returns the given string attributes as a map.
+func attributes(kvs []*commonpb.KeyValue) map[string]string {
+	attrs := make(map[string]string)
+	for _, kv := range kvs {
+		attrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	return attrs
+}
+
+var testSpecs = definition.SpecGroups{
+	"pod": {
+		Specs: []definition.Spec{
+			{Name: "cpuUsedCores", Type: sdkMetric.GAUGE},
+			{Name: "net.errorsPerSecond", Type: sdkMetric.RATE},
+			{Name: "restartCountDelta", Type: sdkMetric.DELTA},
+			{Name: "label.*", Type: sdkMetric.ATTRIBUTE},
+		},
+	},
+}
+
+func TestExporter(t *testing.T) {
+	sender := &recordingSender{}
+	e := NewExporter(sender, "1.2.3")
+	start := time.Unix(100, 0)
+	now := time.Unix(115, 0)
+	e.lastFlush = start
+	e.now = func() time.Time { return now }
+
+	e.Record("cluster", SourceTypes(testSpecs), sdkMetric.MetricSet{
+		"event_type":          "K8sPodSample",
+		"entityName":          "k8s:cluster:default:pod:nginx",
+		"displayName":         "nginx",
+		"clusterName":         "cluster",
+		"podName":             "nginx",
+		"namespace":           "default",
+		"namespaceName":       "default",
+		"nodeName":            "node-1",
+		"label.app":           "nginx",
+		"cpuUsedCores":        0.5,
+		"net.errorsPerSecond": 0.2,
+		"restartCountDelta":   uint64(2),
+	})
+	require.NoError(t, e.Flush())
+	require.Len(t, sender.requests, 1)
+
+	resources := sender.requests[0].ResourceMetrics
+	require.Len(t, resources, 1)
+
+	// Only the attributes of the semantic conventions identify the resource
+	assert.Equal(t, map[string]string{
+		"k8s.cluster.name":   "cluster",
+		"k8s.namespace.name": "default",
+		"k8s.node.name":      "node-1",
+		"k8s.pod.name":       "nginx",
+	}, attributes(resources[0].Resource.Attributes))
+
+	require.Len(t, resources[0].ScopeMetrics, 1)
+	scopeMetrics := resources[0].ScopeMetrics[0]
+	assert.Equal(t, scopeName, scopeMetrics.Scope.Name)
+	assert.Equal(t, "1.2.3", scopeMetrics.Scope.Version)
+
+	require.Len(t, scopeMetrics.Metrics, 3)
+	byName := make(map[string]*metricspb.Metric)
+	for _, m := range scopeMetrics.Metrics {
+		byName[m.Name] = m
+	}
+
+	// GAUGE metrics are gauges
+	m, ok := byName["k8s.pod.cpuUsedCores"]
+	require.True(t, ok)
+	require.NotNil(t, m.GetGauge())
+	dp := m.GetGauge().DataPoints[0]
+	assert.Equal(t, 0.5, dp.GetAsDouble())
+	assert.Equal(t, uint64(now.UnixNano()), dp.TimeUnixNano)
+	assert.Zero(t, dp.StartTimeUnixNano)
+	assert.Equal(t, map[string]string{"label.app": "nginx"}, attributes(dp.Attributes))
+
+	// RATE and DELTA metrics are monotonic sums with delta temporality, starting at the previous flush when
+	// first sampled, and RATE metrics are converted to the increase over that interval
+	for name, expected := range map[string]float64{"k8s.pod.restartCountDelta": 2, "k8s.pod.net.errorsPerSecond": 3} {
+		m, ok := byName[name]
+		require.True(t, ok, name)
+		sum := m.GetSum()
+		require.NotNil(t, sum, name)
+		assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, sum.AggregationTemporality, name)
+		assert.True(t, sum.IsMonotonic, name)
+		dp := sum.DataPoints[0]
+		assert.InDelta(t, expected, dp.GetAsDouble(), 1e-9, name)
+		assert.Equal(t, uint64(start.UnixNano()), dp.StartTimeUnixNano, name)
+		assert.Equal(t, uint64(now.UnixNano()), dp.TimeUnixNano, name)
+		assert.Equal(t, map[string]string{"label.app": "nginx"}, attributes(dp.Attributes), name)
+	}
+}
+
+func TestExporter_DeltaStartsAtThePreviousSampleOfTheSeries(t *testing.T) {
+	sender := &recordingSender{}
+	e := NewExporter(sender, "1.2.3")
+	e.lastFlush = time.Unix(100, 0)
+
+	pod := func(name string, restarts uint64) sdkMetric.MetricSet {
+		return sdkMetric.MetricSet{"event_type": "K8sPodSample", "podName": name, "restartCountDelta": restarts}
+	}
+	startTimes := func(request *colmetricspb.ExportMetricsServiceRequest) map[string]uint64 {
+		starts := make(map[string]uint64)
+		for _, rm := range request.ResourceMetrics {
+			pod := attributes(rm.Resource.Attributes)["k8s.pod.name"]
+			starts[pod] = rm.ScopeMetrics[0].Metrics[0].GetSum().DataPoints[0].StartTimeUnixNano
+		}
+		return starts
+	}
+
+	e.now = func() time.Time { return time.Unix(115, 0) }
+	e.Record("cluster", SourceTypes(testSpecs), pod("nginx", 1), pod("redis", 1))
+	require.NoError(t, e.Flush())
+
+	// The redis pod is not sampled by the next collection, e.g. because its job was skipped
+	e.now = func() time.Time { return time.Unix(130, 0) }
+	e.Record("cluster", SourceTypes(testSpecs), pod("nginx", 1))
+	require.NoError(t, e.Flush())
+
+	e.now = func() time.Time { return time.Unix(145, 0) }
+	e.Record("cluster", SourceTypes(testSpecs), pod("nginx", 1), pod("redis", 2), pod("mysql", 1))
+	require.NoError(t, e.Flush())
+
+	require.Len(t, sender.requests, 3)
+	assert.Equal(t, map[string]uint64{
+		"nginx": uint64(time.Unix(100, 0).UnixNano()),
+		"redis": uint64(time.Unix(100, 0).UnixNano()),
+	}, startTimes(sender.requests[0]))
+	assert.Equal(t, uint64(time.Unix(115, 0).UnixNano()), startTimes(sender.requests[1])["nginx"])
+	assert.Equal(t, map[string]uint64{
+		"nginx": uint64(time.Unix(130, 0).UnixNano()),
+		"redis": uint64(time.Unix(115, 0).UnixNano()),
+		// The series sampled for the first time start at the previous flush
+		"mysql": uint64(time.Unix(130, 0).UnixNano()),
+	}, startTimes(sender.requests[2]))
+}
+
+func TestExporter_SameEntityFromSeveralJobs(t *testing.T) {
+	sender := &recordingSender{}
+	e := NewExporter(sender, "1.2.3")
+
+	cluster := func(nodes int) sdkMetric.MetricSet {
+		return sdkMetric.MetricSet{"event_type": "K8sClusterSample", "entityName": "k8s:cluster", "nodes": nodes}
+	}
+	e.Record("cluster", SourceTypes(nil), cluster(1))
+	e.Record("cluster", SourceTypes(nil), cluster(2))
+	require.NoError(t, e.Flush())
+
+	resources := sender.requests[0].ResourceMetrics
+	require.Len(t, resources, 1)
+	metrics := resources[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 1)
+	assert.Equal(t, 2.0, metrics[0].GetGauge().DataPoints[0].GetAsDouble())
+}
+
+func TestExporter_FlushWithoutMetrics(t *testing.T) {
+	sender := &recordingSender{}
+	e := NewExporter(sender, "1.2.3")
+
+	require.NoError(t, e.Flush())
+	assert.Empty(t, sender.requests)
+}
+
+func TestSourceTypes(t *testing.T) {
+	specs := definition.SpecGroups{
+		"api-server": {
+			Specs: []definition.Spec{
+				{Name: "apiserverRequestsDelta", Type: sdkMetric.DELTA},
+				{Name: "apiserverRequestsRate", Type: sdkMetric.RATE},
+			},
+		},
+	}
+	sourceType := SourceTypes(specs)
+
+	assert.Equal(t, sdkMetric.DELTA, sourceType("apiserverRequestsDelta"))
+	assert.Equal(t, sdkMetric.DELTA, sourceType("apiserverRequestsDelta_verb_GET_code_200"))
+	assert.Equal(t, sdkMetric.RATE, sourceType("apiserverRequestsRate_verb_GET_code_200"))
+	assert.Equal(t, sdkMetric.GAUGE, sourceType("unknownMetric"))
+}
+
+func TestMetricName(t *testing.T) {
+	assert.Equal(t, "k8s.pod.cpuUsedCores", MetricName("K8sPodSample", "cpuUsedCores"))
+	assert.Equal(t, "k8s.apiserver.apiserverRequestsDelta", MetricName("K8sApiServerSample", "apiserverRequestsDelta"))
+	assert.Equal(t, "k8s.isReady", MetricName("", "isReady"))
+}
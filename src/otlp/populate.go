@@ -0,0 +1,72 @@
+package otlp
+
+import (
+	"strings"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+type populator struct {
+	populator data.Populator
+	exporter  *Exporter
+}
+
+// NewPopulator creates a data.Populator that records the metric sets populated by the given populator into
+// the given Exporter, so they are sent on its next Flush.
+func NewPopulator(p data.Populator, e *Exporter) data.Populator {
+	return &populator{populator: p, exporter: e}
+}
+
+// Populate populates the integration using the wrapped populator and records the new metric sets, using the
+// given specs to know the type of each metric.
+func (p *populator) Populate(
+	groups definition.RawGroups,
+	specGroups definition.SpecGroups,
+	i *sdk.IntegrationProtocol2,
+	clusterName string,
+	k8sVersion *version.Info,
+) data.PopulateResult {
+	before := data.SnapshotMetricSets(i)
+	result := p.populator.Populate(groups, specGroups, i, clusterName, k8sVersion)
+	populated := before.NewMetricSets(i)
+	p.exporter.Record(clusterName, SourceTypes(specGroups), populated...)
+
+	return result
+}
+
+// SourceTypes returns a function giving the source type of the metrics populated from the given specs. Specs
+// fetching several values, like "label.*" or the ones with label suffixes, are matched by prefix. Metrics not
+// matching any spec are considered gauges.
+func SourceTypes(specGroups definition.SpecGroups) func(metricName string) sdkMetric.SourceType {
+	types := make(map[string]sdkMetric.SourceType)
+	for _, group := range specGroups {
+		for _, spec := range group.Specs {
+			types[spec.Name] = spec.Type
+		}
+	}
+
+	return func(metricName string) sdkMetric.SourceType {
+		if t, ok := types[metricName]; ok {
+			return t
+		}
+
+		// The longest matching prefix is the most specific spec.
+		matched := ""
+		t := sdkMetric.GAUGE
+		for name, specType := range types {
+			prefix := strings.TrimSuffix(name, "*")
+			if prefix == name {
+				prefix += "_"
+			}
+			if strings.HasPrefix(metricName, prefix) && len(prefix) > len(matched) {
+				matched = prefix
+				t = specType
+			}
+		}
+		return t
+	}
+}
@@ -0,0 +1,64 @@
+package otlp
+
+import (
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// podPopulator populates one K8sPodSample per entity of the "pod" group.
+type podPopulator struct{}
+
+func (podPopulator) Populate(
+	groups definition.RawGroups,
+	_ definition.SpecGroups,
+	i *sdk.IntegrationProtocol2,
+	_ string,
+	_ *version.Info,
+) data.PopulateResult {
+	for podName, raw := range groups["pod"] {
+		e, err := i.Entity(podName, "k8s:cluster:default:pod")
+		if err != nil {
+			return data.PopulateResult{Errors: []error{err}}
+		}
+		ms := e.NewMetricSet("K8sPodSample")
+		ms.SetMetric("podName", podName, sdkMetric.ATTRIBUTE)                   // nolint: errcheck
+		ms.SetMetric("restartCountDelta", raw["restartCount"], sdkMetric.DELTA) // nolint: errcheck
+	}
+	return data.PopulateResult{Populated: true}
+}
+
+func TestPopulator_RecordsOnlyNewMetricSets(t *testing.T) {
+	i, err := sdk.NewIntegrationProtocol2("nr.test", "1.0.0", new(struct{}))
+	require.NoError(t, err)
+
+	sender := &recordingSender{}
+	e := NewExporter(sender, "1.2.3")
+	p := NewPopulator(podPopulator{}, e)
+
+	// The integration already holds data populated by another job
+	result := podPopulator{}.Populate(definition.RawGroups{"pod": {"previous": {"restartCount": 1}}}, nil, i, "", nil)
+	require.True(t, result.Populated)
+
+	result = p.Populate(definition.RawGroups{"pod": {"new": {"restartCount": 2}}}, testSpecs, i, "cluster", nil)
+	assert.True(t, result.Populated)
+	require.NoError(t, e.Flush())
+
+	require.Len(t, sender.requests, 1)
+	resources := sender.requests[0].ResourceMetrics
+	require.Len(t, resources, 1)
+	assert.Equal(t, "new", attributes(resources[0].Resource.Attributes)["k8s.pod.name"])
+	assert.Equal(t, "cluster", attributes(resources[0].Resource.Attributes)["k8s.cluster.name"])
+
+	// The type of the metric is taken from the specs
+	metrics := resources[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "k8s.pod.restartCountDelta", metrics[0].Name)
+	assert.NotNil(t, metrics[0].GetSum())
+}
@@ -0,0 +1,140 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ProtocolGRPC sends the metrics using OTLP over gRPC.
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTPProtobuf sends the metrics using OTLP over HTTP, encoded with protobuf.
+	ProtocolHTTPProtobuf = "http/protobuf"
+
+	httpMetricsPath = "/v1/metrics"
+)
+
+// Sender sends an ExportMetricsServiceRequest to an OTLP receiver.
+type Sender interface {
+	Send(request *colmetricspb.ExportMetricsServiceRequest) error
+}
+
+// NewSender creates a Sender for the given protocol. Headers are added to every request, e.g. to authenticate
+// against the receiver.
+func NewSender(protocol, endpoint string, headers map[string]string, timeout time.Duration) (Sender, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %v", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: the scheme must be http or https", endpoint)
+	}
+
+	switch protocol {
+	case ProtocolGRPC:
+		return newGRPCSender(u, headers, timeout)
+	case ProtocolHTTPProtobuf:
+		return newHTTPSender(u, headers, timeout), nil
+	}
+	return nil, fmt.Errorf("unknown OTLP protocol %q, it must be %s or %s", protocol, ProtocolGRPC, ProtocolHTTPProtobuf)
+}
+
+type httpSender struct {
+	client   *http.Client
+	endpoint string
+	headers  map[string]string
+}
+
+// newHTTPSender creates a Sender posting the requests to the /v1/metrics path of the endpoint, unless the
+// endpoint already specifies a path.
+func newHTTPSender(endpoint *url.URL, headers map[string]string, timeout time.Duration) *httpSender {
+	u := *endpoint
+	if u.Path == "" || u.Path == "/" {
+		u.Path = httpMetricsPath
+	}
+	return &httpSender{
+		client:   &http.Client{Timeout: timeout},
+		endpoint: u.String(),
+		headers:  headers,
+	}
+}
+
+func (s *httpSender) Send(request *colmetricspb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending metrics to %s: %v", s.endpoint, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sending metrics to %s: status code %d: %s", s.endpoint, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	// Read the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body) // nolint: errcheck
+	return nil
+}
+
+type grpcSender struct {
+	client   colmetricspb.MetricsServiceClient
+	endpoint string
+	headers  metadata.MD
+	timeout  time.Duration
+}
+
+// newGRPCSender creates a Sender calling the Export method of the OTLP MetricsService, with TLS for https
+// endpoints and without it for http endpoints. The connection is established on the first call.
+func newGRPCSender(endpoint *url.URL, headers map[string]string, timeout time.Duration) (*grpcSender, error) {
+	creds := insecure.NewCredentials()
+	if endpoint.Scheme == "https" {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.Dial(endpoint.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %v", endpoint, err)
+	}
+	return &grpcSender{
+		client:   colmetricspb.NewMetricsServiceClient(conn),
+		endpoint: endpoint.String(),
+		headers:  metadata.New(headers),
+		timeout:  timeout,
+	}, nil
+}
+
+func (s *grpcSender) Send(request *colmetricspb.ExportMetricsServiceRequest) error {
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), s.headers), s.timeout)
+	defer cancel()
+
+	if _, err := s.client.Export(ctx, request); err != nil {
+		return fmt.Errorf("sending metrics to %s: %v", s.endpoint, err)
+	}
+	return nil
+}
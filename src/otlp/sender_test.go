@@ -0,0 +1,142 @@
+package otlp
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// receivedRequest is what the receiver stand-ins got from the senders.
+type receivedRequest struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+// testRequest returns a request with a single resource, to check it is received as sent.
+func testRequest() *colmetricspb.ExportMetricsServiceRequest {
+	e := NewExporter(nil, "1.2.3")
+	e.Record("cluster", SourceTypes(nil), map[string]interface{}{"event_type": "K8sClusterSample", "nodes": 1})
+	request := &colmetricspb.ExportMetricsServiceRequest{}
+	for _, rm := range e.resources {
+		request.ResourceMetrics = append(request.ResourceMetrics, rm)
+	}
+	return request
+}
+
+func TestHTTPSender(t *testing.T) {
+	received := make(chan receivedRequest, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- receivedRequest{path: r.URL.Path, headers: r.Header, body: body}
+	}))
+	defer receiver.Close()
+
+	s, err := NewSender(ProtocolHTTPProtobuf, receiver.URL, map[string]string{"Api-Key": "secret"}, time.Second)
+	require.NoError(t, err)
+	request := testRequest()
+	require.NoError(t, s.Send(request))
+
+	r := <-received
+	assert.Equal(t, httpMetricsPath, r.path)
+	assert.Equal(t, "application/x-protobuf", r.headers.Get("Content-Type"))
+	assert.Equal(t, "secret", r.headers.Get("Api-Key"))
+	decoded := &colmetricspb.ExportMetricsServiceRequest{}
+	require.NoError(t, proto.Unmarshal(r.body, decoded))
+	assert.True(t, proto.Equal(request, decoded))
+}
+
+func TestHTTPSender_ErrorStatus(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	s, err := NewSender(ProtocolHTTPProtobuf, receiver.URL+"/custom/path", nil, time.Second)
+	require.NoError(t, err)
+	err = s.Send(testRequest())
+	assert.EqualError(t, err, "sending metrics to "+receiver.URL+"/custom/path: status code 400: invalid request")
+}
+
+// metricsService is an OTLP gRPC receiver stand-in, answering with the given error.
+type metricsService struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	received chan *colmetricspb.ExportMetricsServiceRequest
+	metadata chan metadata.MD
+	err      error
+}
+
+func (s *metricsService) Export(ctx context.Context, r *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.metadata <- md
+	s.received <- r
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+// grpcReceiver serves the given service without TLS, like an OTLP gRPC receiver does.
+func grpcReceiver(t *testing.T, service *metricsService) (endpoint string, closeFn func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(server, service)
+	go server.Serve(listener) // nolint: errcheck
+
+	return "http://" + listener.Addr().String(), server.Stop
+}
+
+func TestGRPCSender(t *testing.T) {
+	service := &metricsService{
+		received: make(chan *colmetricspb.ExportMetricsServiceRequest, 1),
+		metadata: make(chan metadata.MD, 1),
+	}
+	endpoint, closeReceiver := grpcReceiver(t, service)
+	defer closeReceiver()
+
+	s, err := NewSender(ProtocolGRPC, endpoint, map[string]string{"Api-Key": "secret"}, time.Second)
+	require.NoError(t, err)
+	request := testRequest()
+	require.NoError(t, s.Send(request))
+
+	assert.Equal(t, []string{"secret"}, (<-service.metadata).Get("api-key"))
+	assert.True(t, proto.Equal(request, <-service.received))
+}
+
+func TestGRPCSender_ErrorStatus(t *testing.T) {
+	service := &metricsService{
+		received: make(chan *colmetricspb.ExportMetricsServiceRequest, 1),
+		metadata: make(chan metadata.MD, 1),
+		err:      status.Error(codes.Unavailable, "unavailable"),
+	}
+	endpoint, closeReceiver := grpcReceiver(t, service)
+	defer closeReceiver()
+
+	s, err := NewSender(ProtocolGRPC, endpoint, nil, time.Second)
+	require.NoError(t, err)
+	err = s.Send(testRequest())
+	assert.EqualError(t, err, "sending metrics to "+endpoint+": rpc error: code = Unavailable desc = unavailable")
+}
+
+func TestNewSender_Errors(t *testing.T) {
+	_, err := NewSender("http/json", "http://localhost:4318", nil, time.Second)
+	assert.EqualError(t, err, `unknown OTLP protocol "http/json", it must be grpc or http/protobuf`)
+
+	_, err = NewSender(ProtocolGRPC, "localhost:4317", nil, time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/newrelic/nri-kubernetes/src/config"
+	"github.com/newrelic/nri-kubernetes/src/otlp"
+	"github.com/newrelic/nri-kubernetes/src/prometheus/exposition"
+	"github.com/newrelic/nri-kubernetes/src/scrape"
+)
+
+const (
+	outputStdout     = "stdout"
+	outputPrometheus = "prometheus"
+	outputOTLP       = "otlp"
+)
+
+// outputs are the destinations of the collected metrics. The exporters are nil when their output is disabled.
+type outputs struct {
+	stdout     bool
+	prometheus *exposition.Exporter
+	otlp       *otlp.Exporter
+}
+
+// newOutputs creates the outputs enabled by the arguments.
+func newOutputs(args argumentList) (*outputs, error) {
+	enabled, err := config.Outputs(args.Output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output: %v", err)
+	}
+
+	o := &outputs{stdout: enabled[outputStdout]}
+
+	if enabled[outputPrometheus] {
+		if !args.Daemon {
+			return nil, fmt.Errorf("the %s output requires running as a daemon", outputPrometheus)
+		}
+		o.prometheus = exposition.NewExporter()
+	}
+
+	if enabled[outputOTLP] {
+		if args.OtlpEndpoint == "" {
+			return nil, fmt.Errorf("the %s output requires the otlp_endpoint argument", outputOTLP)
+		}
+		headers, err := parseHeaders(args.OtlpHeaders)
+		if err != nil {
+			return nil, err
+		}
+		sender, err := otlp.NewSender(args.OtlpProtocol, args.OtlpEndpoint, headers, time.Millisecond*time.Duration(args.OtlpTimeout))
+		if err != nil {
+			return nil, err
+		}
+		o.otlp = otlp.NewExporter(sender, integrationVersion)
+	}
+
+	return o, nil
+}
+
+// wrap makes the jobs record the data they populate into the exporters.
+func (o *outputs) wrap(jobs []*scrape.Job) {
+	for _, job := range jobs {
+		if o.prometheus != nil {
+			job.Populator = exposition.NewPopulator(job.Populator, o.prometheus)
+		}
+		if o.otlp != nil {
+			job.Populator = otlp.NewPopulator(job.Populator, o.otlp)
+		}
+	}
+}
+
+// flush makes the data recorded during the last collection available to the exporters. Errors sending the data
+// are logged, as they should not prevent the data from reaching the rest of the outputs.
+func (o *outputs) flush(logger *logrus.Logger) {
+	if o.prometheus != nil {
+		o.prometheus.Commit()
+	}
+	if o.otlp != nil {
+		if err := o.otlp.Flush(); err != nil {
+			logger.WithError(err).Error("exporting metrics over OTLP")
+		}
+	}
+}
+
+// parseHeaders parses a list of headers in the "key=value,key2=value2" format.
+func parseHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, h := range strings.Split(raw, ",") {
+		if strings.TrimSpace(h) == "" {
+			continue
+		}
+		kv := strings.SplitN(h, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q, it must be in the key=value format", h)
+		}
+		headers[key] = strings.TrimSpace(kv[1])
+	}
+	return headers, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOutputs(t *testing.T) {
+	o, err := newOutputs(argumentList{
+		Output:       "stdout,otlp",
+		OtlpEndpoint: "http://localhost:4318",
+		OtlpProtocol: "http/protobuf",
+		OtlpTimeout:  5000,
+	})
+	require.NoError(t, err)
+	assert.True(t, o.stdout)
+	assert.Nil(t, o.prometheus)
+	assert.NotNil(t, o.otlp)
+
+	o, err = newOutputs(argumentList{Output: "both", Daemon: true})
+	require.NoError(t, err)
+	assert.True(t, o.stdout)
+	assert.NotNil(t, o.prometheus)
+	assert.Nil(t, o.otlp)
+}
+
+func TestNewOutputs_Errors(t *testing.T) {
+	testCases := map[string]argumentList{
+		"unknown output":              {Output: "statsd"},
+		"prometheus without daemon":   {Output: "prometheus"},
+		"otlp without endpoint":       {Output: "otlp", OtlpProtocol: "grpc"},
+		"otlp with unknown protocol":  {Output: "otlp", OtlpEndpoint: "http://localhost:4317", OtlpProtocol: "thrift"},
+		"otlp with malformed headers": {Output: "otlp", OtlpEndpoint: "http://localhost:4317", OtlpProtocol: "grpc", OtlpHeaders: "api-key"},
+	}
+
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newOutputs(args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders("api-key=secret, x-scope = team=a,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"api-key": "secret", "x-scope": "team=a"}, headers)
+
+	headers, err = parseHeaders("")
+	require.NoError(t, err)
+	assert.Empty(t, headers)
+
+	_, err = parseHeaders("=secret")
+	assert.Error(t, err)
+}
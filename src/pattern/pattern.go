@@ -0,0 +1,98 @@
+// Package pattern matches names against the patterns used in the configuration: globs, where '*' matches any
+// sequence of characters and '?' any single character, or regular expressions when enclosed in slashes, like
+// /^team-.+$/.
+package pattern
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrEmpty is returned when compiling an empty pattern.
+var ErrEmpty = errors.New("patterns must not be empty")
+
+// Matcher matches whole names against a compiled pattern.
+type Matcher struct {
+	expr *regexp.Regexp
+}
+
+// List is a list of matchers, matching the names that any of them matches.
+type List []*Matcher
+
+// IsRegexp returns whether the given pattern is a regular expression, enclosed in slashes, instead of a glob.
+func IsRegexp(p string) bool {
+	return len(p) >= 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/")
+}
+
+// Compile compiles the given glob or regular expression enclosed in slashes. It fails if the pattern is empty or
+// is not a valid regular expression.
+func Compile(p string) (*Matcher, error) {
+	if p == "" {
+		return nil, ErrEmpty
+	}
+	if IsRegexp(p) {
+		expr, err := regexp.Compile(p[1 : len(p)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", p, err)
+		}
+		return &Matcher{expr: expr}, nil
+	}
+	return Glob(p), nil
+}
+
+// Glob compiles the given glob, with no support for regular expressions.
+func Glob(p string) *Matcher {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range p {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return &Matcher{expr: regexp.MustCompile(expr.String())}
+}
+
+// CompileList compiles the given patterns with Compile, failing on the first pattern that is not valid.
+func CompileList(patterns []string) (List, error) {
+	compiled := make(List, 0, len(patterns))
+	for _, p := range patterns {
+		m, err := Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, m)
+	}
+	return compiled, nil
+}
+
+// Globs compiles the given patterns with Glob.
+func Globs(patterns []string) List {
+	compiled := make(List, 0, len(patterns))
+	for _, p := range patterns {
+		compiled = append(compiled, Glob(p))
+	}
+	return compiled
+}
+
+// Matches returns whether the whole name matches the pattern.
+func (m *Matcher) Matches(name string) bool {
+	return m.expr.MatchString(name)
+}
+
+// MatchesAny returns whether any of the matchers matches the name. An empty List matches no name.
+func (l List) MatchesAny(name string) bool {
+	for _, m := range l {
+		if m.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
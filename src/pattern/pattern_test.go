@@ -0,0 +1,61 @@
+package pattern
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		matching []string
+		other    []string
+	}{
+		{pattern: "eth0", matching: []string{"eth0"}, other: []string{"eth01", "xeth0"}},
+		{pattern: "eth*", matching: []string{"eth", "eth0", "eth0.100"}, other: []string{"veth0"}},
+		{pattern: "eth?", matching: []string{"eth0"}, other: []string{"eth", "eth10"}},
+		{pattern: "app.kubernetes.io/*", matching: []string{"app.kubernetes.io/name"}, other: []string{"appXkubernetes.io/name"}},
+		{pattern: "/^team-[a-z]+$/", matching: []string{"team-a"}, other: []string{"team-1", "/^team-[a-z]+$/"}},
+		// Regular expressions are not anchored
+		{pattern: "/veth/", matching: []string{"veth0", "aveth"}, other: []string{"eth0"}},
+		{pattern: "/", matching: []string{"/"}, other: []string{""}},
+	}
+	for _, c := range cases {
+		t.Run(c.pattern, func(t *testing.T) {
+			m, err := Compile(c.pattern)
+			require.NoError(t, err)
+			for _, name := range c.matching {
+				assert.True(t, m.Matches(name), name)
+			}
+			for _, name := range c.other {
+				assert.False(t, m.Matches(name), name)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	_, err := Compile("")
+	assert.Equal(t, ErrEmpty, err)
+
+	_, err = Compile("/veth(/")
+	assert.EqualError(t, err, "invalid regular expression \"/veth(/\": error parsing regexp: missing closing ): `veth(`")
+
+	_, err = CompileList([]string{"eth*", ""})
+	assert.Equal(t, ErrEmpty, err)
+}
+
+func TestGlob_SlashesAreLiteral(t *testing.T) {
+	assert.True(t, Glob("/veth/").Matches("/veth/"))
+	assert.False(t, Glob("/veth/").Matches("veth"))
+}
+
+func TestList_MatchesAny(t *testing.T) {
+	l := Globs([]string{"kube-*", "default"})
+	assert.True(t, l.MatchesAny("kube-system"))
+	assert.True(t, l.MatchesAny("default"))
+	assert.False(t, l.MatchesAny("monitoring"))
+	assert.False(t, List(nil).MatchesAny("default"))
+}
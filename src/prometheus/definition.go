@@ -458,6 +458,103 @@ func suffixLabelsInOrder(metricName string, labels Labels) string {
 // Since it expects the RawValue to be of type []Metric it should be
 // used when grouping with GroupEntityMetricsBySpec.
 func FromSummary(key string) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		value, err := definition.FromRaw(key)(groupLabel, entityID, groups)
+		if err != nil {
+			return nil, err
+		}
+
+		val, _, err := summaryValues(key, value)
+		if err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+}
+
+// DroppedSummaryValues returns the values of the given raw summary that FromSummary discards because New Relic
+// does not support them, like NaN quantiles, indexed by the name they would have been reported with. Raw values
+// that are not summaries have no dropped values.
+func DroppedSummaryValues(key string, value definition.RawValue) map[string]float64 {
+	_, dropped, err := summaryValues(key, value)
+	if err != nil {
+		return nil
+	}
+	return dropped
+}
+
+// summaryValues returns the values of the summaries of the given raw value, named after the given key, split in
+// the valid ones and the ones dropped by validNRValue.
+func summaryValues(key string, value definition.RawValue) (val definition.FetchedValues, dropped map[string]float64, err error) {
+	metrics, ok := value.([]Metric)
+	if !ok {
+		return nil, nil, fmt.Errorf(
+			"incompatible metric type for %s. Expected: []Metric. Got: %T",
+			key,
+			value,
+		)
+	}
+
+	val = make(definition.FetchedValues)
+	dropped = make(map[string]float64)
+	for _, metric := range metrics {
+		summary, ok := metric.Value.(*model.Summary)
+		if !ok {
+			return nil, nil, fmt.Errorf(
+				"incompatible metric type for %s. Expected: Summary. Got: %T",
+				key,
+				metric.Value,
+			)
+		}
+		name := suffixLabelsInOrder(key, metric.Labels)
+		val[fmt.Sprintf("%s_count", name)] = summary.GetSampleCount()
+
+		sumVal := summary.GetSampleSum()
+		if validNRValue(sumVal) {
+			val[fmt.Sprintf("%s_sum", name)] = sumVal
+		} else {
+			dropped[fmt.Sprintf("%s_sum", name)] = sumVal
+		}
+
+		for _, q := range summary.GetQuantile() {
+			quantileVal := q.GetValue()
+			nameWithQuantileSuffix := fmt.Sprintf(
+				"%s_quantile_%s",
+				name,
+				strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64),
+			)
+			if validNRValue(quantileVal) {
+				val[nameWithQuantileSuffix] = quantileVal
+			} else {
+				dropped[nameWithQuantileSuffix] = quantileVal
+			}
+		}
+	}
+	return val, dropped, nil
+}
+
+// FromHistogram creates a FetchFunc that fetches values from histogram
+// metrics. The returned values are the count, the sum and the cumulative
+// count of every bucket, but the +Inf one, which is the same as the count.
+// Their names follow the format:
+// - <metric_name>_<label_1>_<label_1_value>_..._<label_n>_<label_n_value>_count
+// - <metric_name>_<label_1>_<label_1_value>_..._<label_n>_<label_n_value>_sum
+// - <metric_name>_<label_1>_<label_1_value>_..._<label_n>_<label_n_value>_bucket_<upper_bound>
+//
+// Since it expects the RawValue to be of type []Metric it should be
+// used when grouping with GroupEntityMetricsBySpec.
+func FromHistogram(key string) definition.FetchFunc {
+	return fromHistogram(key, true)
+}
+
+// FromHistogramTotals creates a FetchFunc that fetches only the count and
+// the sum of histogram metrics, for the histograms with too many label
+// combinations to report all their buckets.
+func FromHistogramTotals(key string) definition.FetchFunc {
+	return fromHistogram(key, false)
+}
+
+func fromHistogram(key string, withBuckets bool) definition.FetchFunc {
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
 		value, err := definition.FromRaw(key)(groupLabel, entityID, groups)
 		if err != nil {
@@ -475,32 +572,33 @@ func FromSummary(key string) definition.FetchFunc {
 
 		val := make(definition.FetchedValues)
 		for _, metric := range metrics {
-			summary, ok := metric.Value.(*model.Summary)
+			histogram, ok := metric.Value.(*model.Histogram)
 			if !ok {
 				return nil, fmt.Errorf(
-					"incompatible metric type for %s. Expected: Summary. Got: %T",
+					"incompatible metric type for %s. Expected: Histogram. Got: %T",
 					key,
 					metric.Value,
 				)
 			}
 			name := suffixLabelsInOrder(key, metric.Labels)
-			val[fmt.Sprintf("%s_count", name)] = summary.GetSampleCount()
-
-			sumVal := summary.GetSampleSum()
-			if validNRValue(sumVal) {
+			val[fmt.Sprintf("%s_count", name)] = histogram.GetSampleCount()
+			if sumVal := histogram.GetSampleSum(); validNRValue(sumVal) {
 				val[fmt.Sprintf("%s_sum", name)] = sumVal
 			}
 
-			for _, q := range summary.GetQuantile() {
-				quantileVal := q.GetValue()
-				if validNRValue(quantileVal) {
-					nameWithQuantileSuffix := fmt.Sprintf(
-						"%s_quantile_%s",
-						name,
-						strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64),
-					)
-					val[nameWithQuantileSuffix] = quantileVal
+			if !withBuckets {
+				continue
+			}
+			for _, b := range histogram.GetBucket() {
+				if !validNRValue(b.GetUpperBound()) {
+					continue
 				}
+				nameWithBucketSuffix := fmt.Sprintf(
+					"%s_bucket_%s",
+					name,
+					strconv.FormatFloat(b.GetUpperBound(), 'f', -1, 64),
+				)
+				val[nameWithBucketSuffix] = b.GetCumulativeCount()
 			}
 		}
 		return val, nil
@@ -649,6 +747,15 @@ func InheritAllLabelsFrom(parentGroupLabel, relatedMetricKey string) definition.
 	}
 }
 
+// InheritAllAnnotationsFrom gets all the annotation values from a related metric, like kube_pod_annotations, and
+// changes the prefix "annotation_" for "annotation.".
+// Related metric means any metric you can get with the info that you have in your own metric.
+func InheritAllAnnotationsFrom(parentGroupLabel, relatedMetricKey string) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		return labelsFromMetric(parentGroupLabel, relatedMetricKey, groupLabel, entityID, groups, "annotation")
+	}
+}
+
 // InheritAllSelectorsFrom gets all the label values from from a related
 // metric and changes the prefix "selector_" for "selector.". It's meant to
 // be used with metrics that contain label selectors.
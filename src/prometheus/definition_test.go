@@ -515,6 +515,27 @@ func TestGroupEntityMetricsBySpec_NoMatch(t *testing.T) {
 	assert.Empty(t, metricGroup)
 }
 
+var histogramRawGroups = definition.RawGroups{
+	"scheduler": {
+		"kube-scheduler-minikube": {
+			"kubelet_pleg_relist_duration_seconds": []Metric{
+				{
+					Labels: Labels{},
+					Value: &model.Histogram{
+						SampleCount: uint64Ptr(10),
+						SampleSum:   float64Ptr(0.25),
+						Bucket: []*model.Bucket{
+							{UpperBound: float64Ptr(0.1), CumulativeCount: uint64Ptr(8)},
+							{UpperBound: float64Ptr(1), CumulativeCount: uint64Ptr(10)},
+							{UpperBound: float64Ptr(math.Inf(1)), CumulativeCount: uint64Ptr(10)},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
 func TestFetchFuncs_CorrectValue(t *testing.T) {
 
 	testCases := []struct {
@@ -680,6 +701,26 @@ func TestFetchFuncs_CorrectValue(t *testing.T) {
 				"http_request_duration_microseconds_handler_other_l1_v1_l2_v2_sum":                float64(45),
 			},
 		},
+		{
+			name:      "FromHistogram correct value",
+			rawGroups: histogramRawGroups,
+			fetchFunc: FromHistogram("kubelet_pleg_relist_duration_seconds"),
+			expectedFetchedValue: definition.FetchedValues{
+				"kubelet_pleg_relist_duration_seconds_count":      uint64(10),
+				"kubelet_pleg_relist_duration_seconds_sum":        float64(0.25),
+				"kubelet_pleg_relist_duration_seconds_bucket_0.1": uint64(8),
+				"kubelet_pleg_relist_duration_seconds_bucket_1":   uint64(10),
+			},
+		},
+		{
+			name:      "FromHistogramTotals correct value",
+			rawGroups: histogramRawGroups,
+			fetchFunc: FromHistogramTotals("kubelet_pleg_relist_duration_seconds"),
+			expectedFetchedValue: definition.FetchedValues{
+				"kubelet_pleg_relist_duration_seconds_count": uint64(10),
+				"kubelet_pleg_relist_duration_seconds_sum":   float64(0.25),
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1410,3 +1451,26 @@ func TestControlPlaneComponentTypeGenerator(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "k8s:myCluster:controlplane:my-component", generatedType)
 }
+
+func TestDroppedSummaryValues(t *testing.T) {
+	raw := []Metric{
+		{
+			Labels: Labels{"handler": "prometheus"},
+			Value: &model.Summary{
+				SampleCount: uint64Ptr(5),
+				SampleSum:   float64Ptr(math.Inf(1)),
+				Quantile: []*model.Quantile{
+					{Quantile: float64Ptr(0.5), Value: float64Ptr(math.NaN())},
+					{Quantile: float64Ptr(0.99), Value: float64Ptr(44)},
+				},
+			},
+		},
+	}
+
+	dropped := DroppedSummaryValues("http_request_duration_microseconds", raw)
+	require.Len(t, dropped, 2)
+	assert.True(t, math.IsInf(dropped["http_request_duration_microseconds_handler_prometheus_sum"], 1))
+	assert.True(t, math.IsNaN(dropped["http_request_duration_microseconds_handler_prometheus_quantile_0.5"]))
+
+	assert.Empty(t, DroppedSummaryValues("process_open_fds", Metric{Value: GaugeValue(3)}))
+}
@@ -0,0 +1,226 @@
+// Package exposition exposes the metrics populated by the integration in the Prometheus text exposition format.
+package exposition
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	eventTypeAttribute = "event_type"
+	// infoMetricName is the name of the metric holding the non-identifying attributes of the entities.
+	infoMetricName = "info"
+)
+
+// identityAttributes are the attributes identifying the entity a metric set belongs to. They are the only
+// attributes labelling the samples, so the other attributes changing, e.g. the status of a pod, don't create new
+// series for every metric of the entity.
+var identityAttributes = map[string]bool{
+	"entityName":    true,
+	"clusterName":   true,
+	"namespace":     true,
+	"namespaceName": true,
+	"podName":       true,
+	"containerName": true,
+	"nodeName":      true,
+}
+
+var (
+	invalidNameChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+	// wordBoundary matches the boundaries between words in camel cased names, e.g. "cpuUsedCores" or "nodeIP".
+	wordBoundary = regexp.MustCompile("([a-z0-9])([A-Z])|([A-Z])([A-Z][a-z])")
+)
+
+// Exporter holds the metrics of the last collection, converted to Prometheus metric families, and serves them
+// over HTTP. Metrics are recorded during a collection and only served once the collection is committed, so
+// scrapes never see the data of a collection that is still in progress.
+type Exporter struct {
+	lock     sync.RWMutex
+	pending  map[string]*family
+	families []*dto.MetricFamily
+}
+
+type family struct {
+	name    string
+	help    string
+	metrics map[string]*dto.Metric
+}
+
+// NewExporter creates a new Exporter, with no metrics.
+func NewExporter() *Exporter {
+	return &Exporter{pending: make(map[string]*family)}
+}
+
+// Record converts the given metric sets into metrics of the collection in progress. Each numeric metric of a
+// metric set becomes a sample of the metric family named after its event type and its name, labelled with the
+// identity attributes of the metric set. The other string attributes label a single sample, valued 1, of the
+// info metric family of the event type, e.g. k8s_pod_info, which can be joined with the other families on the
+// identity labels.
+func (e *Exporter) Record(metricSets ...sdkMetric.MetricSet) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, ms := range metricSets {
+		eventType, _ := ms[eventTypeAttribute].(string)
+		labels, infoLabels := labelPairs(ms)
+		signature := labelsSignature(labels)
+
+		for name, value := range ms {
+			if _, ok := value.(string); ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+			if err != nil {
+				continue
+			}
+			e.record(eventType, name, fmt.Sprintf("%s of the %s samples.", name, eventType), signature, labels, v)
+		}
+
+		if len(infoLabels) > len(labels) {
+			e.record(eventType, infoMetricName, fmt.Sprintf("Attributes of the %s samples.", eventType), signature, infoLabels, 1)
+		}
+	}
+}
+
+func (e *Exporter) record(eventType, name, help, signature string, labels []*dto.LabelPair, value float64) {
+	familyName := FamilyName(eventType, name)
+	f, ok := e.pending[familyName]
+	if !ok {
+		f = &family{
+			name:    familyName,
+			help:    help,
+			metrics: make(map[string]*dto.Metric),
+		}
+		e.pending[familyName] = f
+	}
+	// The same entity can be reported by several jobs, e.g. the cluster, in which case the last value wins.
+	f.metrics[signature] = &dto.Metric{
+		Label: labels,
+		Gauge: &dto.Gauge{Value: proto.Float64(value)},
+	}
+}
+
+// Commit replaces the served metrics by the ones recorded since the last commit.
+func (e *Exporter) Commit() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	names := make([]string, 0, len(e.pending))
+	for name := range e.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		f := e.pending[name]
+		signatures := make([]string, 0, len(f.metrics))
+		for s := range f.metrics {
+			signatures = append(signatures, s)
+		}
+		sort.Strings(signatures)
+
+		mf := &dto.MetricFamily{
+			Name: proto.String(f.name),
+			Help: proto.String(f.help),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+		for _, s := range signatures {
+			mf.Metric = append(mf.Metric, f.metrics[s])
+		}
+		families = append(families, mf)
+	}
+
+	e.families = families
+	e.pending = make(map[string]*family)
+}
+
+// ServeHTTP writes the committed metrics in the format negotiated with the client, the text exposition
+// format by default.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	format := expfmt.Negotiate(r.Header)
+	w.Header().Set("Content-Type", string(format))
+	encoder := expfmt.NewEncoder(w, format)
+	for _, mf := range e.families {
+		if err := encoder.Encode(mf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// FamilyName returns the name of the Prometheus metric family for the given metric of the given event type,
+// e.g. "k8s_pod_cpu_used_cores" for the cpuUsedCores metric of the K8sPodSample event type.
+func FamilyName(eventType, metricName string) string {
+	prefix := snakeCase(strings.TrimSuffix(eventType, "Sample"))
+	if prefix == "" {
+		return snakeCase(metricName)
+	}
+	return prefix + "_" + snakeCase(metricName)
+}
+
+// LabelName returns the name of the Prometheus label for the given attribute, e.g. "namespace_name" for the
+// namespaceName attribute or "label_app" for the label.app attribute.
+func LabelName(attribute string) string {
+	return snakeCase(attribute)
+}
+
+func snakeCase(name string) string {
+	name = wordBoundary.ReplaceAllString(name, "${1}${3}_${2}${4}")
+	// a second pass is needed for overlapping boundaries, e.g. "aBC"
+	name = wordBoundary.ReplaceAllString(name, "${1}${3}_${2}${4}")
+	name = strings.ToLower(invalidNameChars.ReplaceAllString(name, "_"))
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// labelPairs returns the non-empty identity attributes of the metric set as labels, and the labels of its info
+// metric, with all its non-empty string attributes. Both are sorted by name.
+func labelPairs(ms sdkMetric.MetricSet) (labels, infoLabels []*dto.LabelPair) {
+	for name, value := range ms {
+		s, ok := value.(string)
+		if !ok || s == "" || name == eventTypeAttribute {
+			continue
+		}
+		label := &dto.LabelPair{Name: proto.String(LabelName(name)), Value: proto.String(s)}
+		if identityAttributes[name] {
+			labels = append(labels, label)
+		}
+		infoLabels = append(infoLabels, label)
+	}
+	sortLabels(labels)
+	sortLabels(infoLabels)
+	return labels, infoLabels
+}
+
+func sortLabels(labels []*dto.LabelPair) {
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].GetName() < labels[j].GetName()
+	})
+}
+
+func labelsSignature(labels []*dto.LabelPair) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.GetName())
+		b.WriteByte('=')
+		b.WriteString(l.GetValue())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package exposition
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFamilyName(t *testing.T) {
+	testCases := []struct {
+		eventType string
+		metric    string
+		expected  string
+	}{
+		{"K8sPodSample", "cpuUsedCores", "k8s_pod_cpu_used_cores"},
+		{"K8sNodeSample", "net.rxBytesPerSecond", "k8s_node_net_rx_bytes_per_second"},
+		{"K8sApiServerSample", "apiserverRequestsDelta_verb_GET_code_200", "k8s_api_server_apiserver_requests_delta_verb_get_code_200"},
+		{"K8sContainerSample", "memoryMappedFileIsLimited", "k8s_container_memory_mapped_file_is_limited"},
+		{"", "isReady", "is_ready"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, FamilyName(tc.eventType, tc.metric))
+	}
+}
+
+func TestLabelName(t *testing.T) {
+	assert.Equal(t, "namespace_name", LabelName("namespaceName"))
+	assert.Equal(t, "node_ip", LabelName("nodeIP"))
+	assert.Equal(t, "label_app_kubernetes_io_name", LabelName("label.app.kubernetes.io/name"))
+	assert.Equal(t, "_1label", LabelName("1label"))
+}
+
+func scrapeExporter(t *testing.T, e *Exporter) string {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestExporter(t *testing.T) {
+	e := NewExporter()
+	e.Record(
+		sdkMetric.MetricSet{
+			"event_type":    "K8sPodSample",
+			"entityName":    "k8s:cluster:default:pod:nginx",
+			"podName":       "nginx",
+			"namespaceName": "default",
+			"reason":        "",
+			"isReady":       1,
+			"cpuUsedCores":  prometheus.GaugeValue(0.25),
+		},
+		sdkMetric.MetricSet{
+			"event_type":    "K8sPodSample",
+			"entityName":    "k8s:cluster:kube-system:pod:coredns",
+			"podName":       "coredns",
+			"namespaceName": "kube-system",
+			"isReady":       uint64(0),
+		},
+	)
+
+	// Nothing is served until the collection is committed
+	assert.Empty(t, scrapeExporter(t, e))
+
+	e.Commit()
+
+	expected := `# HELP k8s_pod_cpu_used_cores cpuUsedCores of the K8sPodSample samples.
+# TYPE k8s_pod_cpu_used_cores gauge
+k8s_pod_cpu_used_cores{entity_name="k8s:cluster:default:pod:nginx",namespace_name="default",pod_name="nginx"} 0.25
+# HELP k8s_pod_is_ready isReady of the K8sPodSample samples.
+# TYPE k8s_pod_is_ready gauge
+k8s_pod_is_ready{entity_name="k8s:cluster:default:pod:nginx",namespace_name="default",pod_name="nginx"} 1
+k8s_pod_is_ready{entity_name="k8s:cluster:kube-system:pod:coredns",namespace_name="kube-system",pod_name="coredns"} 0
+`
+	assert.Equal(t, expected, scrapeExporter(t, e))
+}
+
+func TestExporter_CommitReplacesPreviousCollection(t *testing.T) {
+	e := NewExporter()
+	e.Record(sdkMetric.MetricSet{"event_type": "K8sClusterSample", "clusterName": "cluster", "up": 1})
+	e.Commit()
+
+	// The same entity reported twice in a collection is only exposed once, with the last value
+	e.Record(sdkMetric.MetricSet{"event_type": "K8sClusterSample", "clusterName": "cluster", "nodes": 2})
+	e.Record(sdkMetric.MetricSet{"event_type": "K8sClusterSample", "clusterName": "cluster", "nodes": 3})
+	e.Commit()
+
+	expected := `# HELP k8s_cluster_nodes nodes of the K8sClusterSample samples.
+# TYPE k8s_cluster_nodes gauge
+k8s_cluster_nodes{cluster_name="cluster"} 3
+`
+	assert.Equal(t, expected, scrapeExporter(t, e))
+}
+
+func TestExporter_OnlyIdentityAttributesLabelTheMetrics(t *testing.T) {
+	e := NewExporter()
+	e.Record(sdkMetric.MetricSet{
+		"event_type":    "K8sPodSample",
+		"entityName":    "k8s:cluster:default:pod:nginx",
+		"clusterName":   "cluster",
+		"podName":       "nginx",
+		"namespaceName": "default",
+		"status":        "Running",
+		"label.app":     "nginx",
+		"isReady":       1,
+	})
+	e.Commit()
+
+	expected := `# HELP k8s_pod_info Attributes of the K8sPodSample samples.
+# TYPE k8s_pod_info gauge
+k8s_pod_info{cluster_name="cluster",entity_name="k8s:cluster:default:pod:nginx",label_app="nginx",namespace_name="default",pod_name="nginx",status="Running"} 1
+# HELP k8s_pod_is_ready isReady of the K8sPodSample samples.
+# TYPE k8s_pod_is_ready gauge
+k8s_pod_is_ready{cluster_name="cluster",entity_name="k8s:cluster:default:pod:nginx",namespace_name="default",pod_name="nginx"} 1
+`
+	assert.Equal(t, expected, scrapeExporter(t, e))
+}
@@ -0,0 +1,36 @@
+package exposition
+
+import (
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+type populator struct {
+	populator data.Populator
+	exporter  *Exporter
+}
+
+// NewPopulator creates a data.Populator that records the metric sets populated by the given populator into
+// the given Exporter, so they are exposed in the Prometheus exposition format once the collection is
+// committed.
+func NewPopulator(p data.Populator, e *Exporter) data.Populator {
+	return &populator{populator: p, exporter: e}
+}
+
+// Populate populates the integration using the wrapped populator and records the new metric sets.
+func (p *populator) Populate(
+	groups definition.RawGroups,
+	specGroups definition.SpecGroups,
+	i *sdk.IntegrationProtocol2,
+	clusterName string,
+	k8sVersion *version.Info,
+) data.PopulateResult {
+	before := data.SnapshotMetricSets(i)
+	result := p.populator.Populate(groups, specGroups, i, clusterName, k8sVersion)
+	populated := before.NewMetricSets(i)
+	p.exporter.Record(populated...)
+
+	return result
+}
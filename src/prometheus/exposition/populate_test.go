@@ -0,0 +1,56 @@
+package exposition
+
+import (
+	"strings"
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// entityPopulator populates one metric set per entity of the "test" group.
+type entityPopulator struct{}
+
+func (entityPopulator) Populate(
+	groups definition.RawGroups,
+	_ definition.SpecGroups,
+	i *sdk.IntegrationProtocol2,
+	_ string,
+	_ *version.Info,
+) data.PopulateResult {
+	for entityID, raw := range groups["test"] {
+		e, err := i.Entity(entityID, "test")
+		if err != nil {
+			return data.PopulateResult{Errors: []error{err}}
+		}
+		ms := e.NewMetricSet("TestSample")
+		ms.SetMetric("name", entityID, sdkMetric.ATTRIBUTE)  // nolint: errcheck
+		ms.SetMetric("value", raw["value"], sdkMetric.GAUGE) // nolint: errcheck
+	}
+	return data.PopulateResult{Populated: true}
+}
+
+func TestPopulator_RecordsOnlyNewMetricSets(t *testing.T) {
+	i, err := sdk.NewIntegrationProtocol2("nr.test", "1.0.0", new(struct{}))
+	require.NoError(t, err)
+
+	e := NewExporter()
+	p := NewPopulator(entityPopulator{}, e)
+
+	// The integration already holds data populated by another job
+	result := entityPopulator{}.Populate(definition.RawGroups{"test": {"previous": {"value": 1}}}, nil, i, "", nil)
+	require.True(t, result.Populated)
+
+	result = p.Populate(definition.RawGroups{"test": {"new": {"value": 2}}}, nil, i, "", nil)
+	assert.True(t, result.Populated)
+	e.Commit()
+
+	body := scrapeExporter(t, e)
+	assert.True(t, strings.Contains(body, `test_value{entity_name="test:new"} 2`), body)
+	assert.False(t, strings.Contains(body, `entity_name="test:previous"`), body)
+}
@@ -114,8 +114,7 @@ func valueFromPrometheus(metricType model.MetricType, metric *model.Metric) Valu
 	case model.MetricType_GAUGE:
 		return GaugeValue(metric.Gauge.GetValue())
 	case model.MetricType_HISTOGRAM:
-		// Not supported yet
-		fallthrough
+		return metric.Histogram
 	case model.MetricType_SUMMARY:
 		return metric.Summary
 	case model.MetricType_UNTYPED:
@@ -140,9 +139,11 @@ func Do(c client.HTTPClient, endpoint string, queries []Query) ([]MetricFamily,
 
 	metrics := make([]MetricFamily, 0)
 	ch := make(chan *model.MetricFamily)
+	// The parsing error is sent back once ch is closed, so it is only read after the loop below.
+	errCh := make(chan error, 1)
 
 	go func() {
-		err = prom2json.ParseResponse(resp, ch)
+		errCh <- prom2json.ParseResponse(resp, ch)
 	}()
 
 	for promMetricFamily := range ch {
@@ -154,7 +155,7 @@ func Do(c client.HTTPClient, endpoint string, queries []Query) ([]MetricFamily,
 		}
 	}
 
-	return metrics, err
+	return metrics, <-errCh
 }
 
 func labelsFromPrometheus(pairs []*model.LabelPair) Labels {
@@ -0,0 +1,208 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+)
+
+var logger = logrus.StandardLogger()
+
+// staticClient answers every path with the given bodies, and with a 404 status the paths without one.
+type staticClient struct {
+	nodeIP string
+	bodies map[string]string
+}
+
+func (c staticClient) Do(method, path string) (*http.Response, error) {
+	body, ok := c.bodies[path]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+}
+
+func (c staticClient) NodeIP() string {
+	return c.nodeIP
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "test_record")
+	require.NoError(t, err)
+	return dir
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close() // nolint: errcheck
+	raw, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func TestRecordAndReplay_HTTPClient(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	recorder, err := NewRecorder(dir, logger)
+	require.NoError(t, err)
+	recorder.Node("node-1", "eth0")
+
+	kubelet := recorder.HTTPClient("kubelet", staticClient{
+		nodeIP: "10.0.0.1",
+		bodies: map[string]string{"/pods": `{"items":[]}`, "/metrics/cadvisor": "# cadvisor"},
+	})
+	ksm := recorder.HTTPClient("ksm/10.0.0.2", staticClient{nodeIP: "10.0.0.2", bodies: map[string]string{"/metrics": "# ksm"}})
+
+	// The recording client returns the responses untouched
+	resp, err := kubelet.Do(http.MethodGet, "/pods")
+	require.NoError(t, err)
+	assert.Equal(t, `{"items":[]}`, body(t, resp))
+	_, err = kubelet.Do(http.MethodGet, "/metrics/cadvisor")
+	require.NoError(t, err)
+	_, err = ksm.Do(http.MethodGet, "/metrics")
+	require.NoError(t, err)
+	resp, err = kubelet.Do(http.MethodGet, "/stats/summary")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The responses are stored following the URL paths
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "kubelet", "metrics", "cadvisor"))
+	require.NoError(t, err)
+	assert.Equal(t, "# cadvisor", string(raw))
+	_, err = os.Stat(filepath.Join(dir, "kubelet", "stats", "summary"))
+	assert.True(t, os.IsNotExist(err))
+
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", replayer.NodeName())
+	assert.Equal(t, "eth0", replayer.DefaultInterface())
+	assert.Equal(t, []string{"ksm/10.0.0.2"}, replayer.Sources("ksm"))
+	assert.Empty(t, replayer.Sources("k"))
+
+	replayed := replayer.HTTPClient("kubelet")
+	assert.Equal(t, "10.0.0.1", replayed.NodeIP())
+	resp, err = replayed.Do(http.MethodGet, "/pods")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"items":[]}`, body(t, resp))
+
+	resp, err = replayed.Do(http.MethodGet, "/stats/summary")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = replayer.HTTPClient("ksm/10.0.0.2").Do(http.MethodGet, "/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, "# ksm", body(t, resp))
+	assert.Equal(t, "10.0.0.2", replayer.HTTPClient("ksm/10.0.0.2").NodeIP())
+}
+
+func TestRecordAndReplay_NestedPaths(t *testing.T) {
+	for _, order := range [][]string{{"/metrics", "/metrics/cadvisor"}, {"/metrics/cadvisor", "/metrics"}} {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir) // nolint: errcheck
+
+		recorder, err := NewRecorder(dir, logger)
+		require.NoError(t, err)
+		kubelet := recorder.HTTPClient("kubelet", staticClient{
+			bodies: map[string]string{"/metrics": "# kubelet", "/metrics/cadvisor": "# cadvisor"},
+		})
+		for _, p := range order {
+			_, err = kubelet.Do(http.MethodGet, p)
+			require.NoError(t, err)
+		}
+
+		raw, err := ioutil.ReadFile(filepath.Join(dir, "kubelet", "metrics", "index.html"))
+		require.NoError(t, err)
+		assert.Equal(t, "# kubelet", string(raw))
+
+		replayer, err := NewReplayer(dir)
+		require.NoError(t, err)
+		resp, err := replayer.HTTPClient("kubelet").Do(http.MethodGet, "/metrics")
+		require.NoError(t, err)
+		assert.Equal(t, "# kubelet", body(t, resp))
+		resp, err = replayer.HTTPClient("kubelet").Do(http.MethodGet, "/metrics/cadvisor")
+		require.NoError(t, err)
+		assert.Equal(t, "# cadvisor", body(t, resp))
+	}
+}
+
+func TestRecordAndReplay_Kubernetes(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"kubernetes.io/role": "master"}}}
+	services := &v1.ServiceList{Items: []v1.Service{{ObjectMeta: metav1.ObjectMeta{Name: "kube-state-metrics"}}}}
+	k8s := new(client.MockedKubernetes)
+	k8s.On("FindNode", "node-1").Return(node, nil)
+	k8s.On("FindNode", "node-2").Return(&v1.Node{}, errors.New("nodes \"node-2\" not found"))
+	k8s.On("FindServicesByLabel", "app.kubernetes.io/name", "kube-state-metrics").Return(services, nil)
+	k8s.On("ServerVersion").Return(&version.Info{GitVersion: "v1.18.2"}, nil)
+	k8s.On("FindSecret", "etcd-tls").Return(&v1.Secret{}, nil)
+
+	recorder, err := NewRecorder(dir, logger)
+	require.NoError(t, err)
+	recorded := recorder.Kubernetes(k8s)
+
+	_, err = recorded.FindNode("node-1")
+	require.NoError(t, err)
+	_, err = recorded.FindNode("node-2")
+	require.Error(t, err)
+	_, err = recorded.FindServicesByLabel("app.kubernetes.io/name", "kube-state-metrics")
+	require.NoError(t, err)
+	_, err = recorded.ServerVersion()
+	require.NoError(t, err)
+	_, err = recorded.FindSecret("etcd-tls", "default")
+	require.NoError(t, err)
+
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+	replayed := replayer.Kubernetes()
+
+	n, err := replayed.FindNode("node-1")
+	require.NoError(t, err)
+	assert.Equal(t, node, n)
+
+	_, err = replayed.FindNode("node-2")
+	assert.EqualError(t, err, "nodes \"node-2\" not found")
+
+	s, err := replayed.FindServicesByLabel("app.kubernetes.io/name", "kube-state-metrics")
+	require.NoError(t, err)
+	assert.Equal(t, services, s)
+
+	v, err := replayed.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.18.2", v.GitVersion)
+
+	// Lookups that were not recorded fail, and secrets are never recorded
+	_, err = replayed.ListServices()
+	assert.Error(t, err)
+	_, err = replayed.FindSecret("etcd-tls", "default")
+	assert.Error(t, err)
+}
+
+func TestResponseFile(t *testing.T) {
+	assert.Equal(t, "kubelet/stats/summary", responseFile("kubelet", "/stats/summary"))
+	assert.Equal(t, "ksm/metrics", responseFile("ksm", "metrics?format=text"))
+	assert.Equal(t, "controlplane/etcd/metrics", responseFile("controlplane/etcd", "/../../metrics"))
+}
+
+func TestNewReplayer_MissingRecording(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	_, err := NewReplayer(dir)
+	assert.Error(t, err)
+}
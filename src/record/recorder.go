@@ -0,0 +1,282 @@
+// Package record saves the responses of the data sources of the integration to a directory, and replays them,
+// so the collection of a cluster can be reproduced without access to it.
+//
+// The responses of each HTTP client are stored under a directory named after their source, following the URL
+// path, which is the layout of the cmd/kubernetes-static data: kubelet/pods, kubelet/stats/summary,
+// kubelet/metrics/cadvisor, ksm/metrics and controlplane/<component>/metrics. Responses to paths which are also
+// the parent of other paths are stored in the index.html file of their directory, like kubelet/metrics/index.html
+// for the kubelet /metrics. The Kubernetes API lookups are stored as JSON under the k8s directory.
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+)
+
+const (
+	manifestFile = "sources.json"
+	k8sDir       = "k8s"
+	errorSuffix  = ".error"
+	// indexFile stores the responses to paths which are also the parent of other paths, like kubelet/metrics and
+	// kubelet/metrics/cadvisor. It is where http.FileServer looks up the content of directories too.
+	indexFile = "index.html"
+)
+
+// manifest describes the recorded sources, with the values the integration discovers from the node it runs on.
+type manifest struct {
+	NodeName         string            `json:"nodeName"`
+	DefaultInterface string            `json:"defaultInterface"`
+	NodeIPs          map[string]string `json:"nodeIPs"`
+}
+
+// Recorder saves the responses of the data sources to a directory. When the integration runs as a daemon, each
+// collection overwrites the responses of the previous one.
+type Recorder struct {
+	lock     sync.Mutex
+	dir      string
+	manifest manifest
+	logger   *logrus.Logger
+}
+
+// NewRecorder creates a Recorder saving the responses to the given directory, which is created if it does not
+// exist.
+func NewRecorder(dir string, logger *logrus.Logger) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating the record directory: %v", err)
+	}
+	r := &Recorder{
+		dir:      dir,
+		manifest: manifest{NodeIPs: make(map[string]string)},
+		logger:   logger,
+	}
+	return r, r.writeManifest()
+}
+
+// Node records the name and the default network interface of the node the integration runs on.
+func (r *Recorder) Node(name, defaultInterface string) {
+	r.lock.Lock()
+	r.manifest.NodeName = name
+	r.manifest.DefaultInterface = defaultInterface
+	r.lock.Unlock()
+	if err := r.writeManifest(); err != nil {
+		r.logger.WithError(err).Warn("recording the node")
+	}
+}
+
+// HTTPClient wraps the given client, saving the body of its successful responses under the directory of the
+// given source.
+func (r *Recorder) HTTPClient(source string, c client.HTTPClient) client.HTTPClient {
+	r.lock.Lock()
+	r.manifest.NodeIPs[source] = c.NodeIP()
+	r.lock.Unlock()
+	if err := r.writeManifest(); err != nil {
+		r.logger.WithError(err).Warnf("recording the source %s", source)
+	}
+	return &recordingClient{client: c, recorder: r, source: source}
+}
+
+// Kubernetes wraps the given client, saving the results of its lookups. Secrets are not saved.
+func (r *Recorder) Kubernetes(k client.Kubernetes) client.Kubernetes {
+	return &recordingKubernetes{Kubernetes: k, recorder: r}
+}
+
+func (r *Recorder) writeManifest() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	raw, err := json.MarshalIndent(r.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(r.dir, manifestFile), raw, 0644)
+}
+
+// write saves the given content under the given slash separated name, relative to the record directory.
+func (r *Recorder) write(name string, content []byte) {
+	file := filepath.Join(r.dir, filepath.FromSlash(name))
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(file, content, 0644)
+	}
+	if err != nil {
+		r.logger.WithError(err).Warnf("recording %s", name)
+	}
+}
+
+// writeResponse saves the given response under the given slash separated name, relative to the record directory.
+// Responses saved at any of its parent directories are moved to their index file.
+func (r *Recorder) writeResponse(name string, content []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		file := filepath.Join(r.dir, filepath.FromSlash(dir))
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			if err := moveToIndex(file); err != nil {
+				r.logger.WithError(err).Warnf("recording %s", name)
+				return
+			}
+		}
+	}
+	if info, err := os.Stat(filepath.Join(r.dir, filepath.FromSlash(name))); err == nil && info.IsDir() {
+		name = path.Join(name, indexFile)
+	}
+	r.write(name, content)
+}
+
+// moveToIndex moves the given file to the index file of a directory with the same name.
+func moveToIndex(file string) error {
+	tmp := file + ".tmp"
+	if err := os.Rename(file, tmp); err != nil {
+		return err
+	}
+	if err := os.Mkdir(file, 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(file, indexFile))
+}
+
+// writeLookup saves the result of a Kubernetes API lookup: the JSON encoded value, or the error message.
+func (r *Recorder) writeLookup(name string, value interface{}, err error) {
+	// Only one of the files is kept, so replaying returns the last result.
+	os.Remove(filepath.Join(r.dir, filepath.FromSlash(name+errorSuffix))) // nolint: errcheck
+	os.Remove(filepath.Join(r.dir, filepath.FromSlash(name)))             // nolint: errcheck
+	if err != nil {
+		r.write(name+errorSuffix, []byte(err.Error()))
+		return
+	}
+	raw, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		r.logger.WithError(err).Warnf("recording %s", name)
+		return
+	}
+	r.write(name, raw)
+}
+
+// responseFile returns the slash separated name of the file storing the responses of the given source to the
+// given URL path, or of the directory holding it in its index file. The query is ignored, and the path can not
+// leave the directory of the source.
+func responseFile(source, urlPath string) string {
+	if u, err := url.Parse(urlPath); err == nil {
+		urlPath = u.Path
+	}
+	return path.Join(source, path.Clean("/"+urlPath))
+}
+
+// lookupFile returns the slash separated name of the file storing the result of the given lookup.
+func lookupFile(method string, lookupArgs ...string) string {
+	if len(lookupArgs) == 0 {
+		return path.Join(k8sDir, method+".json")
+	}
+	escaped := make([]string, 0, len(lookupArgs))
+	for _, a := range lookupArgs {
+		escaped = append(escaped, url.PathEscape(a))
+	}
+	return path.Join(k8sDir, method, strings.Join(escaped, "_")+".json")
+}
+
+type recordingClient struct {
+	client   client.HTTPClient
+	recorder *Recorder
+	source   string
+}
+
+func (c *recordingClient) Do(method, urlPath string) (*http.Response, error) {
+	resp, err := c.client.Do(method, urlPath)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close() // nolint: errcheck
+	if err != nil {
+		return nil, err
+	}
+	c.recorder.writeResponse(responseFile(c.source, urlPath), body)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (c *recordingClient) NodeIP() string {
+	return c.client.NodeIP()
+}
+
+// Describe describes the wrapped client.
+func (c *recordingClient) Describe() client.Description {
+	return client.Describe(c.client)
+}
+
+// recordingKubernetes records the lookups of the embedded client. Config, SecureHTTPClient and FindSecret are not
+// recorded: they hold credentials, which are neither needed to replay the responses nor meant to leave the cluster.
+type recordingKubernetes struct {
+	client.Kubernetes
+	recorder *Recorder
+}
+
+func (k *recordingKubernetes) FindNode(name string) (*v1.Node, error) {
+	node, err := k.Kubernetes.FindNode(name)
+	k.recorder.writeLookup(lookupFile("FindNode", name), node, err)
+	return node, err
+}
+
+func (k *recordingKubernetes) FindPodsByLabel(name, value string) (*v1.PodList, error) {
+	pods, err := k.Kubernetes.FindPodsByLabel(name, value)
+	k.recorder.writeLookup(lookupFile("FindPodsByLabel", name, value), pods, err)
+	return pods, err
+}
+
+func (k *recordingKubernetes) FindPodByName(name string) (*v1.PodList, error) {
+	pods, err := k.Kubernetes.FindPodByName(name)
+	k.recorder.writeLookup(lookupFile("FindPodByName", name), pods, err)
+	return pods, err
+}
+
+func (k *recordingKubernetes) FindPodsByHostname(hostname string) (*v1.PodList, error) {
+	pods, err := k.Kubernetes.FindPodsByHostname(hostname)
+	k.recorder.writeLookup(lookupFile("FindPodsByHostname", hostname), pods, err)
+	return pods, err
+}
+
+func (k *recordingKubernetes) FindServicesByLabel(name, value string) (*v1.ServiceList, error) {
+	services, err := k.Kubernetes.FindServicesByLabel(name, value)
+	k.recorder.writeLookup(lookupFile("FindServicesByLabel", name, value), services, err)
+	return services, err
+}
+
+func (k *recordingKubernetes) FindNamespace(name string) (*v1.Namespace, error) {
+	namespace, err := k.Kubernetes.FindNamespace(name)
+	k.recorder.writeLookup(lookupFile("FindNamespace", name), namespace, err)
+	return namespace, err
+}
+
+func (k *recordingKubernetes) FindOwner(kind, name, namespace string) (*metav1.ObjectMeta, error) {
+	owner, err := k.Kubernetes.FindOwner(kind, name, namespace)
+	k.recorder.writeLookup(lookupFile("FindOwner", kind, name, namespace), owner, err)
+	return owner, err
+}
+
+func (k *recordingKubernetes) ListServices() (*v1.ServiceList, error) {
+	services, err := k.Kubernetes.ListServices()
+	k.recorder.writeLookup(lookupFile("ListServices"), services, err)
+	return services, err
+}
+
+func (k *recordingKubernetes) ServerVersion() (*version.Info, error) {
+	info, err := k.Kubernetes.ServerVersion()
+	k.recorder.writeLookup(lookupFile("ServerVersion"), info, err)
+	return info, err
+}
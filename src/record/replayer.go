@@ -0,0 +1,196 @@
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/prometheus"
+)
+
+// errNotRecorded is returned by the Kubernetes lookups that are never recorded.
+var errNotRecorded = errors.New("not available when replaying recorded responses")
+
+// Replayer serves the responses saved by a Recorder.
+type Replayer struct {
+	dir      string
+	manifest manifest
+}
+
+// NewReplayer creates a Replayer serving the responses saved to the given directory.
+func NewReplayer(dir string) (*Replayer, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading the recorded sources: %v", err)
+	}
+	r := &Replayer{dir: dir}
+	if err := json.Unmarshal(raw, &r.manifest); err != nil {
+		return nil, fmt.Errorf("decoding the recorded sources: %v", err)
+	}
+	return r, nil
+}
+
+// NodeName returns the name of the node the responses were recorded on.
+func (r *Replayer) NodeName() string {
+	return r.manifest.NodeName
+}
+
+// DefaultInterface returns the default network interface of the node the responses were recorded on.
+func (r *Replayer) DefaultInterface() string {
+	return r.manifest.DefaultInterface
+}
+
+// Sources returns, sorted, the recorded sources that are the given one or are nested under it, like ksm/10.0.0.1
+// is nested under ksm.
+func (r *Replayer) Sources(source string) []string {
+	var sources []string
+	for s := range r.manifest.NodeIPs {
+		if s == source || strings.HasPrefix(s, source+"/") {
+			sources = append(sources, s)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// HTTPClient returns a client serving the responses recorded for the given source. Paths without a recorded
+// response are answered with a 404 status.
+func (r *Replayer) HTTPClient(source string) client.HTTPClient {
+	return &replayingClient{dir: r.dir, source: source, nodeIP: r.manifest.NodeIPs[source]}
+}
+
+// Kubernetes returns a client answering the lookups with the recorded results.
+func (r *Replayer) Kubernetes() client.Kubernetes {
+	return &replayingKubernetes{dir: r.dir}
+}
+
+type replayingClient struct {
+	dir    string
+	source string
+	nodeIP string
+}
+
+func (c *replayingClient) Do(method, urlPath string) (*http.Response, error) {
+	file := filepath.Join(c.dir, filepath.FromSlash(responseFile(c.source, urlPath)))
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		file = filepath.Join(file, indexFile)
+	}
+	body, err := ioutil.ReadFile(file)
+	status := http.StatusOK
+	if os.IsNotExist(err) {
+		status = http.StatusNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	// The Prometheus endpoints are always queried for the text format.
+	header.Set("Content-Type", prometheus.AcceptHeader)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+func (c *replayingClient) NodeIP() string {
+	return c.nodeIP
+}
+
+// Describe returns the directory the responses are replayed from.
+func (c *replayingClient) Describe() client.Description {
+	return client.Description{
+		Endpoint:       filepath.Join(c.dir, filepath.FromSlash(c.source)),
+		Authentication: "None (replaying recorded responses)",
+	}
+}
+
+type replayingKubernetes struct {
+	dir string
+}
+
+// read decodes the recorded result of a lookup into the given value, or returns the recorded error.
+func (k *replayingKubernetes) read(name string, value interface{}) error {
+	file := filepath.Join(k.dir, filepath.FromSlash(name))
+	if message, err := ioutil.ReadFile(file + errorSuffix); err == nil {
+		return errors.New(string(message))
+	}
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("the lookup %s was not recorded: %v", name, err)
+	}
+	return json.Unmarshal(raw, value)
+}
+
+func (k *replayingKubernetes) FindNode(name string) (*v1.Node, error) {
+	node := &v1.Node{}
+	return node, k.read(lookupFile("FindNode", name), node)
+}
+
+func (k *replayingKubernetes) FindPodsByLabel(name, value string) (*v1.PodList, error) {
+	pods := &v1.PodList{}
+	return pods, k.read(lookupFile("FindPodsByLabel", name, value), pods)
+}
+
+func (k *replayingKubernetes) FindPodByName(name string) (*v1.PodList, error) {
+	pods := &v1.PodList{}
+	return pods, k.read(lookupFile("FindPodByName", name), pods)
+}
+
+func (k *replayingKubernetes) FindPodsByHostname(hostname string) (*v1.PodList, error) {
+	pods := &v1.PodList{}
+	return pods, k.read(lookupFile("FindPodsByHostname", hostname), pods)
+}
+
+func (k *replayingKubernetes) FindServicesByLabel(name, value string) (*v1.ServiceList, error) {
+	services := &v1.ServiceList{}
+	return services, k.read(lookupFile("FindServicesByLabel", name, value), services)
+}
+
+func (k *replayingKubernetes) FindNamespace(name string) (*v1.Namespace, error) {
+	namespace := &v1.Namespace{}
+	return namespace, k.read(lookupFile("FindNamespace", name), namespace)
+}
+
+func (k *replayingKubernetes) FindOwner(kind, name, namespace string) (*metav1.ObjectMeta, error) {
+	owner := &metav1.ObjectMeta{}
+	return owner, k.read(lookupFile("FindOwner", kind, name, namespace), owner)
+}
+
+func (k *replayingKubernetes) ListServices() (*v1.ServiceList, error) {
+	services := &v1.ServiceList{}
+	return services, k.read(lookupFile("ListServices"), services)
+}
+
+func (k *replayingKubernetes) ServerVersion() (*version.Info, error) {
+	info := &version.Info{}
+	return info, k.read(lookupFile("ServerVersion"), info)
+}
+
+func (k *replayingKubernetes) Config() *rest.Config {
+	return &rest.Config{}
+}
+
+func (k *replayingKubernetes) SecureHTTPClient(time.Duration) (*http.Client, error) {
+	return nil, errNotRecorded
+}
+
+func (k *replayingKubernetes) FindSecret(name, namespace string) (*v1.Secret, error) {
+	return nil, errNotRecorded
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/record"
+)
+
+// Sources of the recorded responses, which are the directories they are saved under.
+const (
+	kubeletSource      = "kubelet"
+	ksmSource          = "ksm"
+	controlPlaneSource = "controlplane"
+	cadvisorSource     = "cadvisor"
+)
+
+// recording records the responses of the data sources, or replays them instead of calling the data sources, when
+// enabled by the arguments. Both the recorder and the replayer are nil otherwise.
+type recording struct {
+	recorder *record.Recorder
+	replayer *record.Replayer
+}
+
+// newRecording creates the recorder or the replayer enabled by the arguments.
+func newRecording(logger *logrus.Logger) (*recording, error) {
+	r := &recording{}
+	if args.Record != "" && args.Replay != "" {
+		return nil, errors.New("record and replay can not both be set")
+	}
+
+	var err error
+	if args.Record != "" {
+		r.recorder, err = record.NewRecorder(args.Record, logger)
+	}
+	if args.Replay != "" {
+		if args.LeaderElection {
+			return nil, errors.New("leader election can not be enabled when replaying recorded responses")
+		}
+		r.replayer, err = record.NewReplayer(args.Replay)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// replaying returns whether the data sources are replayed, in which case they must not be discovered.
+func (r *recording) replaying() bool {
+	return r.replayer != nil
+}
+
+// httpClient returns the client to call the given source with: the given one, wrapped to record its responses
+// when recording, or one replaying the recorded responses when replaying.
+func (r *recording) httpClient(source string, c client.HTTPClient) client.HTTPClient {
+	switch {
+	case r.recorder != nil:
+		return r.recorder.HTTPClient(source, c)
+	case r.replayer != nil:
+		return r.replayer.HTTPClient(source)
+	}
+	return c
+}
+
+// kubernetes returns the Kubernetes API client to look up the cluster objects with, like httpClient.
+func (r *recording) kubernetes(k8s client.Kubernetes) client.Kubernetes {
+	switch {
+	case r.recorder != nil:
+		return r.recorder.Kubernetes(k8s)
+	case r.replayer != nil:
+		return r.replayer.Kubernetes()
+	}
+	return k8s
+}
+
+// ksmSources returns the recorded kube-state-metrics sources when replaying.
+func (r *recording) ksmSources() []string {
+	if r.replayer == nil {
+		return nil
+	}
+	return r.replayer.Sources(ksmSource)
+}
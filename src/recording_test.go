@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/src/client"
+)
+
+func TestNewRecording_Errors(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+
+	testCases := map[string]argumentList{
+		"record and replay":           {Record: "/tmp/record", Replay: "/tmp/record"},
+		"replay with leader election": {Replay: "/tmp/record", LeaderElection: true},
+		"replay without a recording":  {Replay: "/nonexistent/record"},
+	}
+	for name, a := range testCases {
+		t.Run(name, func(t *testing.T) {
+			args = a
+			_, err := newRecording(logger)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewRecording_Disabled(t *testing.T) {
+	defer func(a argumentList) { args = a }(args)
+
+	args = argumentList{}
+	rec, err := newRecording(logger)
+	require.NoError(t, err)
+	assert.False(t, rec.replaying())
+
+	c := new(client.MockDiscoveredHTTPClient)
+	assert.Equal(t, c, rec.httpClient(kubeletSource, c))
+	k8s := new(client.MockedKubernetes)
+	assert.Equal(t, k8s, rec.kubernetes(k8s))
+	assert.Empty(t, rec.ksmSources())
+}
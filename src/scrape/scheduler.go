@@ -0,0 +1,181 @@
+package scrape
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// JobResult holds the outcome of a single Job run by the Scheduler.
+type JobResult struct {
+	Name     string
+	Duration time.Duration
+	TimedOut bool
+	// Skipped jobs were not run because their previous run exceeded its deadline and is still fetching the data.
+	Skipped bool
+	// Entities is the number of entities that received data from the job.
+	Entities int
+	// Metrics is the number of metrics the job populated, including attributes.
+	Metrics int
+	// RecoverableErrors counts the errors that did not prevent the job from populating data.
+	RecoverableErrors int
+	// NonRecoverableErrors counts the errors that prevented the job from populating data.
+	NonRecoverableErrors int
+	data.PopulateResult
+}
+
+// Scheduler runs scrape jobs concurrently, bounding the amount of jobs
+// running at the same time and the time each one of them is allowed to take.
+// A job is not run again while the data it fetches in a run that exceeded its
+// deadline has not been returned, so slow endpoints do not pile up requests.
+type Scheduler struct {
+	concurrency int
+	jobTimeout  time.Duration
+	logger      *logrus.Logger
+
+	lock sync.Mutex
+	// inFlight holds the name of the jobs still fetching data.
+	inFlight map[string]bool
+}
+
+// NewScheduler creates a new Scheduler. A concurrency lower than 1 means
+// that all the jobs are run at the same time. A jobTimeout of 0 disables
+// the per-job deadline.
+func NewScheduler(concurrency int, jobTimeout time.Duration, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		concurrency: concurrency,
+		jobTimeout:  jobTimeout,
+		logger:      logger,
+		inFlight:    make(map[string]bool),
+	}
+}
+
+type groupResult struct {
+	groups definition.RawGroups
+	errs   *data.ErrorGroup
+}
+
+// Run executes all the given jobs, populating the integration with their
+// data, and returns one JobResult per job in the same order they were given.
+func (s *Scheduler) Run(
+	jobs []*Job,
+	integration *sdk.IntegrationProtocol2,
+	clusterName string,
+	k8sVersion *version.Info,
+) []JobResult {
+	concurrency := s.concurrency
+	if concurrency < 1 || concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+
+	results := make([]JobResult, len(jobs))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, job *Job) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i] = s.runJob(job, integration, clusterName, k8sVersion)
+		}(i, job)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (s *Scheduler) runJob(
+	job *Job,
+	integration *sdk.IntegrationProtocol2,
+	clusterName string,
+	k8sVersion *version.Info,
+) JobResult {
+	if !s.start(job.Name) {
+		s.logger.Warnf("Skipping job %s, its previous run is still fetching data", job.Name)
+		return JobResult{
+			Name:                 job.Name,
+			Skipped:              true,
+			NonRecoverableErrors: 1,
+			PopulateResult: data.PopulateResult{
+				Errors: []error{fmt.Errorf("job %s skipped, its previous run exceeded its deadline and is still fetching data", job.Name)},
+			},
+		}
+	}
+
+	s.logger.Debugf("Running job: %s", job.Name)
+	start := time.Now()
+
+	// The channel is buffered so the grouping goroutine does not leak
+	// when the job deadline is exceeded and nobody reads its result.
+	grouped := make(chan groupResult, 1)
+	go func() {
+		defer s.finish(job.Name)
+		groups, errs := job.Grouper.Group(job.Specs)
+		grouped <- groupResult{groups: groups, errs: errs}
+	}()
+
+	var deadline <-chan time.Time
+	if s.jobTimeout > 0 {
+		timer := time.NewTimer(s.jobTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	result := JobResult{Name: job.Name}
+	select {
+	case g := <-grouped:
+		var stats populateStats
+		result.PopulateResult, stats = job.populate(g.groups, g.errs, integration, clusterName, s.logger, k8sVersion)
+		result.Entities = stats.entities
+		result.Metrics = stats.metrics
+		if g.errs != nil && !g.errs.Recoverable {
+			result.NonRecoverableErrors = len(result.Errors)
+		} else {
+			// Errors reported while populating never stop the rest of the data from being populated.
+			result.RecoverableErrors = len(result.Errors)
+			if g.errs != nil {
+				result.RecoverableErrors += len(g.errs.Errors)
+			}
+		}
+	case <-deadline:
+		result.TimedOut = true
+		result.NonRecoverableErrors = 1
+		result.PopulateResult = data.PopulateResult{
+			Errors:    []error{fmt.Errorf("job %s exceeded its deadline of %s", job.Name, s.jobTimeout)},
+			Populated: false,
+		}
+	}
+	result.Duration = time.Since(start)
+	s.logger.Debugf("Job %s took %s", job.Name, result.Duration.Round(time.Millisecond))
+
+	return result
+}
+
+// start marks the job with the given name as fetching data, unless it already was, in which case it returns false.
+func (s *Scheduler) start(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.inFlight[name] {
+		return false
+	}
+	s.inFlight[name] = true
+	return true
+}
+
+// finish marks the job with the given name as done fetching data.
+func (s *Scheduler) finish(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.inFlight, name)
+}
@@ -0,0 +1,175 @@
+package scrape
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/newrelic/nri-kubernetes/src/definition"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+var logger = logrus.StandardLogger()
+
+var testSpecs = definition.SpecGroups{
+	"test": {
+		TypeGenerator: func(_, _ string, _ definition.RawGroups, clusterName string) (string, error) {
+			return "k8s:" + clusterName + ":test", nil
+		},
+		Specs: []definition.Spec{
+			{Name: "value", ValueFunc: definition.FromRaw("value"), Type: sdkMetric.GAUGE},
+		},
+	},
+}
+
+type sleepyGrouper struct {
+	entity  string
+	delay   time.Duration
+	running *int32
+	maxSeen *int32
+}
+
+func (g *sleepyGrouper) Group(definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
+	if g.running != nil {
+		current := atomic.AddInt32(g.running, 1)
+		for {
+			seen := atomic.LoadInt32(g.maxSeen)
+			if current <= seen || atomic.CompareAndSwapInt32(g.maxSeen, seen, current) {
+				break
+			}
+		}
+		defer atomic.AddInt32(g.running, -1)
+	}
+	time.Sleep(g.delay)
+	return definition.RawGroups{
+		"test": {
+			g.entity: definition.RawMetrics{"value": 1},
+		},
+	}, nil
+}
+
+func newIntegration(t *testing.T) *sdk.IntegrationProtocol2 {
+	integration, err := sdk.NewIntegrationProtocol2("nr.test", "1.0.0", new(struct{}))
+	require.NoError(t, err)
+	return integration
+}
+
+func TestScheduler_RunsAllJobs(t *testing.T) {
+	integration := newIntegration(t)
+	jobs := []*Job{
+		NewScrapeJob("first", &sleepyGrouper{entity: "first"}, testSpecs),
+		NewScrapeJob("second", &sleepyGrouper{entity: "second"}, testSpecs),
+		NewScrapeJob("third", &sleepyGrouper{entity: "third"}, testSpecs),
+	}
+
+	results := NewScheduler(0, 0, logger).Run(jobs, integration, "cluster", &version.Info{})
+
+	require.Len(t, results, 3)
+	for i, r := range results {
+		assert.Equal(t, jobs[i].Name, r.Name)
+		assert.True(t, r.Populated)
+		assert.Empty(t, r.Errors)
+		assert.False(t, r.TimedOut)
+		// the job entity and the cluster entity, which is populated by every job
+		assert.Equal(t, 2, r.Entities)
+		// event_type, entityName, displayName, clusterName and value for the job entity,
+		// and event_type, entityName, clusterName and clusterK8sVersion for the cluster
+		assert.Equal(t, 9, r.Metrics)
+		assert.Zero(t, r.RecoverableErrors)
+		assert.Zero(t, r.NonRecoverableErrors)
+	}
+	// one entity per job plus the cluster entity
+	assert.Len(t, integration.Data, 4)
+}
+
+func TestScheduler_JobsExceedingTheDeadlineAreDiscarded(t *testing.T) {
+	integration := newIntegration(t)
+	jobs := []*Job{
+		NewScrapeJob("fast", &sleepyGrouper{entity: "fast"}, testSpecs),
+		NewScrapeJob("slow", &sleepyGrouper{entity: "slow", delay: time.Second}, testSpecs),
+	}
+
+	start := time.Now()
+	results := NewScheduler(0, 50*time.Millisecond, logger).Run(jobs, integration, "cluster", &version.Info{})
+
+	assert.True(t, time.Since(start) < time.Second)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Populated)
+	assert.False(t, results[0].TimedOut)
+	assert.False(t, results[1].Populated)
+	assert.True(t, results[1].TimedOut)
+	assert.Len(t, results[1].Errors, 1)
+	assert.Equal(t, 1, results[1].NonRecoverableErrors)
+
+	for _, e := range integration.Data {
+		assert.NotEqual(t, "slow", e.Entity.Name)
+	}
+}
+
+// blockingGrouper does not return until it is released.
+type blockingGrouper struct {
+	release chan struct{}
+}
+
+func (g *blockingGrouper) Group(definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
+	<-g.release
+	return definition.RawGroups{"test": {"blocked": {"value": 1}}}, nil
+}
+
+func TestScheduler_JobsStillFetchingAreSkipped(t *testing.T) {
+	integration := newIntegration(t)
+	grouper := &blockingGrouper{release: make(chan struct{})}
+	jobs := []*Job{NewScrapeJob("blocked", grouper, testSpecs)}
+	scheduler := NewScheduler(0, 20*time.Millisecond, logger)
+
+	results := scheduler.Run(jobs, integration, "cluster", &version.Info{})
+	require.Len(t, results, 1)
+	assert.True(t, results[0].TimedOut)
+	assert.False(t, results[0].Skipped)
+
+	// The previous run is still fetching, so the job is not run again
+	results = scheduler.Run(jobs, integration, "cluster", &version.Info{})
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Skipped)
+	assert.False(t, results[0].TimedOut)
+	assert.False(t, results[0].Populated)
+	assert.Equal(t, 1, results[0].NonRecoverableErrors)
+	require.Len(t, results[0].Errors, 1)
+	assert.Contains(t, results[0].Errors[0].Error(), "still fetching data")
+
+	close(grouper.release)
+	for i := 0; i < 100 && !scheduler.start("blocked"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	scheduler.finish("blocked")
+
+	results = scheduler.Run(jobs, integration, "cluster", &version.Info{})
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Skipped)
+	assert.True(t, results[0].Populated)
+}
+
+func TestScheduler_ConcurrencyIsCapped(t *testing.T) {
+	integration := newIntegration(t)
+	var running, maxSeen int32
+	var jobs []*Job
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		grouper := &sleepyGrouper{entity: name, delay: 20 * time.Millisecond, running: &running, maxSeen: &maxSeen}
+		jobs = append(jobs, NewScrapeJob(name, grouper, testSpecs))
+	}
+
+	results := NewScheduler(2, 0, logger).Run(jobs, integration, "cluster", &version.Info{})
+
+	require.Len(t, results, len(jobs))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxSeen))
+	for _, r := range results {
+		assert.True(t, r.Populated)
+		assert.True(t, r.Duration > 0)
+	}
+}
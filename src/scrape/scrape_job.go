@@ -1,7 +1,10 @@
 package scrape
 
 import (
+	"sync"
+
 	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/client"
 	"github.com/newrelic/nri-kubernetes/src/data"
 	"github.com/newrelic/nri-kubernetes/src/definition"
 	"github.com/newrelic/nri-kubernetes/src/metric"
@@ -9,23 +12,37 @@ import (
 	"k8s.io/apimachinery/pkg/version"
 )
 
+// populateLock serializes the population of the integration. Neither the
+// integration entities nor the SDK cache used to compute RATE and DELTA
+// metrics are safe for concurrent use, so jobs can fetch their data in
+// parallel but they have to take turns to populate it.
+var populateLock sync.Mutex
+
 // NewScrapeJob creates a new Scrape Job with the given attributes
 func NewScrapeJob(name string, grouper data.Grouper, specs definition.SpecGroups) *Job {
 	return &Job{
-		Name:    name,
-		Grouper: grouper,
-		Specs:   specs,
+		Name:      name,
+		Grouper:   grouper,
+		Specs:     specs,
+		Populator: metric.NewK8sPopulator(),
 	}
 }
 
 // Job hold all information specific to a certain Scrape Job, e.g.: where do I get the data from, and what data
 type Job struct {
-	Name    string
-	Grouper data.Grouper
-	Specs   definition.SpecGroups
+	Name      string
+	Grouper   data.Grouper
+	Specs     definition.SpecGroups
+	Populator data.Populator
+	// Client is the client the Grouper fetches the data with, if known. It is only used to describe the job.
+	Client client.HTTPClient
+	// ClusterScoped jobs collect data that is not specific to the node the integration runs on, so only the
+	// leader runs them when the leader election is enabled.
+	ClusterScoped bool
 }
 
-// Populate will get the data using the given Group, transform it, and push it to the given Integration
+// Populate will get the data using the given Group, transform it, and push it to the given Integration.
+// It is safe to call Populate from several goroutines against the same integration.
 func (s *Job) Populate(
 	integration *sdk.IntegrationProtocol2,
 	clusterName string,
@@ -33,16 +50,53 @@ func (s *Job) Populate(
 	k8sVersion *version.Info,
 ) data.PopulateResult {
 	groups, errs := s.Grouper.Group(s.Specs)
+	result, _ := s.populate(groups, errs, integration, clusterName, logger, k8sVersion)
+	return result
+}
+
+// populateStats counts what a Job has populated into the integration.
+type populateStats struct {
+	entities int
+	metrics  int
+}
+
+// statsSince counts the entities that got new metric sets since the given snapshot was taken, and the metrics
+// those new metric sets hold.
+func statsSince(integration *sdk.IntegrationProtocol2, before data.MetricSetsSnapshot) populateStats {
+	var stats populateStats
+	for _, newMetricSets := range before.NewMetricSetsPerEntity(integration) {
+		stats.entities++
+		for _, ms := range newMetricSets {
+			stats.metrics += len(ms)
+		}
+	}
+	return stats
+}
+
+// populate pushes the already grouped data to the given Integration.
+func (s *Job) populate(
+	groups definition.RawGroups,
+	errs *data.ErrorGroup,
+	integration *sdk.IntegrationProtocol2,
+	clusterName string,
+	logger *logrus.Logger,
+	k8sVersion *version.Info,
+) (data.PopulateResult, populateStats) {
 	if errs != nil && len(errs.Errors) > 0 {
 		if !errs.Recoverable {
 			return data.PopulateResult{
 				Errors:    errs.Errors,
 				Populated: false,
-			}
+			}, populateStats{}
 		}
 
 		logger.Warnf("%s", errs)
 	}
 
-	return metric.NewK8sPopulator().Populate(groups, s.Specs, integration, clusterName, k8sVersion)
+	populateLock.Lock()
+	defer populateLock.Unlock()
+
+	before := data.SnapshotMetricSets(integration)
+	result := s.Populator.Populate(groups, s.Specs, integration, clusterName, k8sVersion)
+	return result, statsSince(integration, before)
 }
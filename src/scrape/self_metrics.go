@@ -0,0 +1,99 @@
+package scrape
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/client"
+)
+
+// SelfSampleEventType is the event type of the samples describing how the integration itself behaved.
+const SelfSampleEventType = "K8sIntegrationSelfSample"
+
+type selfMetric struct {
+	name       string
+	value      interface{}
+	sourceType sdkMetric.SourceType
+}
+
+// PopulateSelfMetrics adds to the integration one K8sIntegrationSelfSample per job result, with the time the
+// job took, whether it populated any data or was skipped, how much data and how many errors it produced, the errors themselves
+// and how many jobs of the collection populated data, which is 0 when every job failed. The usage of the
+// discovery caches is added to the sample of the job with the same name as the key of discoveryCaches:
+// discoveryCacheHits and discoveryCacheMisses are the times the endpoint of the job was taken from the cache or
+// discovered again since the previous collection.
+func PopulateSelfMetrics(
+	integration *sdk.IntegrationProtocol2,
+	clusterName string,
+	nodeName string,
+	integrationVersion string,
+	results []JobResult,
+	discoveryCaches map[string]client.CacheStatsReporter,
+) error {
+	e, err := integration.Entity(nodeName, fmt.Sprintf("k8s:%s:integration", clusterName))
+	if err != nil {
+		return err
+	}
+
+	successfulJobs := 0
+	for _, r := range results {
+		if r.Populated {
+			successfulJobs++
+		}
+	}
+
+	for _, r := range results {
+		ms := e.NewMetricSet(SelfSampleEventType)
+		values := []selfMetric{
+			{"clusterName", clusterName, sdkMetric.ATTRIBUTE},
+			{"nodeName", nodeName, sdkMetric.ATTRIBUTE},
+			{"integrationVersion", integrationVersion, sdkMetric.ATTRIBUTE},
+			{"jobName", r.Name, sdkMetric.ATTRIBUTE},
+			{"jobDurationMs", float64(r.Duration) / float64(time.Millisecond), sdkMetric.GAUGE},
+			{"populated", numericBoolean(r.Populated), sdkMetric.GAUGE},
+			{"timedOut", numericBoolean(r.TimedOut), sdkMetric.GAUGE},
+			{"skipped", numericBoolean(r.Skipped), sdkMetric.GAUGE},
+			{"entitiesPopulated", r.Entities, sdkMetric.GAUGE},
+			{"metricsPopulated", r.Metrics, sdkMetric.GAUGE},
+			{"recoverableErrors", r.RecoverableErrors, sdkMetric.GAUGE},
+			{"nonRecoverableErrors", r.NonRecoverableErrors, sdkMetric.GAUGE},
+			{"successfulJobs", successfulJobs, sdkMetric.GAUGE},
+		}
+		if len(r.Errors) > 0 {
+			values = append(values, selfMetric{"errors", joinErrors(r.Errors), sdkMetric.ATTRIBUTE})
+		}
+		if cache, ok := discoveryCaches[r.Name]; ok {
+			stats := cache.CacheStats()
+			values = append(values,
+				selfMetric{"discoveryCacheHits", stats.Hits, sdkMetric.GAUGE},
+				selfMetric{"discoveryCacheMisses", stats.Misses, sdkMetric.GAUGE},
+			)
+		}
+
+		for _, v := range values {
+			if err := ms.SetMetric(v.name, v.value, v.sourceType); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinErrors(errs []error) string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func numericBoolean(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package scrape
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/metric"
+	"github.com/newrelic/infra-integrations-sdk/sdk"
+	"github.com/newrelic/nri-kubernetes/src/client"
+	"github.com/newrelic/nri-kubernetes/src/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedCacheStats client.CacheStats
+
+func (f fixedCacheStats) CacheStats() client.CacheStats {
+	return client.CacheStats(f)
+}
+
+func TestPopulateSelfMetrics(t *testing.T) {
+	integration := newIntegration(t)
+	results := []JobResult{
+		{
+			Name:              "kubelet",
+			Duration:          1500 * time.Millisecond,
+			Entities:          10,
+			Metrics:           200,
+			RecoverableErrors: 1,
+			PopulateResult: data.PopulateResult{
+				Errors:    []error{errors.New("missing metric")},
+				Populated: true,
+			},
+		},
+		{
+			Name:                 "kube-state-metrics",
+			Duration:             time.Second,
+			TimedOut:             true,
+			NonRecoverableErrors: 1,
+		},
+	}
+	caches := map[string]client.CacheStatsReporter{
+		"kubelet": fixedCacheStats{Hits: 3, Misses: 1},
+	}
+
+	err := PopulateSelfMetrics(integration, "cluster", "node", "1.0.0", results, caches)
+	require.NoError(t, err)
+
+	require.Len(t, integration.Data, 1)
+	assert.Equal(t, sdk.Entity{Name: "node", Type: "k8s:cluster:integration"}, integration.Data[0].Entity)
+	assert.Equal(t, []sdkMetric.MetricSet{
+		{
+			"event_type":           "K8sIntegrationSelfSample",
+			"entityName":           "k8s:cluster:integration:node",
+			"clusterName":          "cluster",
+			"nodeName":             "node",
+			"integrationVersion":   "1.0.0",
+			"jobName":              "kubelet",
+			"jobDurationMs":        1500.,
+			"populated":            1,
+			"timedOut":             0,
+			"skipped":              0,
+			"entitiesPopulated":    10,
+			"metricsPopulated":     200,
+			"recoverableErrors":    1,
+			"nonRecoverableErrors": 0,
+			"successfulJobs":       1,
+			"errors":               "missing metric",
+			"discoveryCacheHits":   uint64(3),
+			"discoveryCacheMisses": uint64(1),
+		},
+		{
+			"event_type":           "K8sIntegrationSelfSample",
+			"entityName":           "k8s:cluster:integration:node",
+			"clusterName":          "cluster",
+			"nodeName":             "node",
+			"integrationVersion":   "1.0.0",
+			"jobName":              "kube-state-metrics",
+			"jobDurationMs":        1000.,
+			"populated":            0,
+			"timedOut":             1,
+			"skipped":              0,
+			"entitiesPopulated":    0,
+			"metricsPopulated":     0,
+			"recoverableErrors":    0,
+			"nonRecoverableErrors": 1,
+			"successfulJobs":       1,
+		},
+	}, integration.Data[0].Metrics)
+}
+
+func TestPopulateSelfMetrics_NoJobSucceeded(t *testing.T) {
+	integration := newIntegration(t)
+	results := []JobResult{
+		{
+			Name:                 "kubelet",
+			Duration:             time.Second,
+			NonRecoverableErrors: 1,
+			PopulateResult: data.PopulateResult{
+				Errors: []error{errors.New("connection refused"), errors.New("no pods found")},
+			},
+		},
+		{
+			Name:                 "kube-state-metrics",
+			Duration:             2 * time.Second,
+			TimedOut:             true,
+			NonRecoverableErrors: 1,
+			PopulateResult: data.PopulateResult{
+				Errors: []error{errors.New("job kube-state-metrics exceeded its deadline of 2s")},
+			},
+		},
+	}
+
+	err := PopulateSelfMetrics(integration, "cluster", "node", "1.0.0", results, nil)
+	require.NoError(t, err)
+
+	require.Len(t, integration.Data, 1)
+	require.Len(t, integration.Data[0].Metrics, 2)
+	for _, ms := range integration.Data[0].Metrics {
+		assert.Equal(t, 0, ms["successfulJobs"])
+		assert.Equal(t, 0, ms["populated"])
+	}
+	assert.Equal(t, "connection refused; no pods found", integration.Data[0].Metrics[0]["errors"])
+	assert.Equal(t, "job kube-state-metrics exceeded its deadline of 2s", integration.Data[0].Metrics[1]["errors"])
+}
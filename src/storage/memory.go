@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MemoryStorage is a Storage implementation that keeps the objects in memory, serialized as JSON so they
+// are read back exactly as a JSONDiskStorage would do. It is aimed for long-running processes, where there is
+// no need to persist the data between executions.
+// This type is thread-safe.
+type MemoryStorage struct {
+	lock    sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	timestamp int64
+	value     []byte
+}
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{entries: map[string]memoryEntry{}}
+}
+
+// Write stores a value for a given key, together with the time when it was stored.
+func (m *MemoryStorage) Write(key string, value interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.entries[key] = memoryEntry{timestamp: now().Unix(), value: bytes}
+	return nil
+}
+
+// Read gets the value associated to a given key and stores it in the value referenced by the pointer passed as
+// second argument.
+func (m *MemoryStorage) Read(key string, valuePtr interface{}) (int64, error) {
+	m.lock.RLock()
+	entry, ok := m.entries[key]
+	m.lock.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("key %q not found", key)
+	}
+	if err := json.Unmarshal(entry.value, valuePtr); err != nil {
+		return 0, err
+	}
+	return entry.timestamp, nil
+}
+
+// Delete removes the stored data for the given key. If the data does not exist, it does not return any error.
+func (m *MemoryStorage) Delete(key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.entries, key)
+	return nil
+}
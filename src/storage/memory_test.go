@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	nowTime := time.Now()
+	setNow(func() time.Time {
+		return nowTime
+	})
+
+	// Given a MemoryStorage
+	var ms Storage = NewMemoryStorage()
+
+	// And a stored struct value
+	type testStruct struct {
+		FloatVal  float64
+		StringVal string
+		MapVal    map[string]string
+	}
+	stored := testStruct{1, "2", map[string]string{"hello": "how are you"}}
+	assert.Nil(t, ms.Write("my-storage-test", stored))
+
+	// When reading it
+	var read testStruct
+	ts, err := ms.Read("my-storage-test", &read)
+
+	// The same value is returned
+	assert.Nil(t, err)
+	assert.Equal(t, stored, read)
+	assert.Equal(t, nowTime.Unix(), ts)
+
+	// And modifying the original value does not modify the stored one
+	stored.MapVal["hello"] = "modified"
+	read = testStruct{}
+	_, err = ms.Read("my-storage-test", &read)
+	assert.Nil(t, err)
+	assert.Equal(t, "how are you", read.MapVal["hello"])
+
+	// When the value is deleted it can not be read anymore
+	assert.Nil(t, ms.Delete("my-storage-test"))
+	_, err = ms.Read("my-storage-test", &read)
+	assert.NotNil(t, err)
+
+	// And deleting an unexisting key does not fail
+	assert.Nil(t, ms.Delete("my-storage-test"))
+}